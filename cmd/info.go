@@ -0,0 +1,112 @@
+/*
+Copyright © 2023 Andrew Denton <ventifus@flying-snail.net>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/ventifus/binmgr/pkg/manifest"
+)
+
+var infoCmd = &cobra.Command{
+	Use:   "info PACKAGE",
+	Short: "Show how an installed package was verified",
+	Long:  `Show an installed package's manifest: its source and version, and for each asset the checksums it was verified against, where they came from, the strategy that supplied them, any verified signer and provenance, and the files installed from it.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runInfo,
+}
+
+func runInfo(cmd *cobra.Command, args []string) error {
+	pkg, err := mgr.Info(context.Background(), args[0])
+	if err != nil {
+		return err
+	}
+	printInfo(os.Stdout, pkg)
+	return nil
+}
+
+// printInfo writes pkg's manifest in a readable form. Fields that were not
+// recorded are left out, except that a missing signer is stated.
+func printInfo(w io.Writer, pkg *manifest.Package) {
+	field := func(indent, label, value string) {
+		if value != "" {
+			fmt.Fprintf(w, "%s%-*s %s\n", indent, 20-len(indent), label+":", value)
+		}
+	}
+
+	field("", "Package", pkg.ID)
+	version := pkg.Version
+	if pkg.Pinned {
+		version += "  [pinned]"
+	}
+	field("", "Version", version)
+	field("", "Backend", pkg.Backend)
+	field("", "Source", pkg.SourceURL)
+	field("", "Constraint", pkg.Constraint)
+	if p := pkg.Provenance; p != nil {
+		required := "required"
+		if p.Builder != "" {
+			required += " from " + p.Builder
+		}
+		field("", "Provenance", required)
+	}
+
+	for _, spec := range pkg.Specs {
+		fmt.Fprintln(w)
+		field("", "Asset", spec.AssetGlob)
+		strategy := spec.Checksum.Strategy
+		if a := spec.Asset; a != nil {
+			field("  ", "URL", a.URL)
+			if a.ChecksumStrategy != "" && a.ChecksumStrategy != strategy {
+				strategy = fmt.Sprintf("%s (configured: %s)", a.ChecksumStrategy, spec.Checksum.Strategy)
+			}
+			field("  ", "Checksum strategy", strategy)
+			field("  ", "Checksum source", a.ChecksumSourceURL)
+			field("  ", "Verified", strings.Join(a.VerifiedAlgorithms, ", "))
+			algos := make([]string, 0, len(a.Checksums))
+			for algo := range a.Checksums {
+				algos = append(algos, algo)
+			}
+			sort.Strings(algos)
+			for _, algo := range algos {
+				field("  ", algo, a.Checksums[algo])
+			}
+			signedBy := a.SignedBy
+			if signedBy == "" {
+				signedBy = "no signature checked"
+			}
+			field("  ", "Signed by", signedBy)
+			field("  ", "Builder", a.Builder)
+			field("  ", "Source commit", a.SourceCommit)
+		} else {
+			field("  ", "Checksum strategy", strategy)
+		}
+		for _, f := range spec.InstalledFiles {
+			field("  ", "Installed", f.LocalPath)
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(infoCmd)
+}
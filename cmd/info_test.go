@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ventifus/binmgr/pkg/manifest"
+)
+
+func TestInfoCmd_CommandRegistered(t *testing.T) {
+	found := false
+	for _, sub := range rootCmd.Commands() {
+		if sub.Name() == "info" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("info command not registered on rootCmd")
+	}
+}
+
+func TestPrintInfo(t *testing.T) {
+	pkg := &manifest.Package{
+		ID:        "github.com/casey/just",
+		Backend:   "github",
+		SourceURL: "https://github.com/casey/just",
+		Version:   "1.46.0",
+		Specs: []manifest.InstallSpec{{
+			AssetGlob: "just-${VERSION}-x86_64-unknown-linux-musl.tar.gz",
+			Checksum:  manifest.ChecksumConfig{Strategy: "auto"},
+			Asset: &manifest.DownloadedAsset{
+				URL:                "https://github.com/casey/just/releases/download/1.46.0/just-1.46.0-x86_64-unknown-linux-musl.tar.gz",
+				Checksums:          map[string]string{"sha-256": "79966e6e"},
+				ChecksumSourceURL:  "https://github.com/casey/just/releases/download/1.46.0/SHA256SUMS",
+				ChecksumStrategy:   "shared-file",
+				VerifiedAlgorithms: []string{"sha-256"},
+			},
+			InstalledFiles: []manifest.InstalledFile{{SourcePath: "just", LocalPath: "/home/user/.local/bin/just"}},
+		}},
+	}
+
+	var buf bytes.Buffer
+	printInfo(&buf, pkg)
+	out := buf.String()
+	for _, want := range []string{
+		"  Checksum strategy: shared-file (configured: auto)\n",
+		"  Checksum source:   https://github.com/casey/just/releases/download/1.46.0/SHA256SUMS\n",
+		"  Verified:          sha-256\n",
+		"  sha-256:           79966e6e\n",
+		"  Signed by:         no signature checked\n",
+		"  Installed:         /home/user/.local/bin/just\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Builder:") {
+		t.Errorf("unrecorded builder should be left out:\n%s", out)
+	}
+}
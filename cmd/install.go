@@ -4,15 +4,21 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/ventifus/binmgr/pkg/manager"
+	"github.com/ventifus/binmgr/pkg/manifest"
+	"github.com/ventifus/binmgr/pkg/verify"
+	"github.com/ventifus/binmgr/pkg/version"
 )
 
 var installCmd = &cobra.Command{
-	Use:   "install URL[@VERSION]",
+	Use:   "install URL[@VERSION|@id:ID]",
 	Short: "Download and install a package",
 	Args:  cobra.ExactArgs(1),
 	RunE:  runInstall,
@@ -20,11 +26,26 @@ var installCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(installCmd)
-	installCmd.Flags().StringArrayP("file", "f", nil, "Install spec: ASSET_GLOB[!TRAVERSAL_GLOB...][@LOCAL_NAME] (repeatable; at least one required)")
-	installCmd.Flags().String("checksum", "auto", "Checksum strategy (auto|none|shared-file:GLOB|per-asset:SUFFIX|multisum[:DATA[:ORDER]]|embedded:GLOB)")
+	installCmd.Flags().StringArrayP("file", "f", nil, "Install spec: ASSET_GLOB[!TRAVERSAL_GLOB...][@LOCAL_NAME] (repeatable; default: pick the asset for this platform)")
+	installCmd.Flags().String("checksum", "auto", "Checksum strategy (auto|none|shared-file:GLOB|per-asset:SUFFIX|multisum[:DATA[:ORDER]]|embedded:GLOB|sigstore[:GLOB])")
+	installCmd.Flags().String("signature-key", "", "sigstore: PEM public key file the signature must verify with")
+	installCmd.Flags().String("certificate-identity", "", "sigstore: email address or URI the signing certificate must be issued to")
+	installCmd.Flags().String("certificate-oidc-issuer", "", "sigstore: OIDC issuer the signing certificate must name")
+	installCmd.Flags().String("signature-roots", "", "sigstore: PEM file of CA certificates the signing certificate must chain to")
+	installCmd.Flags().String("rekor-key", "", "sigstore: PEM public key file of the transparency log, to trust the time a bundle was logged")
+	installCmd.Flags().String("checksum-keyring", "", "auto, shared-file, multisum: OpenPGP public keyring file the checksum file must be signed with")
+	installCmd.Flags().String("checksum-minisign-key", "", "auto, shared-file, multisum: minisign public key (or .pub file) the checksum file must be signed with")
 	installCmd.Flags().String("dir", "", "Default install directory (default: ~/.local/bin/)")
-	installCmd.Flags().String("type", "", "Backend override: github | shasumurl | kubeurl")
+	installCmd.Flags().String("type", "", "Backend override: github | gitlab | gitea | shasumurl | kubeurl")
+	installCmd.Flags().String("channel", "stable", "Releases to follow: stable | prerelease | draft (github only)")
+	installCmd.Flags().String("tag-glob", "", "Only follow releases whose tag matches this glob (github only)")
+	installCmd.Flags().String("tag-regex", "", "Only follow releases whose tag matches this regular expression (github only)")
+	installCmd.Flags().String("constraint", "", "Only follow releases satisfying this version constraint, e.g. ~1.29 or <2.0")
+	installCmd.Flags().String("min-release-age", "", "Only follow releases at least this old, e.g. 7d or 36h (default: min_release_age from config)")
+	installCmd.Flags().String("provenance-key", "", "Require SLSA provenance (.intoto.jsonl) signed with this PEM public key file for each asset")
+	installCmd.Flags().String("provenance-builder", "", "Builder ID the provenance must name; without @REF any ref matches (requires --provenance-key)")
 	installCmd.Flags().Bool("pin", false, "Pin this package to the installed version")
+	installCmd.Flags().Bool("force", false, "Reinstall even if this version is already installed and intact")
 }
 
 // parseURL extracts the URL and optional version from a "URL[@VERSION]" argument.
@@ -46,6 +67,20 @@ func parseURL(arg string) (rawURL, version string) {
 	return rawURL, version
 }
 
+// parseReleaseID splits a version of the form "id:ID" into a numeric GitHub
+// release ID. Any other version is returned unchanged with a zero ID.
+func parseReleaseID(version string) (string, int64, error) {
+	rest, ok := strings.CutPrefix(version, "id:")
+	if !ok {
+		return version, 0, nil
+	}
+	id, err := strconv.ParseInt(rest, 10, 64)
+	if err != nil || id <= 0 {
+		return "", 0, fmt.Errorf("invalid release ID %q: expected id:NUMBER", version)
+	}
+	return "", id, nil
+}
+
 // parseFileSpec parses a single --file value: ASSET_GLOB[!TRAVERSAL_GLOB...][@LOCAL_NAME]
 func parseFileSpec(spec string) (assetGlob string, traversalGlobs []string, localName string) {
 	// Split on last '@' to extract optional LocalName.
@@ -114,30 +149,169 @@ func parseChecksumStrategy(value string) (manager.ChecksumOpts, error) {
 		}
 		return manager.ChecksumOpts{Strategy: "embedded", TraversalGlob: traversalGlob}, nil
 
+	case "sigstore":
+		fileGlob := ""
+		if len(parts) >= 2 {
+			fileGlob = parts[1]
+		}
+		return manager.ChecksumOpts{Strategy: "sigstore", FileGlob: fileGlob}, nil
+
 	default:
 		return manager.ChecksumOpts{}, fmt.Errorf(
-			"invalid checksum strategy %q: valid strategies are auto, none, shared-file:GLOB, per-asset:SUFFIX, multisum[:DATA[:ORDER]], embedded:GLOB",
+			"invalid checksum strategy %q: valid strategies are auto, none, shared-file:GLOB, per-asset:SUFFIX, multisum[:DATA[:ORDER]], embedded:GLOB, sigstore[:GLOB]",
 			value,
 		)
 	}
 }
 
+// parseSigner builds the signer the "sigstore" strategy accepts from the
+// --signature-key, --certificate-identity, --certificate-oidc-issuer,
+// --signature-roots and --rekor-key flag values, reading the key, roots and
+// log key files. Either a key, or an identity, issuer and roots, are
+// required.
+func parseSigner(keyFile, identity, issuer, rootsFile, rekorFile string) (*manifest.Signer, error) {
+	s := &manifest.Signer{Identity: identity, Issuer: issuer}
+	if keyFile != "" {
+		b, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read --signature-key: %w", err)
+		}
+		s.Key = string(b)
+	}
+	if rootsFile != "" {
+		b, err := os.ReadFile(rootsFile)
+		if err != nil {
+			return nil, fmt.Errorf("read --signature-roots: %w", err)
+		}
+		s.Roots = string(b)
+	}
+	if rekorFile != "" {
+		b, err := os.ReadFile(rekorFile)
+		if err != nil {
+			return nil, fmt.Errorf("read --rekor-key: %w", err)
+		}
+		s.Rekor = string(b)
+	}
+	if s.Key == "" && (s.Identity == "" || s.Issuer == "" || s.Roots == "") {
+		return nil, fmt.Errorf("--checksum sigstore requires --signature-key, or --certificate-identity, --certificate-oidc-issuer and --signature-roots")
+	}
+	return s, nil
+}
+
+// parseChecksumSigner builds the signer a checksum file must carry from the
+// --checksum-keyring and --checksum-minisign-key flag values. The keyring is
+// read from a file; the minisign key may be given inline or as a ".pub" file.
+// It returns nil when neither flag is set.
+func parseChecksumSigner(keyringFile, minisignKey string) (*manifest.Signer, error) {
+	switch {
+	case keyringFile != "" && minisignKey != "":
+		return nil, fmt.Errorf("--checksum-keyring and --checksum-minisign-key are mutually exclusive")
+	case keyringFile != "":
+		b, err := os.ReadFile(keyringFile)
+		if err != nil {
+			return nil, fmt.Errorf("read --checksum-keyring: %w", err)
+		}
+		keyring, err := verify.ArmorKeyring(b)
+		if err != nil {
+			return nil, fmt.Errorf("--checksum-keyring: %w", err)
+		}
+		return &manifest.Signer{Keyring: keyring}, nil
+	case minisignKey != "":
+		if b, err := os.ReadFile(minisignKey); err == nil {
+			minisignKey = string(b)
+		}
+		if _, err := verify.ParseMinisignKey(minisignKey); err != nil {
+			return nil, fmt.Errorf("--checksum-minisign-key: %w", err)
+		}
+		return &manifest.Signer{Minisign: strings.TrimSpace(minisignKey) + "\n"}, nil
+	}
+	return nil, nil
+}
+
+// parseProvenance builds the provenance requirement from the
+// --provenance-key and --provenance-builder flag values, reading the key
+// file. It returns nil when neither flag is set.
+func parseProvenance(keyFile, builder string) (*manifest.ProvenanceConfig, error) {
+	if keyFile == "" {
+		if builder != "" {
+			return nil, fmt.Errorf("--provenance-builder requires --provenance-key")
+		}
+		return nil, nil
+	}
+	b, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("read --provenance-key: %w", err)
+	}
+	return &manifest.ProvenanceConfig{Key: string(b), Builder: builder}, nil
+}
+
+// validateConstraint reports a malformed version constraint before any
+// network access. An empty constraint is valid.
+func validateConstraint(expr string) error {
+	if expr == "" {
+		return nil
+	}
+	_, err := version.ParseConstraint(expr)
+	return err
+}
+
+// validateReleaseAge reports a malformed minimum release age. An empty age
+// is valid.
+func validateReleaseAge(age string) error {
+	if age == "" {
+		return nil
+	}
+	_, err := manifest.ParseAge(age)
+	return err
+}
+
+// parseChannel converts the --channel, --tag-glob and --tag-regex flag values
+// into a release channel. Following stable releases with no tag filter
+// returns nil.
+func parseChannel(channel, tagGlob, tagRegex string) (*manifest.Channel, error) {
+	c := manifest.Channel{TagGlob: tagGlob, TagRegex: tagRegex}
+	switch channel {
+	case "", "stable":
+	case "prerelease":
+		c.Prerelease = true
+	case "draft":
+		c.Prerelease = true
+		c.Draft = true
+	default:
+		return nil, fmt.Errorf("invalid channel %q: valid channels are stable, prerelease, draft", channel)
+	}
+	if tagGlob != "" {
+		if _, err := path.Match(tagGlob, ""); err != nil {
+			return nil, fmt.Errorf("invalid --tag-glob %q: %w", tagGlob, err)
+		}
+	}
+	if tagRegex != "" {
+		if _, err := regexp.Compile(tagRegex); err != nil {
+			return nil, fmt.Errorf("invalid --tag-regex %q: %w", tagRegex, err)
+		}
+	}
+	if c == (manifest.Channel{}) {
+		return nil, nil
+	}
+	return &c, nil
+}
+
 func runInstall(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Minute)
 	defer cancel()
 
-	// Parse URL[@VERSION].
+	// Parse URL[@VERSION] or URL[@id:ID].
 	sourceURL, version := parseURL(args[0])
+	version, releaseID, err := parseReleaseID(version)
+	if err != nil {
+		return err
+	}
 
 	// Parse --file flags.
 	fileSpecs, err := cmd.Flags().GetStringArray("file")
 	if err != nil {
 		return err
 	}
-	if len(fileSpecs) == 0 {
-		return fmt.Errorf("at least one --file flag is required")
-	}
-
 	// Parse --checksum.
 	checksumValue, err := cmd.Flags().GetString("checksum")
 	if err != nil {
@@ -148,6 +322,59 @@ func runInstall(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Parse --signature-key, --certificate-identity, --certificate-oidc-issuer
+	// --signature-roots and --rekor-key.
+	signatureKey, err := cmd.Flags().GetString("signature-key")
+	if err != nil {
+		return err
+	}
+	certIdentity, err := cmd.Flags().GetString("certificate-identity")
+	if err != nil {
+		return err
+	}
+	certIssuer, err := cmd.Flags().GetString("certificate-oidc-issuer")
+	if err != nil {
+		return err
+	}
+	signatureRoots, err := cmd.Flags().GetString("signature-roots")
+	if err != nil {
+		return err
+	}
+	rekorKey, err := cmd.Flags().GetString("rekor-key")
+	if err != nil {
+		return err
+	}
+	if checksumOpts.Strategy == "sigstore" {
+		checksumOpts.Signer, err = parseSigner(signatureKey, certIdentity, certIssuer, signatureRoots, rekorKey)
+		if err != nil {
+			return err
+		}
+	} else if signatureKey != "" || certIdentity != "" || certIssuer != "" || signatureRoots != "" || rekorKey != "" {
+		return fmt.Errorf("--signature-key, --certificate-identity, --certificate-oidc-issuer, --signature-roots and --rekor-key require --checksum sigstore")
+	}
+
+	// Parse --checksum-keyring and --checksum-minisign-key.
+	checksumKeyring, err := cmd.Flags().GetString("checksum-keyring")
+	if err != nil {
+		return err
+	}
+	checksumMinisignKey, err := cmd.Flags().GetString("checksum-minisign-key")
+	if err != nil {
+		return err
+	}
+	checksumSigner, err := parseChecksumSigner(checksumKeyring, checksumMinisignKey)
+	if err != nil {
+		return err
+	}
+	if checksumSigner != nil {
+		switch checksumOpts.Strategy {
+		case "auto", "shared-file", "multisum":
+			checksumOpts.Signer = checksumSigner
+		default:
+			return fmt.Errorf("--checksum-keyring and --checksum-minisign-key require --checksum auto, shared-file or multisum")
+		}
+	}
+
 	// Parse --dir.
 	defaultDir, err := cmd.Flags().GetString("dir")
 	if err != nil {
@@ -165,13 +392,77 @@ func runInstall(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Parse --channel, --tag-glob and --tag-regex.
+	channelValue, err := cmd.Flags().GetString("channel")
+	if err != nil {
+		return err
+	}
+	tagGlob, err := cmd.Flags().GetString("tag-glob")
+	if err != nil {
+		return err
+	}
+	tagRegex, err := cmd.Flags().GetString("tag-regex")
+	if err != nil {
+		return err
+	}
+	channel, err := parseChannel(channelValue, tagGlob, tagRegex)
+	if err != nil {
+		return err
+	}
+
+	// Parse --constraint.
+	constraint, err := cmd.Flags().GetString("constraint")
+	if err != nil {
+		return err
+	}
+	if err := validateConstraint(constraint); err != nil {
+		return err
+	}
+
+	// Parse --min-release-age.
+	minReleaseAge, err := cmd.Flags().GetString("min-release-age")
+	if err != nil {
+		return err
+	}
+	if err := validateReleaseAge(minReleaseAge); err != nil {
+		return err
+	}
+	defaultAge, err := defaultMinReleaseAge()
+	if err != nil {
+		return err
+	}
+
+	// Parse --provenance-key and --provenance-builder.
+	provenanceKey, err := cmd.Flags().GetString("provenance-key")
+	if err != nil {
+		return err
+	}
+	provenanceBuilder, err := cmd.Flags().GetString("provenance-builder")
+	if err != nil {
+		return err
+	}
+	provenance, err := parseProvenance(provenanceKey, provenanceBuilder)
+	if err != nil {
+		return err
+	}
+
 	// Parse --pin.
 	pin, err := cmd.Flags().GetBool("pin")
 	if err != nil {
 		return err
 	}
 
-	// Build SpecOpts for each --file.
+	// Parse --force.
+	force, err := cmd.Flags().GetBool("force")
+	if err != nil {
+		return err
+	}
+
+	// Build SpecOpts for each --file. Without --file, one spec with an empty
+	// asset glob asks the manager to pick the asset for this platform.
+	if len(fileSpecs) == 0 {
+		fileSpecs = []string{""}
+	}
 	specs := make([]manager.SpecOpts, 0, len(fileSpecs))
 	for _, raw := range fileSpecs {
 		assetGlob, traversalGlobs, localName := parseFileSpec(raw)
@@ -184,12 +475,20 @@ func runInstall(cmd *cobra.Command, args []string) error {
 	}
 
 	opts := manager.InstallOptions{
-		SourceURL:   sourceURL,
-		Version:     version,
-		Specs:       specs,
-		DefaultDir:  defaultDir,
-		BackendType: backendType,
-		Pin:         pin,
+		SourceURL:     sourceURL,
+		Version:       version,
+		ReleaseID:     releaseID,
+		Channel:       channel,
+		Constraint:    constraint,
+		MinReleaseAge: minReleaseAge,
+		Provenance:    provenance,
+		Specs:         specs,
+		DefaultDir:    defaultDir,
+		BackendType:   backendType,
+		Pin:           pin,
+		Force:         force,
+
+		DefaultMinReleaseAge: defaultAge,
 	}
 
 	if err := mgr.Install(ctx, opts); err != nil {
@@ -1,6 +1,10 @@
 package cmd
 
 import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -233,9 +237,192 @@ func TestParseChecksumStrategy_Embedded(t *testing.T) {
 	}
 }
 
+func TestParseChecksumStrategy_Sigstore(t *testing.T) {
+	opts, err := parseChecksumStrategy("sigstore:SHA256SUMS")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Strategy != "sigstore" {
+		t.Errorf("expected strategy sigstore, got %q", opts.Strategy)
+	}
+	if opts.FileGlob != "SHA256SUMS" {
+		t.Errorf("expected FileGlob SHA256SUMS, got %q", opts.FileGlob)
+	}
+}
+
+func TestParseSigner(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "cosign.pub")
+	if err := os.WriteFile(keyFile, []byte("-----BEGIN PUBLIC KEY-----\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := parseSigner(keyFile, "", "", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Key != "-----BEGIN PUBLIC KEY-----\n" {
+		t.Errorf("expected the key file's content, got %q", s.Key)
+	}
+
+	rekorFile := filepath.Join(dir, "rekor.pub")
+	if err := os.WriteFile(rekorFile, []byte("-----BEGIN PUBLIC KEY-----\nrekor\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s, err = parseSigner("", "release@example.com", "https://accounts.example.com", keyFile, rekorFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Rekor != "-----BEGIN PUBLIC KEY-----\nrekor\n" {
+		t.Errorf("expected the log key file's content, got %q", s.Rekor)
+	}
+
+	if _, err := parseSigner("", "release@example.com", "https://accounts.example.com", "", ""); err == nil {
+		t.Error("expected error for a certificate identity without trusted roots")
+	}
+	if _, err := parseSigner("", "", "", "", ""); err == nil {
+		t.Error("expected error with no signer")
+	}
+}
+
+func TestParseChecksumSigner(t *testing.T) {
+	// "Ed", an 8-byte key ID and a 32-byte Ed25519 public key.
+	key := base64.StdEncoding.EncodeToString(append([]byte("Ed\x01\x02\x03\x04\x05\x06\x07\x08"), make([]byte, 32)...))
+	pubFile := filepath.Join(t.TempDir(), "minisign.pub")
+	if err := os.WriteFile(pubFile, []byte("untrusted comment: minisign public key\n"+key+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []string{key, pubFile} {
+		s, err := parseChecksumSigner("", v)
+		if err != nil {
+			t.Fatalf("parseChecksumSigner(%q): unexpected error: %v", v, err)
+		}
+		if !strings.Contains(s.Minisign, key) {
+			t.Errorf("expected the minisign key to be recorded, got %q", s.Minisign)
+		}
+	}
+
+	if s, err := parseChecksumSigner("", ""); err != nil || s != nil {
+		t.Errorf("expected no signer without flags, got %+v, %v", s, err)
+	}
+	if _, err := parseChecksumSigner("", "not-a-key"); err == nil {
+		t.Error("expected error for a malformed minisign key")
+	}
+	if _, err := parseChecksumSigner(pubFile, key); err == nil {
+		t.Error("expected error with both a keyring and a minisign key")
+	}
+}
+
+func TestParseProvenance(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "provenance.pub")
+	if err := os.WriteFile(keyFile, []byte("-----BEGIN PUBLIC KEY-----\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := parseProvenance(keyFile, "https://github.com/example/builder")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Key != "-----BEGIN PUBLIC KEY-----\n" || p.Builder != "https://github.com/example/builder" {
+		t.Errorf("got %+v", p)
+	}
+
+	if p, err := parseProvenance("", ""); err != nil || p != nil {
+		t.Errorf("expected no requirement without flags, got %+v, %v", p, err)
+	}
+	if _, err := parseProvenance("", "https://github.com/example/builder"); err == nil {
+		t.Error("expected error for a builder without a key")
+	}
+}
+
 func TestParseChecksumStrategy_Invalid(t *testing.T) {
 	_, err := parseChecksumStrategy("bogus")
 	if err == nil {
 		t.Error("expected error for invalid strategy")
 	}
 }
+
+func TestParseChannel_StableIsNil(t *testing.T) {
+	c, err := parseChannel("stable", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c != nil {
+		t.Errorf("expected nil channel for stable, got %+v", c)
+	}
+}
+
+func TestParseChannel_Prerelease(t *testing.T) {
+	c, err := parseChannel("prerelease", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c == nil || !c.Prerelease || c.Draft {
+		t.Errorf("expected prerelease channel, got %+v", c)
+	}
+}
+
+func TestParseChannel_DraftIncludesPrereleases(t *testing.T) {
+	c, err := parseChannel("draft", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c == nil || !c.Prerelease || !c.Draft {
+		t.Errorf("expected draft channel including prereleases, got %+v", c)
+	}
+}
+
+func TestParseChannel_TagFilters(t *testing.T) {
+	c, err := parseChannel("stable", "knative-v*", `^knative-v1\.`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c == nil || c.TagGlob != "knative-v*" || c.TagRegex != `^knative-v1\.` || c.Prerelease {
+		t.Errorf("unexpected channel %+v", c)
+	}
+}
+
+func TestParseChannel_Invalid(t *testing.T) {
+	for _, tt := range []struct{ channel, glob, re string }{
+		{"nightly", "", ""},
+		{"stable", "[", ""},
+		{"stable", "", "("},
+	} {
+		if _, err := parseChannel(tt.channel, tt.glob, tt.re); err == nil {
+			t.Errorf("parseChannel(%q, %q, %q): expected error", tt.channel, tt.glob, tt.re)
+		}
+	}
+}
+
+func TestParseURL_WithReleaseID(t *testing.T) {
+	rawURL, version := parseURL("github.com/casey/just@id:123456")
+	if rawURL != "https://github.com/casey/just" {
+		t.Errorf("unexpected URL %q", rawURL)
+	}
+	version, id, err := parseReleaseID(version)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version != "" || id != 123456 {
+		t.Errorf("expected release ID 123456 and no version, got %q, %d", version, id)
+	}
+}
+
+func TestParseReleaseID_PlainVersionUnchanged(t *testing.T) {
+	version, id, err := parseReleaseID("1.40.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version != "1.40.0" || id != 0 {
+		t.Errorf("expected version 1.40.0 and no ID, got %q, %d", version, id)
+	}
+}
+
+func TestParseReleaseID_Invalid(t *testing.T) {
+	for _, v := range []string{"id:", "id:abc", "id:-5", "id:0"} {
+		if _, _, err := parseReleaseID(v); err == nil {
+			t.Errorf("parseReleaseID(%q): expected error", v)
+		}
+	}
+}
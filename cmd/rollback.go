@@ -0,0 +1,52 @@
+/*
+Copyright © 2023 Andrew Denton <ventifus@flying-snail.net>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/ventifus/binmgr/pkg/manager"
+)
+
+var rollbackTo string
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback PACKAGE [--to VERSION]",
+	Short: "Restore a previously installed version of a package",
+	Long:  `Restore the files and manifest of a previously installed version of a package from its local history, without contacting the backend. With no --to, the most recent previous version is restored.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRollback,
+}
+
+func runRollback(cmd *cobra.Command, args []string) error {
+	result, err := mgr.Rollback(context.Background(), manager.RollbackOptions{
+		ID:      args[0],
+		Version: rollbackTo,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s  %s → %s\n", result.ID, result.OldVersion, result.NewVersion)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+	rollbackCmd.Flags().StringVar(&rollbackTo, "to", "", "Version to restore; default is the most recent previous version")
+}
@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"testing"
+)
+
+// Rollback behavior is covered in pkg/manager; this only checks wiring.
+func TestRollbackCmd_CommandRegistered(t *testing.T) {
+	found := false
+	for _, sub := range rootCmd.Commands() {
+		if sub.Name() == "rollback" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("rollback command not registered on rootCmd")
+	}
+}
@@ -52,9 +52,14 @@ func Execute() {
 	}
 }
 
+// buildRegistry registers every backend. github_hosts, gitlab_hosts and
+// gitea_hosts in the config name further hosts to treat as GitHub Enterprise
+// Server, as self-hosted GitLab, and as Gitea or Forgejo.
 func buildRegistry() *backend.Registry {
 	r := backend.NewRegistry()
-	r.Register(backend.NewGitHubBackend())
+	r.Register(backend.NewGitHubBackend(viper.GetStringSlice("github_hosts")...))
+	r.Register(backend.NewGitLabBackend(viper.GetStringSlice("gitlab_hosts")...))
+	r.Register(backend.NewGiteaBackend(viper.GetStringSlice("gitea_hosts")...))
 	r.Register(backend.NewKubeBackend())
 	r.Register(backend.NewShasumBackend())
 	return r
@@ -64,14 +69,6 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	rootCmd.PersistentFlags().StringVar(&loglevel, "loglevel", "warn", "Log level")
-
-	mgr = manager.New(
-		buildRegistry(),
-		fetch.NewFetcher(),
-		extract.NewExtractor(),
-		verify.NewVerifier(),
-		manifest.LibDir(),
-	)
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -101,4 +98,24 @@ func initConfig() {
 	} else {
 		log.WithError(err).Debug("no config file found")
 	}
+
+	// The manager is built once the config is read, since the checksum
+	// policy comes from it.
+	mgr = manager.New(
+		buildRegistry(),
+		fetch.NewFetcher(),
+		extract.NewExtractor(),
+		verify.NewVerifierWithPolicy(verify.Policy{AllowWeak: viper.GetBool("allow_weak_checksums")}),
+		manifest.LibDir(),
+	)
+}
+
+// defaultMinReleaseAge returns the min_release_age config setting, which
+// applies to packages without a minimum release age of their own.
+func defaultMinReleaseAge() (string, error) {
+	age := viper.GetString("min_release_age")
+	if err := validateReleaseAge(age); err != nil {
+		return "", fmt.Errorf("config min_release_age: %w", err)
+	}
+	return age, nil
 }
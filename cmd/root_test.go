@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestBuildRegistry_ConfiguredHosts(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GH_HOST", "")
+	t.Setenv("GITLAB_HOST", "")
+	viper.Set("github_hosts", []string{"github.corp.example"})
+	viper.Set("gitlab_hosts", []string{"gitlab.corp.example"})
+	viper.Set("gitea_hosts", []string{"git.example.com"})
+	defer viper.Reset()
+
+	r := buildRegistry()
+	for rawURL, want := range map[string]string{
+		"https://github.corp.example/team/tool": "github",
+		"https://gitlab.corp.example/team/tool": "gitlab",
+		"https://git.example.com/team/tool":     "gitea",
+	} {
+		u, _ := url.Parse(rawURL)
+		b, err := r.Dispatch(u)
+		if err != nil {
+			t.Errorf("Dispatch(%q): %v", rawURL, err)
+			continue
+		}
+		if got := b.Type(); got != want {
+			t.Errorf("Dispatch(%q) = %q, want %q", rawURL, got, want)
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/ventifus/binmgr/pkg/manager"
 )
 
 var statusCmd = &cobra.Command{
@@ -32,7 +33,15 @@ var statusCmd = &cobra.Command{
 }
 
 func runStatus(cmd *cobra.Command, args []string) {
-	results, err := mgr.Status(context.Background(), args)
+	defaultAge, err := defaultMinReleaseAge()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	results, err := mgr.Status(context.Background(), manager.StatusOptions{
+		Packages:             args,
+		DefaultMinReleaseAge: defaultAge,
+	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
@@ -40,15 +49,25 @@ func runStatus(cmd *cobra.Command, args []string) {
 
 	anyUpdates := false
 	for _, result := range results {
-		pinnedStr := ""
+		suffix := ""
 		if result.Pinned {
-			pinnedStr = "  [pinned]"
+			suffix = "  [pinned]"
+		}
+		if result.LatestOverall != "" && result.LatestOverall != result.LatestVersion {
+			// LatestVersion is the newest the constraint allows.
+			suffix += fmt.Sprintf("  [latest overall %s]", result.LatestOverall)
+		}
+		if result.HeldBack != "" {
+			suffix += fmt.Sprintf("  [%s held back: too new]", result.HeldBack)
 		}
-		if result.UpdateAvailable {
+		switch {
+		case result.UpdateAvailable:
 			anyUpdates = true
-			fmt.Printf("%-50s %-20s → %-20s%s\n", result.ID, result.InstalledVersion, result.LatestVersion, pinnedStr)
-		} else {
-			fmt.Printf("%-50s %-20s up to date%s\n", result.ID, result.InstalledVersion, pinnedStr)
+			fmt.Printf("%-50s %-20s → %-20s%s\n", result.ID, result.InstalledVersion, result.LatestVersion, suffix)
+		case result.Change == manager.VersionOlder:
+			fmt.Printf("%-50s %-20s latest %s is older%s\n", result.ID, result.InstalledVersion, result.LatestVersion, suffix)
+		default:
+			fmt.Printf("%-50s %-20s up to date%s\n", result.ID, result.InstalledVersion, suffix)
 		}
 	}
 
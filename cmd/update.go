@@ -27,9 +27,12 @@ import (
 
 var updatePin bool
 var updateUnpin bool
+var updateAllowDowngrade bool
+var updateConstraint string
+var updateMinReleaseAge string
 
 var updateCmd = &cobra.Command{
-	Use:   "update [PACKAGE[@VERSION]...] [flags]",
+	Use:   "update [PACKAGE[@VERSION|@id:ID]...] [flags]",
 	Short: "Update installed packages to their latest versions",
 	Long:  `Update installed packages. With no arguments, updates all non-pinned packages. Named packages are always updated, even if pinned.`,
 	RunE:  runUpdate,
@@ -42,6 +45,30 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	if (updatePin || updateUnpin) && len(args) == 0 {
 		return fmt.Errorf("--pin/--unpin require at least one package name")
 	}
+	var constraint *string
+	if cmd != nil && cmd.Flags().Changed("constraint") {
+		if len(args) == 0 {
+			return fmt.Errorf("--constraint requires at least one package name")
+		}
+		if err := validateConstraint(updateConstraint); err != nil {
+			return err
+		}
+		constraint = &updateConstraint
+	}
+	var minReleaseAge *string
+	if cmd != nil && cmd.Flags().Changed("min-release-age") {
+		if len(args) == 0 {
+			return fmt.Errorf("--min-release-age requires at least one package name")
+		}
+		if err := validateReleaseAge(updateMinReleaseAge); err != nil {
+			return err
+		}
+		minReleaseAge = &updateMinReleaseAge
+	}
+	defaultAge, err := defaultMinReleaseAge()
+	if err != nil {
+		return err
+	}
 
 	var targets []manager.PackageTarget
 	for _, arg := range args {
@@ -49,9 +76,14 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		if idx >= 0 {
 			suffix := arg[idx+1:]
 			if len(suffix) > 0 && !strings.Contains(suffix, "/") {
+				version, releaseID, err := parseReleaseID(suffix)
+				if err != nil {
+					return err
+				}
 				targets = append(targets, manager.PackageTarget{
-					ID:      arg[:idx],
-					Version: suffix,
+					ID:        arg[:idx],
+					Version:   version,
+					ReleaseID: releaseID,
 				})
 				continue
 			}
@@ -60,9 +92,13 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	}
 
 	opts := manager.UpdateOptions{
-		Packages: targets,
-		Pin:      updatePin,
-		Unpin:    updateUnpin,
+		Packages:             targets,
+		Pin:                  updatePin,
+		Unpin:                updateUnpin,
+		AllowDowngrade:       updateAllowDowngrade,
+		Constraint:           constraint,
+		MinReleaseAge:        minReleaseAge,
+		DefaultMinReleaseAge: defaultAge,
 	}
 
 	results, err := mgr.Update(context.Background(), opts)
@@ -71,9 +107,12 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	}
 
 	for _, result := range results {
-		if result.Updated {
+		switch {
+		case result.Updated:
 			fmt.Printf("%s  %s → %s\n", result.ID, result.OldVersion, result.NewVersion)
-		} else {
+		case result.Downgrade:
+			fmt.Printf("%s  %s  latest %s is older; not downgrading (use --allow-downgrade)\n", result.ID, result.OldVersion, result.NewVersion)
+		default:
 			fmt.Printf("%s  %s  up to date\n", result.ID, result.OldVersion)
 		}
 	}
@@ -85,4 +124,7 @@ func init() {
 	rootCmd.AddCommand(updateCmd)
 	updateCmd.Flags().BoolVar(&updatePin, "pin", false, "Pin each named package at the version it is updated to")
 	updateCmd.Flags().BoolVar(&updateUnpin, "unpin", false, "Remove the pin from each named package, then update to latest")
+	updateCmd.Flags().StringVar(&updateConstraint, "constraint", "", `Set the version constraint of each named package, e.g. ~1.29 or <2.0 ("" removes it)`)
+	updateCmd.Flags().StringVar(&updateMinReleaseAge, "min-release-age", "", `Set the minimum release age of each named package, e.g. 7d ("" uses min_release_age from config)`)
+	updateCmd.Flags().BoolVar(&updateAllowDowngrade, "allow-downgrade", false, "Install the latest release even if it is older than the installed version")
 }
@@ -37,3 +37,46 @@ func TestRunUpdate_UnpinRequiresPackageName(t *testing.T) {
 		t.Fatal("expected error when --unpin set without package names")
 	}
 }
+
+func TestRunUpdate_InvalidReleaseID(t *testing.T) {
+	err := runUpdate(nil, []string{"github.com/casey/just@id:latest"})
+	if err == nil {
+		t.Fatal("expected error for invalid release ID")
+	}
+}
+
+func TestRunUpdate_ConstraintRequiresPackageName(t *testing.T) {
+	if err := updateCmd.Flags().Set("constraint", "~1.29"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		updateConstraint = ""
+		updateCmd.Flags().Lookup("constraint").Changed = false
+	}()
+
+	if err := runUpdate(updateCmd, []string{}); err == nil {
+		t.Fatal("expected error when --constraint set without package names")
+	}
+	updateConstraint = "~"
+	if err := runUpdate(updateCmd, []string{"github.com/casey/just"}); err == nil {
+		t.Fatal("expected error for invalid constraint")
+	}
+}
+
+func TestRunUpdate_MinReleaseAgeRequiresPackageName(t *testing.T) {
+	if err := updateCmd.Flags().Set("min-release-age", "7d"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		updateMinReleaseAge = ""
+		updateCmd.Flags().Lookup("min-release-age").Changed = false
+	}()
+
+	if err := runUpdate(updateCmd, []string{}); err == nil {
+		t.Fatal("expected error when --min-release-age set without package names")
+	}
+	updateMinReleaseAge = "soon"
+	if err := runUpdate(updateCmd, []string{"github.com/casey/just"}); err == nil {
+		t.Fatal("expected error for invalid minimum release age")
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright © 2023 Andrew Denton <ventifus@flying-snail.net>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/ventifus/binmgr/pkg/manager"
+)
+
+var verifyRepair bool
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify [PACKAGE...] [flags]",
+	Short: "Check installed files against their recorded checksums",
+	Long:  `Recompute the checksums of every installed file and compare them with the manifest. Reports files that are missing, modified, or no longer executable, and exits non-zero if any are found. With no arguments, checks all installed packages.`,
+	Run:   runVerify,
+}
+
+func runVerify(cmd *cobra.Command, args []string) {
+	results, err := mgr.Verify(context.Background(), manager.VerifyOptions{
+		Packages: args,
+		Repair:   verifyRepair,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	anyProblems := false
+	for _, result := range results {
+		if len(result.Problems) == 0 {
+			fmt.Printf("%-50s ok\n", result.ID)
+			continue
+		}
+		repairedStr := ""
+		if result.Repaired {
+			repairedStr = "  [repaired]"
+		} else {
+			anyProblems = true
+		}
+		for _, p := range result.Problems {
+			fmt.Printf("%-50s %s  %s%s\n", result.ID, p.LocalPath, p.Problem, repairedStr)
+		}
+		if result.RepairErr != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", result.RepairErr)
+		}
+	}
+
+	if anyProblems {
+		os.Exit(1)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+	verifyCmd.Flags().BoolVar(&verifyRepair, "repair", false, "Re-download and restore files that fail verification")
+}
@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"testing"
+)
+
+// Verify behavior is covered in pkg/manager; this only checks wiring.
+func TestVerifyCmd_CommandRegistered(t *testing.T) {
+	found := false
+	for _, sub := range rootCmd.Commands() {
+		if sub.Name() == "verify" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("verify command not registered on rootCmd")
+	}
+}
@@ -2,9 +2,13 @@ package backend
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/ventifus/binmgr/pkg/manifest"
+	"github.com/ventifus/binmgr/pkg/version"
 )
 
 type Backend interface {
@@ -15,16 +19,190 @@ type Backend interface {
 }
 
 type ResolveOptions struct {
-	Version string // if non-empty, resolve this specific release; empty = latest
+	Version       string            // if non-empty, resolve this specific release; empty = latest
+	ReleaseID     int64             // github: if non-zero, resolve this release by numeric ID
+	Channel       *manifest.Channel // which releases count as latest; nil = stable only
+	Constraint    string            // if non-empty, resolve the newest release satisfying this version constraint
+	MinReleaseAge time.Duration     // if positive, pass over releases published more recently
 }
 
 type Resolution struct {
-	Version string  // github: tag; kubeurl: stable.txt content; shasumurl: SHA-256 of file
-	Assets  []Asset // nil for kubeurl (manager constructs URLs from asset_glob + version)
+	Version   string    // github: tag; kubeurl: stable.txt content; shasumurl: SHA-256 of file
+	ReleaseID int64     // github: numeric release ID; 0 for other backends
+	Assets    []Asset   // nil for kubeurl (manager constructs URLs from asset_glob + version)
+	Latest    string    // with a constraint: highest release ignoring it; otherwise empty
+	Published time.Time // when the release was published; zero if unknown
+	HeldBack  string    // newer release passed over for being younger than the minimum release age
 }
 
 type Asset struct {
-	Name      string
-	URL       string
-	Checksums map[string]string // non-empty only for shasumurl
+	Name        string
+	URL         string
+	Checksums   map[string]string // non-empty only for shasumurl
+	ChecksumURL string            // the file Checksums were listed in
+}
+
+// requireStableChannel returns an error if c selects anything other than
+// stable releases, for backends that have no notion of release channels.
+func requireStableChannel(backendType string, c *manifest.Channel) error {
+	if c != nil && *c != (manifest.Channel{}) {
+		return fmt.Errorf("%s backend does not support release channels", backendType)
+	}
+	return nil
+}
+
+// rejectGitHubOptions returns an error if opts select a release by numeric
+// ID or use a release channel, which only the github backend supports.
+func rejectGitHubOptions(backendType string, opts ResolveOptions) error {
+	if opts.ReleaseID != 0 {
+		return fmt.Errorf("%s backend does not support release IDs", backendType)
+	}
+	return requireStableChannel(backendType, opts.Channel)
+}
+
+// rejectConstraint returns an error if expr is a version constraint, for
+// backends whose versions cannot be ordered.
+func rejectConstraint(backendType, expr string) error {
+	if expr != "" {
+		return fmt.Errorf("%s backend does not support version constraints", backendType)
+	}
+	return nil
+}
+
+// parseConstraint parses a version constraint expression, returning nil for
+// an empty one.
+func parseConstraint(expr string) (*version.Constraint, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	return version.ParseConstraint(expr)
+}
+
+// minReleaseAge returns the minimum release age pkg asks for; zero if none.
+func minReleaseAge(pkg *manifest.Package) (time.Duration, error) {
+	if pkg.MinReleaseAge == "" {
+		return 0, nil
+	}
+	return manifest.ParseAge(pkg.MinReleaseAge)
+}
+
+// releaseFilter chooses which of a list of releases to offer as the latest.
+type releaseFilter struct {
+	constraint *version.Constraint // nil = any version
+	minAge     time.Duration       // hold back releases published more recently
+	now        time.Time
+}
+
+// active reports whether the filter needs the full list of releases.
+func (f releaseFilter) active() bool {
+	return f.constraint != nil || f.minAge > 0
+}
+
+// tooYoung reports whether a release published at t is held back. A release
+// with an unknown publish time is not.
+func (f releaseFilter) tooYoung(t time.Time) bool {
+	return f.minAge > 0 && !t.IsZero() && f.now.Sub(t) < f.minAge
+}
+
+// choice is the release a releaseFilter picked.
+type choice struct {
+	index    int    // index of the release to offer; -1 if none qualifies
+	latest   string // with a constraint: the highest version ignoring it
+	heldBack string // the release that would be offered but for minAge
+}
+
+// choose picks among tags, listed newest first, published at the matching
+// times. Without a constraint the newest release old enough is picked; with
+// one, the highest allowed version old enough.
+func (f releaseFilter) choose(tags []string, published []time.Time) choice {
+	pick := func(skipYoung bool) int {
+		if f.constraint == nil {
+			for i := range tags {
+				if !skipYoung || !f.tooYoung(published[i]) {
+					return i
+				}
+			}
+			return -1
+		}
+		return newestAllowed(tags, f.constraint, func(i int) bool {
+			return skipYoung && f.tooYoung(published[i])
+		})
+	}
+
+	c := choice{index: pick(true)}
+	if f.constraint != nil {
+		c.latest = tags[max(newestAllowed(tags, nil, nil), 0)]
+	}
+	if f.minAge > 0 {
+		if i := pick(false); i >= 0 && i != c.index {
+			c.heldBack = tags[i]
+		}
+	}
+	return c
+}
+
+// noRelease describes why a releaseFilter found nothing to offer.
+func (f releaseFilter) noRelease() string {
+	switch {
+	case f.constraint != nil && f.minAge > 0:
+		return fmt.Sprintf("no release satisfies constraint %q and is older than %s", f.constraint, f.minAge)
+	case f.constraint != nil:
+		return fmt.Sprintf("no release satisfies constraint %q", f.constraint)
+	}
+	return fmt.Sprintf("no release is older than %s", f.minAge)
+}
+
+// newestAllowed returns the index of the highest version in tags that c
+// allows, or -1 if it allows none. A nil c allows any version that parses.
+// Releases for which skip returns true are passed over.
+func newestAllowed(tags []string, c *version.Constraint, skip func(i int) bool) int {
+	best := -1
+	var bestV version.Version
+	for i, tag := range tags {
+		v, ok := version.Parse(tag)
+		if !ok || (c != nil && !c.Allows(tag)) || (skip != nil && skip(i)) {
+			continue
+		}
+		if best < 0 || v.Compare(bestV) > 0 {
+			best, bestV = i, v
+		}
+	}
+	return best
+}
+
+// lastModified returns the time in a Last-Modified response header, or the
+// zero time if it is missing or malformed.
+func lastModified(h http.Header) time.Time {
+	t, err := http.ParseTime(h.Get("Last-Modified"))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// rejectTooYoung returns an error if res, the only candidate a backend can
+// see, was published less than minAge ago. Such backends cannot fall back to
+// an older release, so installing must fail rather than ignore the age.
+func rejectTooYoung(backendType string, res *Resolution, minAge time.Duration) error {
+	f := releaseFilter{minAge: minAge, now: time.Now()}
+	if f.tooYoung(res.Published) {
+		return fmt.Errorf("%s backend cannot list older releases, and %s was published %s ago, within the minimum release age of %s",
+			backendType, res.Version, f.now.Sub(res.Published).Round(time.Minute), minAge)
+	}
+	return nil
+}
+
+// holdBack returns what Check offers for pkg when res, the only candidate a
+// backend can see, is younger than minAge: the installed version, with res
+// held back.
+func holdBack(pkg *manifest.Package, res *Resolution, minAge time.Duration) *Resolution {
+	if minAge <= 0 || res.Version == pkg.Version || res.Published.IsZero() || time.Since(res.Published) >= minAge {
+		return res
+	}
+	return &Resolution{
+		Version:   pkg.Version,
+		ReleaseID: pkg.ReleaseID,
+		Latest:    res.Latest,
+		HeldBack:  res.Version,
+	}
 }
@@ -0,0 +1,296 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/ventifus/binmgr/pkg/manifest"
+	"go.yaml.in/yaml/v3"
+)
+
+// giteaBackend implements Backend for Gitea and Forgejo releases, such as
+// those on codeberg.org.
+type giteaBackend struct {
+	hosts      map[string]teaLogin // host → login from the tea CLI config
+	token      string              // GITEA_TOKEN; used for hosts without a login
+	httpClient *http.Client        // nil means use http.DefaultClient
+}
+
+// teaLogin is one login entry in the tea CLI config file.
+type teaLogin struct {
+	URL   string `yaml:"url"`
+	Token string `yaml:"token"`
+}
+
+// teaConfig is the subset of ~/.config/tea/config.yml binmgr reads.
+type teaConfig struct {
+	Logins []teaLogin `yaml:"logins"`
+}
+
+// loadTeaLogins reads the logins from the tea CLI config file, keyed by
+// host. Returns an empty map if the file is missing or unreadable, in which
+// case access is unauthenticated.
+func loadTeaLogins() map[string]teaLogin {
+	logins := make(map[string]teaLogin)
+
+	p := path.Join(os.Getenv("HOME"), ".config/tea/config.yml")
+	f, err := os.Open(p)
+	if err != nil {
+		log.WithError(err).Debug("could not open tea config")
+		return logins
+	}
+	defer f.Close()
+
+	var cfg teaConfig
+	if err := yaml.NewDecoder(f).Decode(&cfg); err != nil {
+		log.WithError(err).Warn("could not parse tea config; using unauthenticated Gitea access")
+		return logins
+	}
+	for _, l := range cfg.Logins {
+		u, err := url.Parse(l.URL)
+		if err != nil || u.Host == "" {
+			log.WithField("url", l.URL).Warn("ignoring tea login with invalid URL")
+			continue
+		}
+		logins[u.Host] = l
+	}
+	return logins
+}
+
+// NewGiteaBackend creates a new Gitea backend. Besides codeberg.org it
+// handles every host with a login in the tea CLI config, and extraHosts.
+// Tokens come from the tea CLI config, falling back to GITEA_TOKEN.
+func NewGiteaBackend(extraHosts ...string) Backend {
+	b := &giteaBackend{
+		hosts: loadTeaLogins(),
+		token: os.Getenv("GITEA_TOKEN"),
+	}
+	for _, h := range extraHosts {
+		if _, ok := b.hosts[h]; !ok {
+			b.hosts[h] = teaLogin{}
+		}
+	}
+	return b
+}
+
+// CanHandle returns true when the URL host is codeberg.org, a host with a
+// tea CLI login or a configured host.
+func (g *giteaBackend) CanHandle(u *url.URL) bool {
+	if u.Host == "codeberg.org" {
+		return true
+	}
+	_, ok := g.hosts[u.Host]
+	return ok
+}
+
+// Type returns the backend type string.
+func (g *giteaBackend) Type() string {
+	return "gitea"
+}
+
+// giteaRelease is a minimal representation of the Gitea Releases API response.
+type giteaRelease struct {
+	TagName     string       `json:"tag_name"`
+	PublishedAt time.Time    `json:"published_at"`
+	Assets      []giteaAsset `json:"assets"`
+}
+
+// giteaAsset is a release attachment.
+type giteaAsset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// client returns the HTTP client to use, falling back to http.DefaultClient.
+func (g *giteaBackend) client() *http.Client {
+	if g.httpClient != nil {
+		return g.httpClient
+	}
+	return http.DefaultClient
+}
+
+// tokenFor returns the token for host, falling back to GITEA_TOKEN.
+func (g *giteaBackend) tokenFor(host string) string {
+	if t := g.hosts[host].Token; t != "" {
+		return t
+	}
+	return g.token
+}
+
+// apiBase returns the API base URL for host, honouring the scheme and any
+// sub-path of a tea login URL.
+func (g *giteaBackend) apiBase(host string) string {
+	if l, ok := g.hosts[host]; ok {
+		if u, err := url.Parse(l.URL); err == nil && u.Scheme != "" {
+			return fmt.Sprintf("%s://%s%s/api/v1", u.Scheme, u.Host, strings.TrimRight(u.Path, "/"))
+		}
+	}
+	return "https://" + host + "/api/v1"
+}
+
+// doRequest performs an authenticated (if a token is available) GET request
+// to the Gitea API on host.
+func (g *giteaBackend) doRequest(ctx context.Context, host, apiURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request for %s: %w", apiURL, err)
+	}
+	req.Header.Set("User-Agent", "binmgr")
+	req.Header.Set("Accept", "application/json")
+	if t := g.tokenFor(host); t != "" {
+		req.Header.Set("Authorization", "token "+t)
+	}
+	return g.client().Do(req)
+}
+
+// getJSON fetches apiURL from host and decodes the response into v.
+// Returns the response header for pagination.
+func (g *giteaBackend) getJSON(ctx context.Context, host, owner, repo, apiURL string, v any) (http.Header, error) {
+	resp, err := g.doRequest(ctx, host, apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("gitea request to %s: %w", apiURL, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// handled below
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%s/%s/%s: release not found (404)", host, owner, repo)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, fmt.Errorf("%s/%s/%s: authentication error (%d)", host, owner, repo, resp.StatusCode)
+	case http.StatusTooManyRequests:
+		return nil, fmt.Errorf("%s/%s/%s: rate limited (429)", host, owner, repo)
+	default:
+		return nil, fmt.Errorf("%s/%s/%s: unexpected status %d", host, owner, repo, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return nil, fmt.Errorf("decoding Gitea release response: %w", err)
+	}
+	return resp.Header, nil
+}
+
+// findRelease lists the stable releases of owner/repo and returns the one
+// f chooses.
+func (g *giteaBackend) findRelease(ctx context.Context, host, owner, repo string, f releaseFilter) (*giteaRelease, choice, error) {
+	var rels []giteaRelease
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/releases?draft=false&pre-release=false&limit=50",
+		g.apiBase(host), url.PathEscape(owner), url.PathEscape(repo))
+	for page := 0; apiURL != "" && page < maxReleasePages; page++ {
+		log.WithField("url", apiURL).Debug("listing Gitea releases")
+
+		var pageRels []giteaRelease
+		header, err := g.getJSON(ctx, host, owner, repo, apiURL, &pageRels)
+		if err != nil {
+			return nil, choice{}, err
+		}
+		rels = append(rels, pageRels...)
+		apiURL = nextPageURL(header.Get("Link"))
+	}
+	if len(rels) == 0 {
+		return nil, choice{}, fmt.Errorf("%s/%s/%s: no published releases", host, owner, repo)
+	}
+
+	tags := make([]string, len(rels))
+	published := make([]time.Time, len(rels))
+	for i, r := range rels {
+		tags[i], published[i] = r.TagName, r.PublishedAt
+	}
+	ch := f.choose(tags, published)
+	if ch.index < 0 {
+		return nil, ch, fmt.Errorf("%s/%s/%s: %s", host, owner, repo, f.noRelease())
+	}
+	return &rels[ch.index], ch, nil
+}
+
+// resolveRelease resolves a Gitea release and returns a Resolution: the
+// named version, the newest release satisfying opts.Constraint and
+// opts.MinReleaseAge, or the latest release.
+func (g *giteaBackend) resolveRelease(ctx context.Context, host, owner, repo string, opts ResolveOptions) (*Resolution, error) {
+	con, err := parseConstraint(opts.Constraint)
+	if err != nil {
+		return nil, err
+	}
+	f := releaseFilter{constraint: con, minAge: opts.MinReleaseAge, now: time.Now()}
+
+	base := fmt.Sprintf("%s/repos/%s/%s/releases", g.apiBase(host), url.PathEscape(owner), url.PathEscape(repo))
+	rel := &giteaRelease{}
+	var ch choice
+	switch {
+	case opts.Version != "":
+		apiURL := base + "/tags/" + url.PathEscape(opts.Version)
+		log.WithField("url", apiURL).Debug("resolving Gitea release")
+		if _, err := g.getJSON(ctx, host, owner, repo, apiURL, rel); err != nil {
+			return nil, err
+		}
+	case !f.active():
+		apiURL := base + "/latest"
+		log.WithField("url", apiURL).Debug("resolving Gitea release")
+		if _, err := g.getJSON(ctx, host, owner, repo, apiURL, rel); err != nil {
+			return nil, err
+		}
+	default:
+		if rel, ch, err = g.findRelease(ctx, host, owner, repo, f); err != nil {
+			return nil, err
+		}
+	}
+
+	assets := make([]Asset, 0, len(rel.Assets))
+	for _, a := range rel.Assets {
+		assets = append(assets, Asset{
+			Name: a.Name,
+			URL:  a.BrowserDownloadURL,
+		})
+	}
+
+	return &Resolution{
+		Version:   rel.TagName,
+		Assets:    assets,
+		Latest:    ch.latest,
+		Published: rel.PublishedAt,
+		HeldBack:  ch.heldBack,
+	}, nil
+}
+
+// Resolve resolves a specific release, or the newest allowed by
+// opts.Constraint and opts.MinReleaseAge, for the given URL.
+func (g *giteaBackend) Resolve(ctx context.Context, sourceURL *url.URL, opts ResolveOptions) (*Resolution, error) {
+	if err := rejectGitHubOptions(g.Type(), opts); err != nil {
+		return nil, err
+	}
+	owner, repo, err := ownerRepo(sourceURL)
+	if err != nil {
+		return nil, err
+	}
+	return g.resolveRelease(ctx, sourceURL.Host, owner, repo, opts)
+}
+
+// Check returns the newest release satisfying the package's constraint and
+// minimum release age, which the manager compares to pkg.Version.
+func (g *giteaBackend) Check(ctx context.Context, pkg *manifest.Package) (*Resolution, error) {
+	if err := requireStableChannel(g.Type(), pkg.Channel); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(pkg.SourceURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing source URL %q: %w", pkg.SourceURL, err)
+	}
+	owner, repo, err := ownerRepo(u)
+	if err != nil {
+		return nil, err
+	}
+	minAge, err := minReleaseAge(pkg)
+	if err != nil {
+		return nil, err
+	}
+	return g.resolveRelease(ctx, u.Host, owner, repo, ResolveOptions{Constraint: pkg.Constraint, MinReleaseAge: minAge})
+}
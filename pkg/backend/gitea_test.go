@@ -0,0 +1,286 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ventifus/binmgr/pkg/manifest"
+)
+
+// fixtureGiteaRelease is a sample Gitea Releases API response.
+var fixtureGiteaRelease = giteaRelease{
+	TagName: "v0.9.0",
+	Assets: []giteaAsset{
+		{Name: "tool-linux-amd64.tar.gz", BrowserDownloadURL: "https://codeberg.org/owner/tool/releases/download/v0.9.0/tool-linux-amd64.tar.gz"},
+		{Name: "SHA256SUMS", BrowserDownloadURL: "https://codeberg.org/owner/tool/releases/download/v0.9.0/SHA256SUMS"},
+	},
+}
+
+// newGiteaTestServer serves the release fixture and records the last request.
+func newGiteaTestServer(t *testing.T, statusCode int) (*httptest.Server, *http.Request) {
+	t.Helper()
+	captured := &http.Request{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*captured = *r.Clone(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		if statusCode == http.StatusOK {
+			if err := json.NewEncoder(w).Encode(fixtureGiteaRelease); err != nil {
+				t.Errorf("encoding fixture: %v", err)
+			}
+		}
+	}))
+	return srv, captured
+}
+
+// giteaBackendWithBaseURL returns a giteaBackend that redirects API calls to
+// baseURL.
+func giteaBackendWithBaseURL(hosts map[string]teaLogin, token, baseURL string) *giteaBackend {
+	if hosts == nil {
+		hosts = map[string]teaLogin{}
+	}
+	return &giteaBackend{
+		hosts:      hosts,
+		token:      token,
+		httpClient: &http.Client{Transport: &rewriteTransport{base: baseURL}},
+	}
+}
+
+func TestGiteaCanHandle(t *testing.T) {
+	b := giteaBackendWithBaseURL(map[string]teaLogin{"git.example.com": {URL: "https://git.example.com"}}, "", "http://unused")
+
+	tests := []struct {
+		rawURL string
+		want   bool
+	}{
+		{"https://codeberg.org/forgejo/forgejo", true},
+		{"https://git.example.com/team/tool", true},
+		{"https://github.com/casey/just", false},
+		{"https://gitea.other.com/team/tool", false},
+	}
+
+	for _, tt := range tests {
+		u, err := url.Parse(tt.rawURL)
+		if err != nil {
+			t.Fatalf("parsing %q: %v", tt.rawURL, err)
+		}
+		if got := b.CanHandle(u); got != tt.want {
+			t.Errorf("CanHandle(%q) = %v, want %v", tt.rawURL, got, tt.want)
+		}
+	}
+}
+
+func TestGiteaType(t *testing.T) {
+	b := giteaBackendWithBaseURL(nil, "", "http://unused")
+	if got := b.Type(); got != "gitea" {
+		t.Errorf("Type() = %q, want %q", got, "gitea")
+	}
+}
+
+func TestGiteaLoadTeaLogins(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".config", "tea")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := `logins:
+  - name: work
+    url: https://git.example.com/gitea/
+    token: work-token
+  - name: broken
+    url: "not a url"
+`
+	if err := os.WriteFile(filepath.Join(dir, "config.yml"), []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewGiteaBackend().(*giteaBackend)
+	if len(b.hosts) != 1 {
+		t.Fatalf("hosts = %v, want only git.example.com", b.hosts)
+	}
+	if got := b.tokenFor("git.example.com"); got != "work-token" {
+		t.Errorf("tokenFor = %q, want %q", got, "work-token")
+	}
+	if got, want := b.apiBase("git.example.com"), "https://git.example.com/gitea/api/v1"; got != want {
+		t.Errorf("apiBase = %q, want %q", got, want)
+	}
+	if got, want := b.apiBase("codeberg.org"), "https://codeberg.org/api/v1"; got != want {
+		t.Errorf("apiBase = %q, want %q", got, want)
+	}
+}
+
+func TestNewGiteaBackendConfiguredHosts(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	b := NewGiteaBackend("git.example.com").(*giteaBackend)
+	for rawURL, want := range map[string]bool{
+		"https://codeberg.org/forgejo/forgejo": true,
+		"https://git.example.com/team/tool":    true,
+		"https://gitea.other.com/team/tool":    false,
+	} {
+		u, _ := url.Parse(rawURL)
+		if got := b.CanHandle(u); got != want {
+			t.Errorf("CanHandle(%q) = %v, want %v", rawURL, got, want)
+		}
+	}
+	if got, want := b.apiBase("git.example.com"), "https://git.example.com/api/v1"; got != want {
+		t.Errorf("apiBase = %q, want %q", got, want)
+	}
+}
+
+func TestGiteaResolveLatest(t *testing.T) {
+	srv, captured := newGiteaTestServer(t, http.StatusOK)
+	defer srv.Close()
+
+	b := giteaBackendWithBaseURL(nil, "", srv.URL)
+	u, _ := url.Parse("https://codeberg.org/owner/tool")
+
+	res, err := b.Resolve(context.Background(), u, ResolveOptions{})
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if res.Version != "v0.9.0" {
+		t.Errorf("Version = %q, want %q", res.Version, "v0.9.0")
+	}
+	if wantPath := "/api/v1/repos/owner/tool/releases/latest"; captured.URL.Path != wantPath {
+		t.Errorf("API path = %q, want %q", captured.URL.Path, wantPath)
+	}
+	if len(res.Assets) != 2 {
+		t.Fatalf("len(Assets) = %d, want 2", len(res.Assets))
+	}
+	if res.Assets[0].Name != "tool-linux-amd64.tar.gz" || res.Assets[0].URL != fixtureGiteaRelease.Assets[0].BrowserDownloadURL {
+		t.Errorf("Assets[0] = %+v", res.Assets[0])
+	}
+}
+
+func TestGiteaResolveSpecificTag(t *testing.T) {
+	srv, captured := newGiteaTestServer(t, http.StatusOK)
+	defer srv.Close()
+
+	b := giteaBackendWithBaseURL(nil, "", srv.URL)
+	u, _ := url.Parse("https://codeberg.org/owner/tool")
+
+	if _, err := b.Resolve(context.Background(), u, ResolveOptions{Version: "v0.9.0"}); err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if wantPath := "/api/v1/repos/owner/tool/releases/tags/v0.9.0"; captured.URL.Path != wantPath {
+		t.Errorf("API path = %q, want %q", captured.URL.Path, wantPath)
+	}
+}
+
+func TestGiteaCheck(t *testing.T) {
+	srv, captured := newGiteaTestServer(t, http.StatusOK)
+	defer srv.Close()
+
+	b := giteaBackendWithBaseURL(nil, "", srv.URL)
+	pkg := &manifest.Package{
+		SourceURL: "https://codeberg.org/owner/tool",
+		Version:   "v0.8.0",
+	}
+
+	res, err := b.Check(context.Background(), pkg)
+	if err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+	if res.Version != "v0.9.0" {
+		t.Errorf("Version = %q, want %q", res.Version, "v0.9.0")
+	}
+	if wantPath := "/api/v1/repos/owner/tool/releases/latest"; captured.URL.Path != wantPath {
+		t.Errorf("Check API path = %q, want %q", captured.URL.Path, wantPath)
+	}
+}
+
+func TestGiteaAuthHeader(t *testing.T) {
+	tests := []struct {
+		name  string
+		hosts map[string]teaLogin
+		token string
+		want  string
+	}{
+		{"none", nil, "", ""},
+		{"env token", nil, "env-token", "token env-token"},
+		{"login token wins", map[string]teaLogin{"codeberg.org": {URL: "https://codeberg.org", Token: "login-token"}}, "env-token", "token login-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, captured := newGiteaTestServer(t, http.StatusOK)
+			defer srv.Close()
+
+			b := giteaBackendWithBaseURL(tt.hosts, tt.token, srv.URL)
+			u, _ := url.Parse("https://codeberg.org/owner/tool")
+			if _, err := b.Resolve(context.Background(), u, ResolveOptions{}); err != nil {
+				t.Fatalf("Resolve returned error: %v", err)
+			}
+			if got := captured.Header.Get("Authorization"); got != tt.want {
+				t.Errorf("Authorization header = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGiteaResolve404(t *testing.T) {
+	srv, _ := newGiteaTestServer(t, http.StatusNotFound)
+	defer srv.Close()
+
+	b := giteaBackendWithBaseURL(nil, "", srv.URL)
+	u, _ := url.Parse("https://codeberg.org/owner/nonexistent")
+
+	if _, err := b.Resolve(context.Background(), u, ResolveOptions{}); err == nil {
+		t.Fatal("expected error for 404, got nil")
+	}
+}
+
+func TestGiteaResolveRejectsChannel(t *testing.T) {
+	b := giteaBackendWithBaseURL(nil, "", "http://unused")
+	u, _ := url.Parse("https://codeberg.org/owner/tool")
+
+	if _, err := b.Resolve(context.Background(), u, ResolveOptions{Channel: &manifest.Channel{Prerelease: true}}); err == nil {
+		t.Fatal("expected error for unsupported channel, got nil")
+	}
+}
+
+func TestGiteaCheckConstraint(t *testing.T) {
+	var captured *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		captured = r.Clone(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]giteaRelease{
+			{TagName: "v1.0.0"},
+			{TagName: "v0.9.1"},
+			{TagName: "v0.9.0"},
+		})
+	}))
+	defer srv.Close()
+
+	b := giteaBackendWithBaseURL(nil, "", srv.URL)
+	pkg := &manifest.Package{
+		SourceURL:  "https://codeberg.org/owner/tool",
+		Version:    "v0.9.0",
+		Constraint: "~0.9",
+	}
+
+	res, err := b.Check(context.Background(), pkg)
+	if err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+	if res.Version != "v0.9.1" {
+		t.Errorf("Version = %q, want %q", res.Version, "v0.9.1")
+	}
+	if res.Latest != "v1.0.0" {
+		t.Errorf("Latest = %q, want %q", res.Latest, "v1.0.0")
+	}
+	if wantPath := "/api/v1/repos/owner/tool/releases"; captured.URL.Path != wantPath {
+		t.Errorf("API path = %q, want %q", captured.URL.Path, wantPath)
+	}
+	if got := captured.URL.Query().Get("pre-release"); got != "false" {
+		t.Errorf("pre-release = %q, want %q", got, "false")
+	}
+}
@@ -8,17 +8,22 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/ventifus/binmgr/pkg/manifest"
 	"go.yaml.in/yaml/v3"
 )
 
-// githubBackend implements Backend for GitHub Releases.
+// githubBackend implements Backend for GitHub Releases on github.com and
+// GitHub Enterprise Server.
 type githubBackend struct {
-	token      string
-	httpClient *http.Client // nil means use http.DefaultClient
+	hosts      map[string]ghHostConfig // github.com and GHES host config
+	httpClient *http.Client            // nil means use http.DefaultClient
+	warnOnce   sync.Once               // warns of unauthenticated github.com access
 }
 
 // ghHostConfig holds per-host config from ~/.config/gh/hosts.yml.
@@ -31,14 +36,14 @@ type ghConfig struct {
 	Hosts map[string]ghHostConfig `yaml:",inline"`
 }
 
-// loadGHToken reads the GitHub OAuth token from the gh CLI config file.
-// Returns an empty string (and logs a warning) if unavailable.
-func loadGHToken() string {
+// loadGHHosts reads the per-host config from the gh CLI config file.
+// Returns an empty map (and logs a warning) if unavailable.
+func loadGHHosts() map[string]ghHostConfig {
 	p := path.Join(os.Getenv("HOME"), ".config/gh/hosts.yml")
 	f, err := os.Open(p)
 	if err != nil {
 		log.WithError(err).Warn("could not open gh config; using unauthenticated GitHub access")
-		return ""
+		return map[string]ghHostConfig{}
 	}
 	defer f.Close()
 
@@ -46,25 +51,39 @@ func loadGHToken() string {
 	dec := yaml.NewDecoder(f)
 	if err := dec.Decode(&cfg); err != nil {
 		log.WithError(err).Warn("could not parse gh config; using unauthenticated GitHub access")
-		return ""
+		return map[string]ghHostConfig{}
 	}
-
-	host, ok := cfg.Hosts["github.com"]
-	if !ok || host.OauthToken == "" {
-		log.Warn("no oauth_token for github.com in gh config; using unauthenticated GitHub access")
-		return ""
+	if cfg.Hosts == nil {
+		cfg.Hosts = map[string]ghHostConfig{}
 	}
-	return host.OauthToken
+	return cfg.Hosts
 }
 
-// NewGitHubBackend creates a new GitHub backend, loading auth token from gh CLI config if available.
-func NewGitHubBackend() Backend {
-	return &githubBackend{token: loadGHToken()}
+// NewGitHubBackend creates a new GitHub backend. Besides github.com it
+// handles every GitHub Enterprise Server host in the gh CLI config, the
+// host named by GH_HOST and extraHosts. Auth tokens are loaded per host
+// from the gh CLI config if available.
+func NewGitHubBackend(extraHosts ...string) Backend {
+	b := &githubBackend{hosts: loadGHHosts()}
+	if h := os.Getenv("GH_HOST"); h != "" {
+		extraHosts = append(extraHosts, h)
+	}
+	for _, h := range extraHosts {
+		if _, ok := b.hosts[h]; !ok {
+			b.hosts[h] = ghHostConfig{}
+		}
+	}
+	return b
 }
 
-// CanHandle returns true when the URL host is github.com.
+// CanHandle returns true when the URL host is github.com or a configured
+// GitHub Enterprise Server host.
 func (g *githubBackend) CanHandle(u *url.URL) bool {
-	return u.Host == "github.com"
+	if u.Host == "github.com" {
+		return true
+	}
+	_, ok := g.hosts[u.Host]
+	return ok
 }
 
 // Type returns the backend type string.
@@ -74,8 +93,12 @@ func (g *githubBackend) Type() string {
 
 // githubRelease is a minimal representation of the GitHub Releases API response.
 type githubRelease struct {
-	TagName string        `json:"tag_name"`
-	Assets  []githubAsset `json:"assets"`
+	ID          int64         `json:"id"`
+	TagName     string        `json:"tag_name"`
+	Draft       bool          `json:"draft"`
+	Prerelease  bool          `json:"prerelease"`
+	PublishedAt time.Time     `json:"published_at"`
+	Assets      []githubAsset `json:"assets"`
 }
 
 type githubAsset struct {
@@ -91,41 +114,52 @@ func (g *githubBackend) client() *http.Client {
 	return http.DefaultClient
 }
 
-// doRequest performs an authenticated (if token available) GET request to the GitHub API.
-func (g *githubBackend) doRequest(ctx context.Context, apiURL string) (*http.Response, error) {
+// githubAPIBase returns the REST API base URL for host: api.github.com for
+// github.com, /api/v3 on the host itself for GitHub Enterprise Server.
+func githubAPIBase(host string) string {
+	if host == "github.com" {
+		return "https://api.github.com"
+	}
+	return "https://" + host + "/api/v3"
+}
+
+// doRequest performs an authenticated (if a token is available for host) GET
+// request to the GitHub API.
+func (g *githubBackend) doRequest(ctx context.Context, host, apiURL string) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating request for %s: %w", apiURL, err)
 	}
 	req.Header.Set("User-Agent", "binmgr")
 	req.Header.Set("Accept", "application/vnd.github+json")
-	if g.token != "" {
-		req.Header.Set("Authorization", "Bearer "+g.token)
+	if t := g.hosts[host].OauthToken; t != "" {
+		req.Header.Set("Authorization", "Bearer "+t)
+	} else if host == "github.com" {
+		g.warnOnce.Do(func() {
+			log.Warn("no oauth_token for github.com in gh config; using unauthenticated GitHub access")
+		})
 	}
 	return g.client().Do(req)
 }
 
-// ownerRepo extracts the owner and repo from a GitHub URL path (e.g. /casey/just).
+// ownerRepo extracts the owner and repo from a GitHub or Gitea URL path
+// (e.g. /casey/just).
 func ownerRepo(sourceURL *url.URL) (owner, repo string, err error) {
 	parts := strings.SplitN(strings.TrimPrefix(sourceURL.Path, "/"), "/", 3)
 	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
-		return "", "", fmt.Errorf("invalid GitHub URL %q: expected /owner/repo path", sourceURL)
+		return "", "", fmt.Errorf("invalid repository URL %q: expected /owner/repo path", sourceURL)
 	}
 	return parts[0], parts[1], nil
 }
 
-// resolveRelease resolves a GitHub release and returns a Resolution.
-func (g *githubBackend) resolveRelease(ctx context.Context, owner, repo, version string) (*Resolution, error) {
-	var apiURL string
-	if version != "" {
-		apiURL = fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/tags/%s", owner, repo, version)
-	} else {
-		apiURL = fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", owner, repo)
-	}
+// maxReleasePages bounds how many pages of releases are listed when looking
+// for the newest release in a channel or satisfying a constraint.
+const maxReleasePages = 10
 
-	log.WithField("url", apiURL).Debug("resolving GitHub release")
-
-	resp, err := g.doRequest(ctx, apiURL)
+// getJSON fetches apiURL from host and decodes the response into v.
+// Returns the response header for pagination.
+func (g *githubBackend) getJSON(ctx context.Context, host, owner, repo, apiURL string, v any) (http.Header, error) {
+	resp, err := g.doRequest(ctx, host, apiURL)
 	if err != nil {
 		return nil, fmt.Errorf("github request to %s: %w", apiURL, err)
 	}
@@ -135,19 +169,157 @@ func (g *githubBackend) resolveRelease(ctx context.Context, owner, repo, version
 	case http.StatusOK:
 		// handled below
 	case http.StatusNotFound:
-		return nil, fmt.Errorf("github.com/%s/%s: release not found (404)", owner, repo)
+		return nil, fmt.Errorf("%s/%s/%s: release not found (404)", host, owner, repo)
 	case http.StatusUnauthorized, http.StatusForbidden:
-		return nil, fmt.Errorf("github.com/%s/%s: authentication error (%d)", owner, repo, resp.StatusCode)
+		return nil, fmt.Errorf("%s/%s/%s: authentication error (%d)", host, owner, repo, resp.StatusCode)
 	case http.StatusTooManyRequests:
-		return nil, fmt.Errorf("github.com/%s/%s: rate limited (429)", owner, repo)
+		return nil, fmt.Errorf("%s/%s/%s: rate limited (429)", host, owner, repo)
 	default:
-		return nil, fmt.Errorf("github.com/%s/%s: unexpected status %d", owner, repo, resp.StatusCode)
+		return nil, fmt.Errorf("%s/%s/%s: unexpected status %d", host, owner, repo, resp.StatusCode)
 	}
 
-	var rel githubRelease
-	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
 		return nil, fmt.Errorf("decoding GitHub release response: %w", err)
 	}
+	return resp.Header, nil
+}
+
+// nextPageURL returns the rel="next" URL from a Link response header, or ""
+// on the last page.
+func nextPageURL(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(part), ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		return strings.Trim(strings.TrimSpace(target), "<>")
+	}
+	return ""
+}
+
+// channelMatcher returns a function reporting whether a release belongs to
+// channel c.
+func channelMatcher(c *manifest.Channel) (func(*githubRelease) bool, error) {
+	var re *regexp.Regexp
+	if c.TagRegex != "" {
+		var err error
+		if re, err = regexp.Compile(c.TagRegex); err != nil {
+			return nil, fmt.Errorf("invalid tag regex %q: %w", c.TagRegex, err)
+		}
+	}
+	if c.TagGlob != "" {
+		if _, err := path.Match(c.TagGlob, ""); err != nil {
+			return nil, fmt.Errorf("invalid tag glob %q: %w", c.TagGlob, err)
+		}
+	}
+	return func(rel *githubRelease) bool {
+		if rel.Draft && !c.Draft {
+			return false
+		}
+		if rel.Prerelease && !c.Prerelease && !c.Draft {
+			return false
+		}
+		if c.TagGlob != "" {
+			if ok, _ := path.Match(c.TagGlob, rel.TagName); !ok {
+				return false
+			}
+		}
+		return re == nil || re.MatchString(rel.TagName)
+	}, nil
+}
+
+// findRelease lists the releases of owner/repo, newest first, and returns
+// the first that belongs to channel c. With an active filter it instead
+// returns the release the filter chooses from those in the channel.
+func (g *githubBackend) findRelease(ctx context.Context, host, owner, repo string, c *manifest.Channel, f releaseFilter) (*githubRelease, choice, error) {
+	if c == nil {
+		c = &manifest.Channel{}
+	}
+	match, err := channelMatcher(c)
+	if err != nil {
+		return nil, choice{}, err
+	}
+
+	var candidates []githubRelease
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/releases?per_page=100", githubAPIBase(host), owner, repo)
+	for page := 0; apiURL != "" && page < maxReleasePages; page++ {
+		log.WithField("url", apiURL).Debug("listing GitHub releases")
+
+		var rels []githubRelease
+		header, err := g.getJSON(ctx, host, owner, repo, apiURL, &rels)
+		if err != nil {
+			return nil, choice{}, err
+		}
+		for i := range rels {
+			if !match(&rels[i]) {
+				continue
+			}
+			if !f.active() {
+				return &rels[i], choice{}, nil
+			}
+			candidates = append(candidates, rels[i])
+		}
+		apiURL = nextPageURL(header.Get("Link"))
+	}
+	if len(candidates) == 0 {
+		return nil, choice{}, fmt.Errorf("%s/%s/%s: no release matches the package's channel", host, owner, repo)
+	}
+
+	tags := make([]string, len(candidates))
+	published := make([]time.Time, len(candidates))
+	for i := range candidates {
+		tags[i], published[i] = candidates[i].TagName, candidates[i].PublishedAt
+	}
+	ch := f.choose(tags, published)
+	if ch.index < 0 {
+		return nil, ch, fmt.Errorf("%s/%s/%s: %s", host, owner, repo, f.noRelease())
+	}
+	return &candidates[ch.index], ch, nil
+}
+
+// resolveRelease resolves a GitHub release and returns a Resolution. A
+// release ID or explicit version is looked up directly; otherwise the newest
+// release in opts.Channel satisfying opts.Constraint and opts.MinReleaseAge
+// is used.
+func (g *githubBackend) resolveRelease(ctx context.Context, host, owner, repo string, opts ResolveOptions) (*Resolution, error) {
+	con, err := parseConstraint(opts.Constraint)
+	if err != nil {
+		return nil, err
+	}
+
+	f := releaseFilter{constraint: con, minAge: opts.MinReleaseAge, now: time.Now()}
+
+	var rel *githubRelease
+	var ch choice
+	switch id, c := opts.ReleaseID, opts.Channel; {
+	case id != 0:
+		// Unlike a tag, the ID keeps naming the same release if it is retagged.
+		apiURL := fmt.Sprintf("%s/repos/%s/%s/releases/%d", githubAPIBase(host), owner, repo, id)
+		log.WithField("url", apiURL).Debug("resolving GitHub release")
+		rel = &githubRelease{}
+		if _, err := g.getJSON(ctx, host, owner, repo, apiURL, rel); err != nil {
+			return nil, err
+		}
+	case opts.Version != "":
+		apiURL := fmt.Sprintf("%s/repos/%s/%s/releases/tags/%s", githubAPIBase(host), owner, repo, opts.Version)
+		log.WithField("url", apiURL).Debug("resolving GitHub release")
+		rel = &githubRelease{}
+		if _, err := g.getJSON(ctx, host, owner, repo, apiURL, rel); err != nil {
+			return nil, err
+		}
+	case !f.active() && (c == nil || *c == (manifest.Channel{})):
+		// The latest endpoint already excludes drafts and prereleases.
+		apiURL := fmt.Sprintf("%s/repos/%s/%s/releases/latest", githubAPIBase(host), owner, repo)
+		log.WithField("url", apiURL).Debug("resolving GitHub release")
+		rel = &githubRelease{}
+		if _, err := g.getJSON(ctx, host, owner, repo, apiURL, rel); err != nil {
+			return nil, err
+		}
+	default:
+		if rel, ch, err = g.findRelease(ctx, host, owner, repo, c, f); err != nil {
+			return nil, err
+		}
+	}
 
 	assets := make([]Asset, 0, len(rel.Assets))
 	for _, a := range rel.Assets {
@@ -159,21 +331,28 @@ func (g *githubBackend) resolveRelease(ctx context.Context, owner, repo, version
 	}
 
 	return &Resolution{
-		Version: rel.TagName,
-		Assets:  assets,
+		Version:   rel.TagName,
+		ReleaseID: rel.ID,
+		Assets:    assets,
+		Latest:    ch.latest,
+		Published: rel.PublishedAt,
+		HeldBack:  ch.heldBack,
 	}, nil
 }
 
-// Resolve resolves a specific or latest GitHub release for the given URL.
+// Resolve resolves a specific release (by ID or tag), or the newest in
+// opts.Channel allowed by opts.Constraint, for the given URL.
 func (g *githubBackend) Resolve(ctx context.Context, sourceURL *url.URL, opts ResolveOptions) (*Resolution, error) {
 	owner, repo, err := ownerRepo(sourceURL)
 	if err != nil {
 		return nil, err
 	}
-	return g.resolveRelease(ctx, owner, repo, opts.Version)
+	return g.resolveRelease(ctx, sourceURL.Host, owner, repo, opts)
 }
 
-// Check returns the latest release resolution, which the manager compares to pkg.Version.
+// Check returns the newest release in the package's channel that satisfies
+// its constraint and minimum release age, which the manager compares to
+// pkg.Version.
 func (g *githubBackend) Check(ctx context.Context, pkg *manifest.Package) (*Resolution, error) {
 	u, err := url.Parse(pkg.SourceURL)
 	if err != nil {
@@ -183,5 +362,13 @@ func (g *githubBackend) Check(ctx context.Context, pkg *manifest.Package) (*Reso
 	if err != nil {
 		return nil, err
 	}
-	return g.resolveRelease(ctx, owner, repo, "")
+	minAge, err := minReleaseAge(pkg)
+	if err != nil {
+		return nil, err
+	}
+	return g.resolveRelease(ctx, u.Host, owner, repo, ResolveOptions{
+		Channel:       pkg.Channel,
+		Constraint:    pkg.Constraint,
+		MinReleaseAge: minAge,
+	})
 }
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/ventifus/binmgr/pkg/manifest"
 )
@@ -47,10 +48,11 @@ func (rt *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error)
 	return http.DefaultTransport.RoundTrip(req2)
 }
 
-// backendWithBaseURL returns a githubBackend that redirects API calls to baseURL.
+// backendWithBaseURL returns a githubBackend that redirects API calls to
+// baseURL, using token for github.com.
 func backendWithBaseURL(token string, baseURL string) *githubBackend {
 	return &githubBackend{
-		token:      token,
+		hosts:      map[string]ghHostConfig{"github.com": {OauthToken: token}},
 		httpClient: &http.Client{Transport: &rewriteTransport{base: baseURL}},
 	}
 }
@@ -245,3 +247,334 @@ func TestResolve404(t *testing.T) {
 		t.Fatal("expected error for 404, got nil")
 	}
 }
+
+func TestCanHandleEnterpriseHost(t *testing.T) {
+	b := &githubBackend{hosts: map[string]ghHostConfig{"github.example.com": {}}}
+
+	for rawURL, want := range map[string]bool{
+		"https://github.com/casey/just":        true,
+		"https://github.example.com/team/tool": true,
+		"https://github.other.com/team/tool":   false,
+		"https://gitlab.example.com/team/tool": false,
+	} {
+		u, _ := url.Parse(rawURL)
+		if got := b.CanHandle(u); got != want {
+			t.Errorf("CanHandle(%q) = %v, want %v", rawURL, got, want)
+		}
+	}
+}
+
+func TestNewGitHubBackendHostFromEnv(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GH_HOST", "github.corp.example")
+
+	b := NewGitHubBackend()
+	u, _ := url.Parse("https://github.corp.example/team/tool")
+	if !b.CanHandle(u) {
+		t.Errorf("CanHandle(%q) = false, want true for GH_HOST", u)
+	}
+}
+
+func TestNewGitHubBackendConfiguredHosts(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GH_HOST", "")
+
+	b := NewGitHubBackend("github.corp.example", "ghe.example.org")
+	for rawURL, want := range map[string]bool{
+		"https://github.com/casey/just":         true,
+		"https://github.corp.example/team/tool": true,
+		"https://ghe.example.org/team/tool":     true,
+		"https://github.other.com/team/tool":    false,
+	} {
+		u, _ := url.Parse(rawURL)
+		if got := b.CanHandle(u); got != want {
+			t.Errorf("CanHandle(%q) = %v, want %v", rawURL, got, want)
+		}
+	}
+}
+
+func TestResolveEnterpriseHost(t *testing.T) {
+	var capturedPath, capturedHost, capturedAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		capturedPath = r.URL.Path
+		capturedHost = r.Host
+		capturedAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(fixtureRelease)
+	}))
+	defer srv.Close()
+
+	b := &githubBackend{
+		hosts: map[string]ghHostConfig{
+			"github.com":         {OauthToken: "public-token"},
+			"github.example.com": {OauthToken: "ghes-token"},
+		},
+		httpClient: &http.Client{Transport: &rewriteTransport{base: srv.URL}},
+	}
+	u, _ := url.Parse("https://github.example.com/team/tool")
+
+	if _, err := b.Resolve(context.Background(), u, ResolveOptions{Version: "v1.2.3"}); err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if capturedHost != "github.example.com" {
+		t.Errorf("API host = %q, want %q", capturedHost, "github.example.com")
+	}
+	if want := "/api/v3/repos/team/tool/releases/tags/v1.2.3"; capturedPath != want {
+		t.Errorf("API path = %q, want %q", capturedPath, want)
+	}
+	if want := "Bearer ghes-token"; capturedAuth != want {
+		t.Errorf("Authorization header = %q, want %q", capturedAuth, want)
+	}
+}
+
+// channelReleases is a release listing, newest first, for a repo that
+// publishes two products.
+var channelReleases = []githubRelease{
+	{TagName: "client-v1.5.0-rc.1", Prerelease: true},
+	{TagName: "knative-v1.20.0", Draft: true},
+	{TagName: "client-v1.4.0"},
+	{TagName: "knative-v1.19.0-rc.1", Prerelease: true},
+	{TagName: "knative-v1.19.0"},
+}
+
+func TestResolveChannel(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel *manifest.Channel
+		want    string
+	}{
+		{"stable with tag glob", &manifest.Channel{TagGlob: "knative-v*"}, "knative-v1.19.0"},
+		{"prerelease", &manifest.Channel{Prerelease: true}, "client-v1.5.0-rc.1"},
+		{"prerelease with tag regex", &manifest.Channel{Prerelease: true, TagRegex: `^knative-`}, "knative-v1.19.0-rc.1"},
+		{"draft", &manifest.Channel{Draft: true, Prerelease: true, TagGlob: "knative-v*"}, "knative-v1.20.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var capturedPath string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				capturedPath = r.URL.Path
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(channelReleases)
+			}))
+			defer srv.Close()
+
+			b := backendWithBaseURL("", srv.URL)
+			u, _ := url.Parse("https://github.com/knative/client")
+
+			res, err := b.Resolve(context.Background(), u, ResolveOptions{Channel: tt.channel})
+			if err != nil {
+				t.Fatalf("Resolve returned error: %v", err)
+			}
+			if res.Version != tt.want {
+				t.Errorf("Version = %q, want %q", res.Version, tt.want)
+			}
+			if want := "/repos/knative/client/releases"; capturedPath != want {
+				t.Errorf("API path = %q, want %q", capturedPath, want)
+			}
+		})
+	}
+}
+
+func TestResolveChannelPaginates(t *testing.T) {
+	var pages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pages = append(pages, r.URL.RawQuery)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", `<https://api.github.com/repositories/1/releases?per_page=100&page=2>; rel="next", <https://api.github.com/repositories/1/releases?per_page=100&page=2>; rel="last"`)
+			_ = json.NewEncoder(w).Encode([]githubRelease{{TagName: "client-v1.4.0"}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]githubRelease{{TagName: "knative-v1.19.0"}})
+	}))
+	defer srv.Close()
+
+	b := backendWithBaseURL("", srv.URL)
+	pkg := &manifest.Package{
+		SourceURL: "https://github.com/knative/client",
+		Channel:   &manifest.Channel{TagGlob: "knative-v*"},
+	}
+
+	res, err := b.Check(context.Background(), pkg)
+	if err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+	if res.Version != "knative-v1.19.0" {
+		t.Errorf("Version = %q, want %q", res.Version, "knative-v1.19.0")
+	}
+	if len(pages) != 2 {
+		t.Errorf("fetched %d pages (%v), want 2", len(pages), pages)
+	}
+}
+
+func TestResolveChannelNoMatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(channelReleases)
+	}))
+	defer srv.Close()
+
+	b := backendWithBaseURL("", srv.URL)
+	u, _ := url.Parse("https://github.com/knative/client")
+
+	_, err := b.Resolve(context.Background(), u, ResolveOptions{Channel: &manifest.Channel{TagGlob: "serving-v*"}})
+	if err == nil {
+		t.Fatal("expected error when no release matches the channel, got nil")
+	}
+}
+
+func TestNextPageURL(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{"", ""},
+		{`<https://api.github.com/x?page=2>; rel="next", <https://api.github.com/x?page=5>; rel="last"`, "https://api.github.com/x?page=2"},
+		{`<https://api.github.com/x?page=1>; rel="prev", <https://api.github.com/x?page=1>; rel="first"`, ""},
+	}
+	for _, tt := range tests {
+		if got := nextPageURL(tt.link); got != tt.want {
+			t.Errorf("nextPageURL(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
+
+func TestResolveByReleaseID(t *testing.T) {
+	var capturedPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		capturedPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		rel := fixtureRelease
+		rel.ID = 123456
+		_ = json.NewEncoder(w).Encode(rel)
+	}))
+	defer srv.Close()
+
+	b := backendWithBaseURL("", srv.URL)
+	u, _ := url.Parse("https://github.com/casey/just")
+
+	res, err := b.Resolve(context.Background(), u, ResolveOptions{ReleaseID: 123456})
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if want := "/repos/casey/just/releases/123456"; capturedPath != want {
+		t.Errorf("API path = %q, want %q", capturedPath, want)
+	}
+	if res.Version != "v1.2.3" {
+		t.Errorf("Version = %q, want %q", res.Version, "v1.2.3")
+	}
+	if res.ReleaseID != 123456 {
+		t.Errorf("ReleaseID = %d, want %d", res.ReleaseID, 123456)
+	}
+}
+
+func TestResolveConstraint(t *testing.T) {
+	rels := []githubRelease{
+		{TagName: "v1.31.0-rc.0", Prerelease: true},
+		{TagName: "v1.29.9"}, // backport published after v1.30.2
+		{TagName: "v1.30.2"},
+		{TagName: "v1.29.8"},
+		{TagName: "v1.28.15"},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want := "/repos/kubernetes/kubernetes/releases"; r.URL.Path != want {
+			t.Errorf("API path = %q, want %q", r.URL.Path, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(rels)
+	}))
+	defer srv.Close()
+
+	b := backendWithBaseURL("", srv.URL)
+	pkg := &manifest.Package{
+		SourceURL:  "https://github.com/kubernetes/kubernetes",
+		Constraint: "~1.29",
+	}
+
+	res, err := b.Check(context.Background(), pkg)
+	if err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+	if res.Version != "v1.29.9" {
+		t.Errorf("Version = %q, want %q", res.Version, "v1.29.9")
+	}
+	if res.Latest != "v1.30.2" {
+		t.Errorf("Latest = %q, want %q", res.Latest, "v1.30.2")
+	}
+
+	pkg.Constraint = "<1.29"
+	res, err = b.Check(context.Background(), pkg)
+	if err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+	if res.Version != "v1.28.15" {
+		t.Errorf("Version = %q, want %q", res.Version, "v1.28.15")
+	}
+
+	pkg.Constraint = ">=2.0"
+	if _, err := b.Check(context.Background(), pkg); err == nil {
+		t.Fatal("expected error when no release satisfies the constraint, got nil")
+	}
+}
+
+func TestCheckMinReleaseAge(t *testing.T) {
+	now := time.Now()
+	rels := []githubRelease{
+		{TagName: "v1.3.0", PublishedAt: now.Add(-2 * time.Hour)},
+		{TagName: "v1.2.1", PublishedAt: now.Add(-3 * 24 * time.Hour)},
+		{TagName: "v1.2.0", PublishedAt: now.Add(-30 * 24 * time.Hour)},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want := "/repos/casey/just/releases"; r.URL.Path != want {
+			t.Errorf("API path = %q, want %q", r.URL.Path, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(rels)
+	}))
+	defer srv.Close()
+
+	b := backendWithBaseURL("", srv.URL)
+	pkg := &manifest.Package{
+		SourceURL:     "https://github.com/casey/just",
+		Version:       "v1.2.0",
+		MinReleaseAge: "1d",
+	}
+
+	res, err := b.Check(context.Background(), pkg)
+	if err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+	if res.Version != "v1.2.1" || res.HeldBack != "v1.3.0" {
+		t.Errorf("Version, HeldBack = %q, %q, want %q, %q", res.Version, res.HeldBack, "v1.2.1", "v1.3.0")
+	}
+	if !res.Published.Equal(rels[1].PublishedAt) {
+		t.Errorf("Published = %v, want %v", res.Published, rels[1].PublishedAt)
+	}
+
+	pkg.MinReleaseAge = "1w"
+	res, err = b.Check(context.Background(), pkg)
+	if err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+	if res.Version != "v1.2.0" || res.HeldBack != "v1.3.0" {
+		t.Errorf("Version, HeldBack = %q, %q, want %q, %q", res.Version, res.HeldBack, "v1.2.0", "v1.3.0")
+	}
+
+	pkg.MinReleaseAge = "1d"
+	pkg.Constraint = "<1.2.1"
+	res, err = b.Check(context.Background(), pkg)
+	if err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+	if res.Version != "v1.2.0" || res.HeldBack != "" {
+		t.Errorf("Version, HeldBack = %q, %q, want %q and nothing held back", res.Version, res.HeldBack, "v1.2.0")
+	}
+
+	pkg.Constraint = ""
+	pkg.MinReleaseAge = "60d"
+	if _, err := b.Check(context.Background(), pkg); err == nil {
+		t.Fatal("expected error when every release is too new, got nil")
+	}
+}
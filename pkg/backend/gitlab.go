@@ -0,0 +1,332 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/ventifus/binmgr/pkg/manifest"
+	"go.yaml.in/yaml/v3"
+)
+
+// gitlabBackend implements Backend for GitLab Releases on gitlab.com and
+// self-hosted instances.
+type gitlabBackend struct {
+	hosts      map[string]glabHostConfig // self-hosted and gitlab.com host config
+	token      string                    // GITLAB_TOKEN; used for hosts without a token of their own
+	httpClient *http.Client              // nil means use http.DefaultClient
+}
+
+// glabHostConfig holds per-host config from the glab CLI config file.
+type glabHostConfig struct {
+	Token       string `yaml:"token"`
+	APIProtocol string `yaml:"api_protocol"`
+}
+
+// glabConfig is the subset of ~/.config/glab-cli/config.yml binmgr reads.
+type glabConfig struct {
+	Hosts map[string]glabHostConfig `yaml:"hosts"`
+}
+
+// loadGlabHosts reads per-host config from the glab CLI config file.
+// Returns an empty map if the file is missing or unreadable, in which case
+// access is unauthenticated.
+func loadGlabHosts() map[string]glabHostConfig {
+	p := path.Join(os.Getenv("HOME"), ".config/glab-cli/config.yml")
+	f, err := os.Open(p)
+	if err != nil {
+		log.WithError(err).Debug("could not open glab config")
+		return map[string]glabHostConfig{}
+	}
+	defer f.Close()
+
+	var cfg glabConfig
+	if err := yaml.NewDecoder(f).Decode(&cfg); err != nil {
+		log.WithError(err).Warn("could not parse glab config; using unauthenticated GitLab access")
+		return map[string]glabHostConfig{}
+	}
+	if cfg.Hosts == nil {
+		cfg.Hosts = map[string]glabHostConfig{}
+	}
+	return cfg.Hosts
+}
+
+// NewGitLabBackend creates a new GitLab backend. Besides gitlab.com it
+// handles every host listed in the glab CLI config, the host named by
+// GITLAB_HOST, and extraHosts. Tokens come from the glab CLI config, falling
+// back to GITLAB_TOKEN.
+func NewGitLabBackend(extraHosts ...string) Backend {
+	b := &gitlabBackend{
+		hosts: loadGlabHosts(),
+		token: os.Getenv("GITLAB_TOKEN"),
+	}
+	if h := os.Getenv("GITLAB_HOST"); h != "" {
+		// glab accepts GITLAB_HOST with or without a scheme.
+		if u, err := url.Parse(h); err == nil && u.Host != "" {
+			h = u.Host
+		}
+		if _, ok := b.hosts[h]; !ok {
+			b.hosts[h] = glabHostConfig{}
+		}
+	}
+	for _, h := range extraHosts {
+		if _, ok := b.hosts[h]; !ok {
+			b.hosts[h] = glabHostConfig{}
+		}
+	}
+	return b
+}
+
+// CanHandle returns true when the URL host is gitlab.com or a configured
+// self-hosted GitLab host.
+func (g *gitlabBackend) CanHandle(u *url.URL) bool {
+	if u.Host == "gitlab.com" {
+		return true
+	}
+	_, ok := g.hosts[u.Host]
+	return ok
+}
+
+// Type returns the backend type string.
+func (g *gitlabBackend) Type() string {
+	return "gitlab"
+}
+
+// gitlabRelease is a minimal representation of the GitLab Releases API response.
+type gitlabRelease struct {
+	TagName         string    `json:"tag_name"`
+	ReleasedAt      time.Time `json:"released_at"`
+	UpcomingRelease bool      `json:"upcoming_release"`
+	Assets          struct {
+		Links []gitlabLink `json:"links"`
+	} `json:"assets"`
+}
+
+// gitlabLink is a release asset link. Generic-package uploads attached to a
+// release appear here too, with link_type "package".
+type gitlabLink struct {
+	Name           string `json:"name"`
+	URL            string `json:"url"`
+	DirectAssetURL string `json:"direct_asset_url"`
+	LinkType       string `json:"link_type"`
+}
+
+// client returns the HTTP client to use, falling back to http.DefaultClient.
+func (g *gitlabBackend) client() *http.Client {
+	if g.httpClient != nil {
+		return g.httpClient
+	}
+	return http.DefaultClient
+}
+
+// tokenFor returns the token for host, falling back to GITLAB_TOKEN.
+func (g *gitlabBackend) tokenFor(host string) string {
+	if t := g.hosts[host].Token; t != "" {
+		return t
+	}
+	return g.token
+}
+
+// apiBase returns the Releases API base URL for project on host.
+func (g *gitlabBackend) apiBase(host, project string) string {
+	scheme := g.hosts[host].APIProtocol
+	if scheme == "" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/api/v4/projects/%s/releases", scheme, host, url.PathEscape(project))
+}
+
+// doRequest performs an authenticated (if a token is available) GET request
+// to the GitLab API on host.
+func (g *gitlabBackend) doRequest(ctx context.Context, host, apiURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request for %s: %w", apiURL, err)
+	}
+	req.Header.Set("User-Agent", "binmgr")
+	if t := g.tokenFor(host); t != "" {
+		req.Header.Set("PRIVATE-TOKEN", t)
+	}
+	return g.client().Do(req)
+}
+
+// projectPath extracts the full project path from a GitLab URL path,
+// including any subgroups (e.g. /group/subgroup/project). Anything from a
+// "/-/" route separator on is dropped, as is a ".git" suffix.
+func projectPath(sourceURL *url.URL) (string, error) {
+	p := strings.Trim(sourceURL.Path, "/")
+	if i := strings.Index(p, "/-/"); i >= 0 {
+		p = p[:i]
+	}
+	p = strings.TrimSuffix(p, ".git")
+	parts := strings.Split(p, "/")
+	if len(parts) < 2 {
+		return "", fmt.Errorf("invalid GitLab URL %q: expected /group/project path", sourceURL)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return "", fmt.Errorf("invalid GitLab URL %q: expected /group/project path", sourceURL)
+		}
+	}
+	return p, nil
+}
+
+// getJSON fetches apiURL from host and decodes the response into v.
+// Returns the response header for pagination.
+func (g *gitlabBackend) getJSON(ctx context.Context, host, project, apiURL string, v any) (http.Header, error) {
+	resp, err := g.doRequest(ctx, host, apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("gitlab request to %s: %w", apiURL, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// handled below
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%s/%s: release not found (404)", host, project)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, fmt.Errorf("%s/%s: authentication error (%d)", host, project, resp.StatusCode)
+	case http.StatusTooManyRequests:
+		return nil, fmt.Errorf("%s/%s: rate limited (429)", host, project)
+	default:
+		return nil, fmt.Errorf("%s/%s: unexpected status %d", host, project, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return nil, fmt.Errorf("decoding GitLab release response: %w", err)
+	}
+	return resp.Header, nil
+}
+
+// listReleases returns the published releases of project, newest first,
+// reading at most pages pages of perPage releases.
+func (g *gitlabBackend) listReleases(ctx context.Context, host, project string, perPage, pages int) ([]gitlabRelease, error) {
+	var published []gitlabRelease
+	apiURL := fmt.Sprintf("%s?order_by=released_at&sort=desc&per_page=%d", g.apiBase(host, project), perPage)
+	for page := 0; apiURL != "" && page < pages; page++ {
+		log.WithField("url", apiURL).Debug("listing GitLab releases")
+
+		var rels []gitlabRelease
+		header, err := g.getJSON(ctx, host, project, apiURL, &rels)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rels {
+			// A release with a future released_at sorts first but is not
+			// published yet.
+			if !r.UpcomingRelease {
+				published = append(published, r)
+			}
+		}
+		apiURL = nextPageURL(header.Get("Link"))
+	}
+	if len(published) == 0 {
+		return nil, fmt.Errorf("%s/%s: no published releases", host, project)
+	}
+	return published, nil
+}
+
+// resolveRelease resolves a GitLab release and returns a Resolution: the
+// named version, the newest release satisfying opts.Constraint and
+// opts.MinReleaseAge, or the newest release.
+func (g *gitlabBackend) resolveRelease(ctx context.Context, host, project string, opts ResolveOptions) (*Resolution, error) {
+	con, err := parseConstraint(opts.Constraint)
+	if err != nil {
+		return nil, err
+	}
+	f := releaseFilter{constraint: con, minAge: opts.MinReleaseAge, now: time.Now()}
+
+	var rel gitlabRelease
+	var ch choice
+	switch {
+	case opts.Version != "":
+		apiURL := g.apiBase(host, project) + "/" + url.PathEscape(opts.Version)
+		log.WithField("url", apiURL).Debug("resolving GitLab release")
+		if _, err := g.getJSON(ctx, host, project, apiURL, &rel); err != nil {
+			return nil, err
+		}
+	case !f.active():
+		// Upcoming releases may fill the first few entries; a page of 20
+		// leaves room to find the newest one already published.
+		rels, err := g.listReleases(ctx, host, project, 20, 1)
+		if err != nil {
+			return nil, err
+		}
+		rel = rels[0]
+	default:
+		rels, err := g.listReleases(ctx, host, project, 100, maxReleasePages)
+		if err != nil {
+			return nil, err
+		}
+		tags := make([]string, len(rels))
+		published := make([]time.Time, len(rels))
+		for i, r := range rels {
+			tags[i], published[i] = r.TagName, r.ReleasedAt
+		}
+		if ch = f.choose(tags, published); ch.index < 0 {
+			return nil, fmt.Errorf("%s/%s: %s", host, project, f.noRelease())
+		}
+		rel = rels[ch.index]
+	}
+
+	assets := make([]Asset, 0, len(rel.Assets.Links))
+	for _, l := range rel.Assets.Links {
+		// direct_asset_url is the stable permalink; url may point at a
+		// generic package or an external host.
+		u := l.DirectAssetURL
+		if u == "" {
+			u = l.URL
+		}
+		assets = append(assets, Asset{Name: l.Name, URL: u})
+	}
+
+	return &Resolution{
+		Version:   rel.TagName,
+		Assets:    assets,
+		Latest:    ch.latest,
+		Published: rel.ReleasedAt,
+		HeldBack:  ch.heldBack,
+	}, nil
+}
+
+// Resolve resolves a specific release, or the newest allowed by
+// opts.Constraint and opts.MinReleaseAge, for the given URL.
+func (g *gitlabBackend) Resolve(ctx context.Context, sourceURL *url.URL, opts ResolveOptions) (*Resolution, error) {
+	if err := rejectGitHubOptions(g.Type(), opts); err != nil {
+		return nil, err
+	}
+	project, err := projectPath(sourceURL)
+	if err != nil {
+		return nil, err
+	}
+	return g.resolveRelease(ctx, sourceURL.Host, project, opts)
+}
+
+// Check returns the newest release satisfying the package's constraint and
+// minimum release age, which the manager compares to pkg.Version.
+func (g *gitlabBackend) Check(ctx context.Context, pkg *manifest.Package) (*Resolution, error) {
+	if err := requireStableChannel(g.Type(), pkg.Channel); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(pkg.SourceURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing source URL %q: %w", pkg.SourceURL, err)
+	}
+	project, err := projectPath(u)
+	if err != nil {
+		return nil, err
+	}
+	minAge, err := minReleaseAge(pkg)
+	if err != nil {
+		return nil, err
+	}
+	return g.resolveRelease(ctx, u.Host, project, ResolveOptions{Constraint: pkg.Constraint, MinReleaseAge: minAge})
+}
@@ -0,0 +1,328 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ventifus/binmgr/pkg/manifest"
+)
+
+// fixtureGitLabRelease builds a sample GitLab Releases API response.
+func fixtureGitLabRelease(tag string, upcoming bool) gitlabRelease {
+	var rel gitlabRelease
+	rel.TagName = tag
+	rel.UpcomingRelease = upcoming
+	rel.Assets.Links = []gitlabLink{
+		{
+			Name:           "tool-linux-amd64.tar.gz",
+			URL:            "https://gitlab.com/api/v4/projects/42/packages/generic/tool/" + tag + "/tool-linux-amd64.tar.gz",
+			DirectAssetURL: "https://gitlab.com/group/sub/tool/-/releases/" + tag + "/downloads/tool-linux-amd64.tar.gz",
+			LinkType:       "package",
+		},
+		{
+			Name:     "checksums.txt",
+			URL:      "https://example.com/tool/" + tag + "/checksums.txt",
+			LinkType: "other",
+		},
+	}
+	return rel
+}
+
+// newGitLabTestServer serves body as JSON and records the escaped request
+// path, query and PRIVATE-TOKEN header of the last request.
+func newGitLabTestServer(t *testing.T, body any, statusCode int) (*httptest.Server, *http.Request) {
+	t.Helper()
+	captured := &http.Request{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*captured = *r.Clone(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		if statusCode == http.StatusOK {
+			if err := json.NewEncoder(w).Encode(body); err != nil {
+				t.Errorf("encoding fixture: %v", err)
+			}
+		}
+	}))
+	return srv, captured
+}
+
+// gitlabBackendWithBaseURL returns a gitlabBackend that redirects API calls
+// to baseURL.
+func gitlabBackendWithBaseURL(hosts map[string]glabHostConfig, token, baseURL string) *gitlabBackend {
+	if hosts == nil {
+		hosts = map[string]glabHostConfig{}
+	}
+	return &gitlabBackend{
+		hosts:      hosts,
+		token:      token,
+		httpClient: &http.Client{Transport: &rewriteTransport{base: baseURL}},
+	}
+}
+
+func TestGitLabCanHandle(t *testing.T) {
+	b := gitlabBackendWithBaseURL(map[string]glabHostConfig{"gitlab.example.com": {}}, "", "http://unused")
+
+	tests := []struct {
+		rawURL string
+		want   bool
+	}{
+		{"https://gitlab.com/group/project", true},
+		{"https://gitlab.com/group/sub/project", true},
+		{"https://gitlab.example.com/tools/cli", true},
+		{"https://github.com/casey/just", false},
+		{"https://gitlab.other.com/group/project", false},
+	}
+
+	for _, tt := range tests {
+		u, err := url.Parse(tt.rawURL)
+		if err != nil {
+			t.Fatalf("parsing %q: %v", tt.rawURL, err)
+		}
+		if got := b.CanHandle(u); got != tt.want {
+			t.Errorf("CanHandle(%q) = %v, want %v", tt.rawURL, got, tt.want)
+		}
+	}
+}
+
+func TestGitLabNewBackendHostFromEnv(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GITLAB_HOST", "https://gitlab.corp.example")
+
+	b := NewGitLabBackend()
+	u, _ := url.Parse("https://gitlab.corp.example/team/tool")
+	if !b.CanHandle(u) {
+		t.Errorf("CanHandle(%q) = false, want true for GITLAB_HOST", u)
+	}
+}
+
+func TestNewGitLabBackendConfiguredHosts(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GITLAB_HOST", "")
+
+	b := NewGitLabBackend("gitlab.example.com").(*gitlabBackend)
+	for rawURL, want := range map[string]bool{
+		"https://gitlab.com/group/tool":         true,
+		"https://gitlab.example.com/group/tool": true,
+		"https://gitlab.other.com/group/tool":   false,
+	} {
+		u, _ := url.Parse(rawURL)
+		if got := b.CanHandle(u); got != want {
+			t.Errorf("CanHandle(%q) = %v, want %v", rawURL, got, want)
+		}
+	}
+	if got, want := b.apiBase("gitlab.example.com", "group/tool"), "https://gitlab.example.com/api/v4/projects/group%2Ftool/releases"; got != want {
+		t.Errorf("apiBase = %q, want %q", got, want)
+	}
+}
+
+func TestGitLabType(t *testing.T) {
+	b := gitlabBackendWithBaseURL(nil, "", "http://unused")
+	if got := b.Type(); got != "gitlab" {
+		t.Errorf("Type() = %q, want %q", got, "gitlab")
+	}
+}
+
+func TestGitLabProjectPath(t *testing.T) {
+	tests := []struct {
+		rawURL  string
+		want    string
+		wantErr bool
+	}{
+		{"https://gitlab.com/group/project", "group/project", false},
+		{"https://gitlab.com/group/sub/deeper/project", "group/sub/deeper/project", false},
+		{"https://gitlab.com/group/project/", "group/project", false},
+		{"https://gitlab.com/group/project.git", "group/project", false},
+		{"https://gitlab.com/group/sub/project/-/releases", "group/sub/project", false},
+		{"https://gitlab.com/project", "", true},
+		{"https://gitlab.com/", "", true},
+	}
+
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.rawURL)
+		got, err := projectPath(u)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("projectPath(%q) error = %v, wantErr %v", tt.rawURL, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("projectPath(%q) = %q, want %q", tt.rawURL, got, tt.want)
+		}
+	}
+}
+
+func TestGitLabResolveLatest(t *testing.T) {
+	rels := []gitlabRelease{
+		fixtureGitLabRelease("v2.0.0", true),
+		fixtureGitLabRelease("v1.2.3", false),
+		fixtureGitLabRelease("v1.2.2", false),
+	}
+	srv, captured := newGitLabTestServer(t, rels, http.StatusOK)
+	defer srv.Close()
+
+	b := gitlabBackendWithBaseURL(nil, "", srv.URL)
+	u, _ := url.Parse("https://gitlab.com/group/sub/tool")
+
+	res, err := b.Resolve(context.Background(), u, ResolveOptions{})
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if res.Version != "v1.2.3" {
+		t.Errorf("Version = %q, want %q (upcoming release must be skipped)", res.Version, "v1.2.3")
+	}
+	wantPath := "/api/v4/projects/group%2Fsub%2Ftool/releases"
+	if got := captured.URL.EscapedPath(); got != wantPath {
+		t.Errorf("API path = %q, want %q", got, wantPath)
+	}
+	if got := captured.URL.Query().Get("order_by"); got != "released_at" {
+		t.Errorf("order_by = %q, want %q", got, "released_at")
+	}
+	if len(res.Assets) != 2 {
+		t.Fatalf("len(Assets) = %d, want 2", len(res.Assets))
+	}
+	if want := "https://gitlab.com/group/sub/tool/-/releases/v1.2.3/downloads/tool-linux-amd64.tar.gz"; res.Assets[0].URL != want {
+		t.Errorf("Assets[0].URL = %q, want direct asset URL %q", res.Assets[0].URL, want)
+	}
+	if want := "https://example.com/tool/v1.2.3/checksums.txt"; res.Assets[1].URL != want {
+		t.Errorf("Assets[1].URL = %q, want link URL %q", res.Assets[1].URL, want)
+	}
+	if res.Assets[0].Checksums != nil {
+		t.Errorf("Assets[0].Checksums should be nil for gitlab backend")
+	}
+}
+
+func TestGitLabResolveNoPublishedReleases(t *testing.T) {
+	rels := []gitlabRelease{fixtureGitLabRelease("v2.0.0", true)}
+	srv, _ := newGitLabTestServer(t, rels, http.StatusOK)
+	defer srv.Close()
+
+	b := gitlabBackendWithBaseURL(nil, "", srv.URL)
+	u, _ := url.Parse("https://gitlab.com/group/tool")
+
+	if _, err := b.Resolve(context.Background(), u, ResolveOptions{}); err == nil {
+		t.Fatal("expected error when only upcoming releases exist, got nil")
+	}
+}
+
+func TestGitLabResolveSpecificTag(t *testing.T) {
+	srv, captured := newGitLabTestServer(t, fixtureGitLabRelease("v1.2.3", false), http.StatusOK)
+	defer srv.Close()
+
+	b := gitlabBackendWithBaseURL(nil, "", srv.URL)
+	u, _ := url.Parse("https://gitlab.com/group/sub/tool")
+
+	res, err := b.Resolve(context.Background(), u, ResolveOptions{Version: "v1.2.3"})
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if res.Version != "v1.2.3" {
+		t.Errorf("Version = %q, want %q", res.Version, "v1.2.3")
+	}
+	wantPath := "/api/v4/projects/group%2Fsub%2Ftool/releases/v1.2.3"
+	if got := captured.URL.EscapedPath(); got != wantPath {
+		t.Errorf("API path = %q, want %q", got, wantPath)
+	}
+}
+
+func TestGitLabCheck(t *testing.T) {
+	rels := []gitlabRelease{fixtureGitLabRelease("v1.2.3", false)}
+	srv, captured := newGitLabTestServer(t, rels, http.StatusOK)
+	defer srv.Close()
+
+	b := gitlabBackendWithBaseURL(map[string]glabHostConfig{"gitlab.example.com": {}}, "", srv.URL)
+	pkg := &manifest.Package{
+		SourceURL: "https://gitlab.example.com/tools/tool",
+		Version:   "v1.0.0",
+	}
+
+	res, err := b.Check(context.Background(), pkg)
+	if err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+	if res.Version != "v1.2.3" {
+		t.Errorf("Version = %q, want %q", res.Version, "v1.2.3")
+	}
+	if got := captured.Host; got != "gitlab.example.com" {
+		t.Errorf("Host header = %q, want %q", got, "gitlab.example.com")
+	}
+	if !strings.HasPrefix(captured.URL.EscapedPath(), "/api/v4/projects/tools%2Ftool/releases") {
+		t.Errorf("Check API path = %q", captured.URL.EscapedPath())
+	}
+}
+
+func TestGitLabTokenHeader(t *testing.T) {
+	tests := []struct {
+		name  string
+		hosts map[string]glabHostConfig
+		token string
+		want  string
+	}{
+		{"none", nil, "", ""},
+		{"env token", nil, "env-token", "env-token"},
+		{"host token wins", map[string]glabHostConfig{"gitlab.com": {Token: "host-token"}}, "env-token", "host-token"},
+		{"other host token ignored", map[string]glabHostConfig{"gitlab.example.com": {Token: "host-token"}}, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, captured := newGitLabTestServer(t, []gitlabRelease{fixtureGitLabRelease("v1.2.3", false)}, http.StatusOK)
+			defer srv.Close()
+
+			b := gitlabBackendWithBaseURL(tt.hosts, tt.token, srv.URL)
+			u, _ := url.Parse("https://gitlab.com/group/tool")
+			if _, err := b.Resolve(context.Background(), u, ResolveOptions{}); err != nil {
+				t.Fatalf("Resolve returned error: %v", err)
+			}
+			if got := captured.Header.Get("PRIVATE-TOKEN"); got != tt.want {
+				t.Errorf("PRIVATE-TOKEN header = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitLabResolve404(t *testing.T) {
+	srv, _ := newGitLabTestServer(t, nil, http.StatusNotFound)
+	defer srv.Close()
+
+	b := gitlabBackendWithBaseURL(nil, "", srv.URL)
+	u, _ := url.Parse("https://gitlab.com/group/nonexistent")
+
+	if _, err := b.Resolve(context.Background(), u, ResolveOptions{Version: "v9.9.9"}); err == nil {
+		t.Fatal("expected error for 404, got nil")
+	}
+}
+
+func TestGitLabResolveConstraint(t *testing.T) {
+	rels := []gitlabRelease{
+		fixtureGitLabRelease("v2.1.0", true),
+		fixtureGitLabRelease("v2.0.0", false),
+		fixtureGitLabRelease("v1.9.3", false),
+		fixtureGitLabRelease("v1.9.2", false),
+	}
+	srv, captured := newGitLabTestServer(t, rels, http.StatusOK)
+	defer srv.Close()
+
+	b := gitlabBackendWithBaseURL(nil, "", srv.URL)
+	u, _ := url.Parse("https://gitlab.com/group/sub/tool")
+
+	res, err := b.Resolve(context.Background(), u, ResolveOptions{Constraint: "<2.0"})
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if res.Version != "v1.9.3" {
+		t.Errorf("Version = %q, want %q", res.Version, "v1.9.3")
+	}
+	if res.Latest != "v2.0.0" {
+		t.Errorf("Latest = %q, want %q", res.Latest, "v2.0.0")
+	}
+	if got := captured.URL.Query().Get("per_page"); got != "100" {
+		t.Errorf("per_page = %q, want %q", got, "100")
+	}
+	if want := "https://gitlab.com/group/sub/tool/-/releases/v1.9.3/downloads/tool-linux-amd64.tar.gz"; res.Assets[0].URL != want {
+		t.Errorf("Assets[0].URL = %q, want %q", res.Assets[0].URL, want)
+	}
+}
@@ -6,9 +6,12 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"path"
 	"strings"
+	"time"
 
 	"github.com/ventifus/binmgr/pkg/manifest"
+	"github.com/ventifus/binmgr/pkg/version"
 )
 
 type kubeBackend struct {
@@ -29,49 +32,125 @@ func (k *kubeBackend) Type() string {
 	return "kubeurl"
 }
 
-func (k *kubeBackend) fetchVersion(ctx context.Context, versionURL string) (string, error) {
+// fetchVersion returns the version a pointer file such as stable.txt names,
+// and when the file last changed.
+func (k *kubeBackend) fetchVersion(ctx context.Context, versionURL string) (string, time.Time, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, versionURL, nil)
 	if err != nil {
-		return "", fmt.Errorf("kubeurl: create request: %w", err)
+		return "", time.Time{}, fmt.Errorf("kubeurl: create request: %w", err)
 	}
 	req.Header.Set("User-Agent", "binmgr")
 
 	resp, err := k.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("kubeurl: fetch %s: %w", versionURL, err)
+		return "", time.Time{}, fmt.Errorf("kubeurl: fetch %s: %w", versionURL, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("kubeurl: fetch %s: unexpected status %s", versionURL, resp.Status)
+		return "", time.Time{}, fmt.Errorf("kubeurl: fetch %s: unexpected status %s", versionURL, resp.Status)
 	}
 
 	b, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("kubeurl: read response from %s: %w", versionURL, err)
+		return "", time.Time{}, fmt.Errorf("kubeurl: read response from %s: %w", versionURL, err)
 	}
 
-	return strings.TrimSpace(string(b)), nil
+	return strings.TrimSpace(string(b)), lastModified(resp.Header), nil
 }
 
+// maxKubeSeries bounds how many minor release series are probed when looking
+// for the newest version satisfying a constraint.
+const maxKubeSeries = 20
+
+// latestAllowed resolves the newest version satisfying con, probing the
+// per-series files next to versionURL ("stable-1.29.txt" beside
+// "stable.txt"), newest series first. Only the newest patch of each series
+// is a candidate.
+func (k *kubeBackend) latestAllowed(ctx context.Context, versionURL string, con *version.Constraint) (*Resolution, error) {
+	latest, published, err := k.fetchVersion(ctx, versionURL)
+	if err != nil {
+		return nil, err
+	}
+	if con.Allows(latest) {
+		return &Resolution{Version: latest, Latest: latest, Published: published}, nil
+	}
+	v, ok := version.Parse(latest)
+	if !ok || len(v.Release) < 2 {
+		return nil, fmt.Errorf("kubeurl: cannot apply constraint to version %q", latest)
+	}
+
+	u, err := url.Parse(versionURL)
+	if err != nil {
+		return nil, fmt.Errorf("kubeurl: parse %s: %w", versionURL, err)
+	}
+	dir, file := path.Split(u.Path)
+	name := strings.TrimSuffix(file, ".txt")
+	major := v.Release[0]
+	for minor := v.Release[1]; minor >= 0 && minor > v.Release[1]-maxKubeSeries; minor-- {
+		u.Path = fmt.Sprintf("%s%s-%d.%d.txt", dir, name, major, minor)
+		s, published, err := k.fetchVersion(ctx, u.String())
+		if err != nil {
+			return nil, err
+		}
+		if con.Allows(s) {
+			return &Resolution{Version: s, Latest: latest, Published: published}, nil
+		}
+	}
+	return nil, fmt.Errorf("kubeurl: no release of %s satisfies constraint %q", versionURL, con)
+}
+
+// Resolve returns opts.Version as given, or the version stable.txt names (with
+// a constraint, the newest allowed). As no older version can be listed, one
+// younger than opts.MinReleaseAge is an error.
 func (k *kubeBackend) Resolve(ctx context.Context, sourceURL *url.URL, opts ResolveOptions) (*Resolution, error) {
+	if err := rejectGitHubOptions(k.Type(), opts); err != nil {
+		return nil, err
+	}
 	if opts.Version != "" {
 		return &Resolution{Version: opts.Version, Assets: nil}, nil
 	}
-
-	version, err := k.fetchVersion(ctx, sourceURL.String())
+	res, err := k.resolveLatest(ctx, sourceURL.String(), opts.Constraint)
 	if err != nil {
 		return nil, err
 	}
-
-	return &Resolution{Version: version, Assets: nil}, nil
+	if err := rejectTooYoung(k.Type(), res, opts.MinReleaseAge); err != nil {
+		return nil, err
+	}
+	return res, nil
 }
 
+// Check returns the version stable.txt names, or with a constraint the
+// newest allowed. As no older version can be listed, one younger than the
+// package's minimum release age is held back in favour of the installed one.
 func (k *kubeBackend) Check(ctx context.Context, pkg *manifest.Package) (*Resolution, error) {
-	version, err := k.fetchVersion(ctx, pkg.SourceURL)
+	if err := requireStableChannel(k.Type(), pkg.Channel); err != nil {
+		return nil, err
+	}
+	minAge, err := minReleaseAge(pkg)
+	if err != nil {
+		return nil, err
+	}
+	res, err := k.resolveLatest(ctx, pkg.SourceURL, pkg.Constraint)
 	if err != nil {
 		return nil, err
 	}
+	return holdBack(pkg, res, minAge), nil
+}
 
-	return &Resolution{Version: version, Assets: nil}, nil
+// resolveLatest returns the version named by versionURL, or with a
+// constraint the newest version satisfying it.
+func (k *kubeBackend) resolveLatest(ctx context.Context, versionURL, constraint string) (*Resolution, error) {
+	con, err := parseConstraint(constraint)
+	if err != nil {
+		return nil, err
+	}
+	if con == nil {
+		v, published, err := k.fetchVersion(ctx, versionURL)
+		if err != nil {
+			return nil, err
+		}
+		return &Resolution{Version: v, Assets: nil, Published: published}, nil
+	}
+	return k.latestAllowed(ctx, versionURL, con)
 }
@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/ventifus/binmgr/pkg/manifest"
 )
@@ -212,3 +213,111 @@ func TestKubeBackend_Check_NonOKStatusReturnsError(t *testing.T) {
 		t.Error("Check() expected error for non-200 response, got nil")
 	}
 }
+
+func TestKubeBackend_Resolve_RejectsReleaseID(t *testing.T) {
+	b := NewKubeBackend()
+	u, _ := url.Parse("https://dl.k8s.io/release/stable.txt")
+
+	if _, err := b.Resolve(context.Background(), u, ResolveOptions{ReleaseID: 1}); err == nil {
+		t.Fatal("expected error for release ID, got nil")
+	}
+}
+
+func TestKubeBackend_Check_ConstraintProbesSeries(t *testing.T) {
+	versions := map[string]string{
+		"/release/stable.txt":      "v1.31.2",
+		"/release/stable-1.31.txt": "v1.31.2",
+		"/release/stable-1.30.txt": "v1.30.6",
+		"/release/stable-1.29.txt": "v1.29.10",
+	}
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		v, ok := versions[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(v + "\n"))
+	}))
+	defer server.Close()
+
+	b := &kubeBackend{client: server.Client()}
+	pkg := &manifest.Package{
+		SourceURL:  server.URL + "/release/stable.txt",
+		Version:    "v1.29.9",
+		Constraint: "~1.29",
+	}
+
+	res, err := b.Check(context.Background(), pkg)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if res.Version != "v1.29.10" {
+		t.Errorf("Check() Version = %q, want %q", res.Version, "v1.29.10")
+	}
+	if res.Latest != "v1.31.2" {
+		t.Errorf("Check() Latest = %q, want %q", res.Latest, "v1.31.2")
+	}
+	if len(paths) != 4 {
+		t.Errorf("fetched %v, want stable.txt then series 1.31 to 1.29", paths)
+	}
+}
+
+func TestKubeBackend_Check_MinReleaseAgeHoldsBack(t *testing.T) {
+	modified := time.Now().Add(-time.Hour)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+		w.Write([]byte("v1.35.0\n"))
+	}))
+	defer server.Close()
+
+	b := &kubeBackend{client: server.Client()}
+	pkg := &manifest.Package{
+		SourceURL:     server.URL,
+		Version:       "v1.34.2",
+		MinReleaseAge: "3d",
+	}
+
+	res, err := b.Check(context.Background(), pkg)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if res.Version != "v1.34.2" || res.HeldBack != "v1.35.0" {
+		t.Errorf("Check() Version, HeldBack = %q, %q, want %q, %q", res.Version, res.HeldBack, "v1.34.2", "v1.35.0")
+	}
+
+	pkg.MinReleaseAge = "30m"
+	res, err = b.Check(context.Background(), pkg)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if res.Version != "v1.35.0" || res.HeldBack != "" {
+		t.Errorf("Check() Version, HeldBack = %q, %q, want %q and nothing held back", res.Version, res.HeldBack, "v1.35.0")
+	}
+	if got := res.Published.Unix(); got != modified.Unix() {
+		t.Errorf("Check() Published = %v, want %v", res.Published, modified)
+	}
+}
+
+func TestKubeBackend_Resolve_MinReleaseAgeRejectsYoungRelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Last-Modified", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+		w.Write([]byte("v1.35.0\n"))
+	}))
+	defer server.Close()
+
+	b := &kubeBackend{client: server.Client()}
+	u, _ := url.Parse(server.URL)
+
+	if _, err := b.Resolve(context.Background(), u, ResolveOptions{MinReleaseAge: 72 * time.Hour}); err == nil {
+		t.Fatal("Resolve() expected error for a release younger than the minimum age, got nil")
+	}
+	res, err := b.Resolve(context.Background(), u, ResolveOptions{MinReleaseAge: 30 * time.Minute})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if res.Version != "v1.35.0" {
+		t.Errorf("Resolve() Version = %q, want %q", res.Version, "v1.35.0")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"net/url"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/ventifus/binmgr/pkg/manifest"
 )
@@ -36,9 +37,16 @@ func (s *shasumBackend) Type() string {
 
 // Resolve fetches the sha256sum.txt at sourceURL, hashes its content to produce
 // a version, and parses each line into an Asset with a resolved download URL and
-// the embedded checksum.
+// the embedded checksum. Content changed more recently than opts.MinReleaseAge
+// is an error, as no older content can be fetched.
 func (s *shasumBackend) Resolve(ctx context.Context, sourceURL *url.URL, opts ResolveOptions) (*Resolution, error) {
-	content, err := s.fetchURL(ctx, sourceURL.String())
+	if err := rejectGitHubOptions(s.Type(), opts); err != nil {
+		return nil, err
+	}
+	if err := rejectConstraint(s.Type(), opts.Constraint); err != nil {
+		return nil, err
+	}
+	content, published, err := s.fetchURL(ctx, sourceURL.String())
 	if err != nil {
 		return nil, fmt.Errorf("shasumurl: fetch %s: %w", sourceURL, err)
 	}
@@ -50,40 +58,58 @@ func (s *shasumBackend) Resolve(ctx context.Context, sourceURL *url.URL, opts Re
 		return nil, fmt.Errorf("shasumurl: parse %s: %w", sourceURL, err)
 	}
 
-	return &Resolution{
-		Version: version,
-		Assets:  assets,
-	}, nil
+	res := &Resolution{
+		Version:   version,
+		Assets:    assets,
+		Published: published,
+	}
+	if err := rejectTooYoung(s.Type(), res, opts.MinReleaseAge); err != nil {
+		return nil, err
+	}
+	return res, nil
 }
 
 // Check re-fetches the sha256sum.txt and returns a Resolution whose Version is
 // the SHA-256 hex of the current file content. The caller compares this to
-// pkg.Version to detect updates.
+// pkg.Version to detect updates. Content changed more recently than the
+// package's minimum release age is held back in favour of the installed one.
 func (s *shasumBackend) Check(ctx context.Context, pkg *manifest.Package) (*Resolution, error) {
-	content, err := s.fetchURL(ctx, pkg.SourceURL)
+	if err := requireStableChannel(s.Type(), pkg.Channel); err != nil {
+		return nil, err
+	}
+	if err := rejectConstraint(s.Type(), pkg.Constraint); err != nil {
+		return nil, err
+	}
+	minAge, err := minReleaseAge(pkg)
+	if err != nil {
+		return nil, err
+	}
+	content, published, err := s.fetchURL(ctx, pkg.SourceURL)
 	if err != nil {
 		return nil, fmt.Errorf("shasumurl: check fetch %s: %w", pkg.SourceURL, err)
 	}
 
 	version := fmt.Sprintf("%x", sha256.Sum256(content))
-	return &Resolution{Version: version}, nil
+	return holdBack(pkg, &Resolution{Version: version, Published: published}, minAge), nil
 }
 
-// fetchURL performs an HTTP GET and returns the response body as bytes.
-func (s *shasumBackend) fetchURL(ctx context.Context, rawURL string) ([]byte, error) {
+// fetchURL performs an HTTP GET and returns the response body as bytes,
+// along with its Last-Modified time.
+func (s *shasumBackend) fetchURL(ctx context.Context, rawURL string) ([]byte, time.Time, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
 	if err != nil {
-		return nil, err
+		return nil, time.Time{}, err
 	}
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, time.Time{}, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP %d from %s", resp.StatusCode, rawURL)
+		return nil, time.Time{}, fmt.Errorf("HTTP %d from %s", resp.StatusCode, rawURL)
 	}
-	return io.ReadAll(resp.Body)
+	b, err := io.ReadAll(resp.Body)
+	return b, lastModified(resp.Header), err
 }
 
 // parseShasumFile parses a sha256sums-format file (lines of "{hex}  {filename}")
@@ -122,6 +148,7 @@ func parseShasumFile(content []byte, sourceURL *url.URL) ([]Asset, error) {
 			Checksums: map[string]string{
 				"sha-256": hexDigest,
 			},
+			ChecksumURL: sourceURL.String(),
 		})
 	}
 	if err := scanner.Err(); err != nil {
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/ventifus/binmgr/pkg/manifest"
 )
@@ -130,6 +131,9 @@ func TestShasumBackendResolve_Checksums(t *testing.T) {
 		if got != want {
 			t.Errorf("Assets[%d].Checksums[sha-256] = %q, want %q", i, got, want)
 		}
+		if got := res.Assets[i].ChecksumURL; got != sourceURL.String() {
+			t.Errorf("Assets[%d].ChecksumURL = %q, want %q", i, got, sourceURL.String())
+		}
 	}
 }
 
@@ -282,3 +286,37 @@ func hasURLPathSuffix(rawURL, suffix string) bool {
 	}
 	return len(u.Path) >= len(suffix) && u.Path[len(u.Path)-len(suffix):] == suffix
 }
+
+func TestShasumBackendCheck_RejectsConstraint(t *testing.T) {
+	b := NewShasumBackend()
+	pkg := &manifest.Package{
+		SourceURL:  "https://example.com/sha256sum.txt",
+		Constraint: "<2.0",
+	}
+
+	if _, err := b.Check(context.Background(), pkg); err == nil {
+		t.Fatal("expected error for version constraint, got nil")
+	}
+}
+
+func TestShasumBackendResolve_MinReleaseAgeRejectsYoungContent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Last-Modified", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+		_, _ = w.Write([]byte(fixtureShaSumTxt))
+	}))
+	defer srv.Close()
+
+	b := NewShasumBackend()
+	sourceURL, _ := url.Parse(srv.URL + "/sha256sum.txt")
+
+	if _, err := b.Resolve(context.Background(), sourceURL, ResolveOptions{MinReleaseAge: 72 * time.Hour}); err == nil {
+		t.Fatal("expected error for content younger than the minimum age, got nil")
+	}
+	res, err := b.Resolve(context.Background(), sourceURL, ResolveOptions{MinReleaseAge: 30 * time.Minute})
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if want := contentVersion(fixtureShaSumTxt); res.Version != want {
+		t.Errorf("Version = %q, want %q", res.Version, want)
+	}
+}
@@ -0,0 +1,142 @@
+package extract
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// format identifies a compression or archive container.
+type format int
+
+const (
+	formatUnknown format = iota
+	formatGzip
+	formatBzip2
+	formatXz
+	formatZstd
+	formatLzma
+	formatLzip
+	formatZip
+	formatTar
+)
+
+func (f format) String() string {
+	switch f {
+	case formatGzip:
+		return "gzip"
+	case formatBzip2:
+		return "bzip2"
+	case formatXz:
+		return "xz"
+	case formatZstd:
+		return "zstd"
+	case formatLzma:
+		return "lzma"
+	case formatLzip:
+		return "lzip"
+	case formatZip:
+		return "zip"
+	case formatTar:
+		return "tar"
+	default:
+		return "unknown"
+	}
+}
+
+// magics lists the signatures used to recognise a format from content.
+// Legacy .lzma streams have no signature and are recognised by suffix only.
+var magics = []struct {
+	format format
+	offset int
+	magic  []byte
+}{
+	{formatGzip, 0, []byte{0x1f, 0x8b}},
+	{formatBzip2, 0, []byte("BZh")},
+	{formatXz, 0, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{formatZstd, 0, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{formatLzip, 0, []byte("LZIP")},
+	{formatZip, 0, []byte("PK\x03\x04")},
+	{formatZip, 0, []byte("PK\x05\x06")}, // empty archive
+	{formatTar, 257, []byte("ustar")},    // POSIX and GNU tar
+}
+
+// sniffLen is the number of leading bytes needed to check every signature.
+const sniffLen = 262
+
+// sniffFormat identifies data's format from its leading bytes.  It returns
+// formatUnknown when no signature matches.
+func sniffFormat(data []byte) format {
+	for _, m := range magics {
+		end := m.offset + len(m.magic)
+		if len(data) >= end && bytes.Equal(data[m.offset:end], m.magic) {
+			return m.format
+		}
+	}
+	return formatUnknown
+}
+
+// suffixHint is what an asset's filename claims about its content: the
+// outermost format, and whether a tar archive sits beneath the compression.
+type suffixHint struct {
+	outer format
+	tar   bool
+}
+
+// suffixHints maps filename suffixes to hints.  Longer suffixes come first so
+// that ".tar.gz" is preferred over ".gz".
+var suffixHints = []struct {
+	suffix string
+	hint   suffixHint
+}{
+	{".tar.gz", suffixHint{formatGzip, true}},
+	{".tgz", suffixHint{formatGzip, true}},
+	{".tar.bz2", suffixHint{formatBzip2, true}},
+	{".tbz", suffixHint{formatBzip2, true}},
+	{".tbz2", suffixHint{formatBzip2, true}},
+	{".tar.xz", suffixHint{formatXz, true}},
+	{".txz", suffixHint{formatXz, true}},
+	{".tar.zst", suffixHint{formatZstd, true}},
+	{".tzst", suffixHint{formatZstd, true}},
+	{".tar.lzma", suffixHint{formatLzma, true}},
+	{".tar.lz", suffixHint{formatLzip, true}},
+	{".gz", suffixHint{formatGzip, false}},
+	{".bz2", suffixHint{formatBzip2, false}},
+	{".xz", suffixHint{formatXz, false}},
+	{".zst", suffixHint{formatZstd, false}},
+	{".lzma", suffixHint{formatLzma, false}},
+	{".lz", suffixHint{formatLzip, false}},
+	{".zip", suffixHint{formatZip, false}},
+	{".tar", suffixHint{formatTar, false}},
+}
+
+// hintFromName returns the hint implied by name's suffix.
+func hintFromName(name string) suffixHint {
+	lower := strings.ToLower(name)
+	for _, h := range suffixHints {
+		if strings.HasSuffix(lower, h.suffix) {
+			return h.hint
+		}
+	}
+	return suffixHint{}
+}
+
+// detectFormat determines the outermost format of data.  Content signatures
+// take precedence over the filename: a ".tar.gz" that is really a plain tar
+// is extracted as tar, and an extensionless gzip file is decompressed.  The
+// suffix is trusted only for formats without a reliable signature (legacy
+// .lzma and pre-POSIX tar).  A suffix that names a signed format the content
+// does not match is an error rather than a silent pass-through.
+func detectFormat(name string, data []byte, hint suffixHint) (format, error) {
+	if f := sniffFormat(data); f != formatUnknown {
+		return f, nil
+	}
+	switch hint.outer {
+	case formatUnknown:
+		return formatUnknown, nil
+	case formatLzma, formatTar:
+		return hint.outer, nil
+	default:
+		return formatUnknown, fmt.Errorf("%q is named like a %s file but its content is not %s", name, hint.outer, hint.outer)
+	}
+}
@@ -3,26 +3,67 @@ package extract
 import (
 	"archive/tar"
 	"archive/zip"
+	"bufio"
 	"bytes"
 	"compress/bzip2"
 	"compress/gzip"
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+	"github.com/ulikunitz/xz/lzma"
+	"github.com/ventifus/binmgr/pkg/internal/ctxio"
 )
 
-// ExtractedFile holds a single file produced by an extraction pass.
+// ExtractedFile describes a single file produced by an extraction pass.
 type ExtractedFile struct {
-	// SourcePath is the path within the archive.  It is empty for
-	// plain-compressed files (.gz / .bz2 without a tar wrapper).
+	// SourcePath is the path within the archive.  It is empty for a file
+	// that is not an archive, such as a plain-compressed one (.gz / .xz /
+	// ... without a tar wrapper).
 	SourcePath string
-	Data       []byte
+	// Path is where the file's content is on disk: a new file in the
+	// caller's scratch directory, or the input file itself when it is
+	// passed through unchanged.
+	Path string
 }
 
-// Extractor extracts files from an in-memory archive.
+// Entry describes a file as listed by Extractor.List, without extracting it.
+type Entry struct {
+	// SourcePath is the path within the archive, as in ExtractedFile.
+	SourcePath string
+	// Head holds the first bytes of the file's content, up to headLen, to
+	// tell what kind of file it is.
+	Head []byte
+}
+
+// headLen is how much of each file's content List reads.
+const headLen = 16
+
+// Extractor extracts files from an archive that has been spooled to disk.
 type Extractor interface {
-	Extract(ctx context.Context, name string, data []byte, globs []string) ([]ExtractedFile, error)
+	// Extract reads the archive at path and writes each entry matching
+	// globs to a new file under dir.  name is the original asset filename,
+	// used as a format hint.  Archives are streamed, so memory use does not
+	// depend on the size of the archive or its entries.
+	Extract(ctx context.Context, name, path string, globs []string, dir string) ([]ExtractedFile, error)
+
+	// Decompress strips the compression layer from a plain-compressed file
+	// at path, writing its payload to a new file under dir, and returns the
+	// payload's path.  Anything else, archives included, is left alone and
+	// path itself is returned.
+	Decompress(ctx context.Context, name, path string, dir string) (string, error)
+
+	// List returns the regular files in the archive at path without
+	// writing any of them out.  Content that is not an archive is listed
+	// as a single entry with an empty SourcePath, and the head of its
+	// decompressed payload if it is compressed.
+	List(ctx context.Context, name, path string) ([]Entry, error)
 }
 
 type extractor struct{}
@@ -32,68 +73,258 @@ func NewExtractor() Extractor {
 	return &extractor{}
 }
 
-// Extract dispatches to the appropriate format handler based on the
-// asset filename (name).  Format detection is performed on the longest
-// matching suffix first.
-func (e *extractor) Extract(ctx context.Context, name string, data []byte, globs []string) ([]ExtractedFile, error) {
-	lower := strings.ToLower(name)
+// Extract detects the format of the file at path from its content, using
+// the asset filename (name) only as a hint, and dispatches to the matching
+// handler.  A single compression layer is stripped first; if the result is
+// a tar archive (by signature, or by a ".tar.*" style name) it is extracted,
+// otherwise the decompressed payload is written out.
+//
+// Data that is not an archive, compressed or not, has no entries for globs
+// to select: it is returned as a single file when globs is empty, and is an
+// error when traversal globs are given.
+func (e *extractor) Extract(ctx context.Context, name, path string, globs []string, dir string) ([]ExtractedFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
 
-	switch {
-	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
-		decompressed, err := gunzip(data)
-		if err != nil {
-			return nil, err
-		}
-		return extractTar(decompressed, globs)
+	head, err := peek(f)
+	if err != nil {
+		return nil, err
+	}
+	hint := hintFromName(name)
+	format, err := detectFormat(name, head, hint)
+	if err != nil {
+		return nil, err
+	}
 
-	case strings.HasSuffix(lower, ".tar.bz2"),
-		strings.HasSuffix(lower, ".tbz"),
-		strings.HasSuffix(lower, ".tbz2"):
-		decompressed, err := bunzip2(data)
+	switch format {
+	case formatZip:
+		info, err := f.Stat()
 		if err != nil {
 			return nil, err
 		}
-		return extractTar(decompressed, globs)
+		return extractZip(ctx, f, info.Size(), globs, dir)
 
-	case strings.HasSuffix(lower, ".gz"):
-		// plain gzip — globs are ignored
-		decompressed, err := gunzip(data)
-		if err != nil {
-			return nil, err
+	case formatTar:
+		return extractTar(ctx, f, globs, dir)
+
+	case formatUnknown:
+		if len(globs) > 0 {
+			return nil, notArchive(name, globs)
 		}
-		return []ExtractedFile{{SourcePath: "", Data: decompressed}}, nil
+		return []ExtractedFile{{SourcePath: "", Path: path}}, nil
+	}
+
+	dr, isTar, err := decompress(format, hint, f)
+	if err != nil {
+		return nil, fmt.Errorf("%s decompress %q: %w", format, name, err)
+	}
+	defer dr.Close()
+	if isTar {
+		return extractTar(ctx, dr, globs, dir)
+	}
+	if len(globs) > 0 {
+		return nil, notArchive(name, globs)
+	}
+	out, err := spool(ctx, dr, dir)
+	if err != nil {
+		return nil, fmt.Errorf("%s decompress %q: %w", format, name, err)
+	}
+	return []ExtractedFile{{SourcePath: "", Path: out}}, nil
+}
+
+func (e *extractor) Decompress(ctx context.Context, name, path string, dir string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
 
-	case strings.HasSuffix(lower, ".bz2"):
-		// plain bzip2 — globs are ignored
-		decompressed, err := bunzip2(data)
+	head, err := peek(f)
+	if err != nil {
+		return "", err
+	}
+	hint := hintFromName(name)
+	format, err := detectFormat(name, head, hint)
+	if err != nil {
+		return "", err
+	}
+	if format == formatZip || format == formatTar || format == formatUnknown {
+		return path, nil
+	}
+
+	dr, isTar, err := decompress(format, hint, f)
+	if err != nil {
+		return "", fmt.Errorf("%s decompress %q: %w", format, name, err)
+	}
+	defer dr.Close()
+	if isTar {
+		return path, nil
+	}
+	out, err := spool(ctx, dr, dir)
+	if err != nil {
+		return "", fmt.Errorf("%s decompress %q: %w", format, name, err)
+	}
+	return out, nil
+}
+
+func (e *extractor) List(ctx context.Context, name, path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	head, err := peek(f)
+	if err != nil {
+		return nil, err
+	}
+	hint := hintFromName(name)
+	format, err := detectFormat(name, head, hint)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case formatZip:
+		info, err := f.Stat()
 		if err != nil {
 			return nil, err
 		}
-		return []ExtractedFile{{SourcePath: "", Data: decompressed}}, nil
+		return listZip(ctx, f, info.Size())
 
-	case strings.HasSuffix(lower, ".zip"):
-		return extractZip(data, globs)
+	case formatTar:
+		return listTar(ctx, f)
 
-	default:
-		// Return as-is; globs are not applied to plain files.
-		return []ExtractedFile{{SourcePath: "", Data: data}}, nil
+	case formatUnknown:
+		return []Entry{{Head: clip(head)}}, nil
+	}
+
+	dr, isTar, err := decompress(format, hint, f)
+	if err != nil {
+		return nil, fmt.Errorf("%s decompress %q: %w", format, name, err)
+	}
+	defer dr.Close()
+	if isTar {
+		return listTar(ctx, dr)
+	}
+	inner, err := dr.Peek(headLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s decompress %q: %w", format, name, err)
+	}
+	return []Entry{{Head: clip(inner)}}, nil
+}
+
+// clip returns a copy of at most the first headLen bytes of b.
+func clip(b []byte) []byte {
+	return bytes.Clone(b[:min(len(b), headLen)])
+}
+
+// readHead reads up to headLen bytes from r.
+func readHead(r io.Reader) ([]byte, error) {
+	head := make([]byte, headLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
 	}
+	return head[:n], nil
 }
 
-// gunzip decompresses a gzip stream.
-func gunzip(data []byte) ([]byte, error) {
-	r, err := gzip.NewReader(bytes.NewReader(data))
+// notArchive is the error for traversal globs applied to a file that has
+// no entries.
+func notArchive(name string, globs []string) error {
+	return fmt.Errorf("%q is not a recognised archive; cannot apply traversal globs %q", name, globs)
+}
+
+// decompress strips compression format f from r and reports whether the
+// content underneath is a tar archive, by signature or because hint says
+// so.
+func decompress(f format, hint suffixHint, r io.Reader) (*bufferedReadCloser, bool, error) {
+	dr, err := decompressor(f, r)
 	if err != nil {
+		return nil, false, err
+	}
+	br := &bufferedReadCloser{bufio.NewReaderSize(dr, sniffLen), dr}
+	inner, err := br.Peek(sniffLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		dr.Close()
+		return nil, false, err
+	}
+	return br, sniffFormat(inner) == formatTar || hint.tar, nil
+}
+
+// bufferedReadCloser reads a decompressor through a buffer, so that its
+// head can be sniffed, and closes the decompressor.
+type bufferedReadCloser struct {
+	*bufio.Reader
+	io.Closer
+}
+
+// peek reads up to sniffLen bytes from the start of f for format detection
+// and rewinds it.
+func peek(f *os.File) ([]byte, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
 		return nil, err
 	}
-	return io.ReadAll(r)
+	return head[:n], nil
 }
 
-// bunzip2 decompresses a bzip2 stream.  compress/bzip2 is read-only so
-// we only decompress here.
-func bunzip2(data []byte) ([]byte, error) {
-	r := bzip2.NewReader(bytes.NewReader(data))
-	return io.ReadAll(r)
+// decompressor wraps r in a reader that strips one compression layer of
+// format f.
+func decompressor(f format, r io.Reader) (io.ReadCloser, error) {
+	switch f {
+	case formatGzip:
+		return gzip.NewReader(r)
+	case formatBzip2:
+		// compress/bzip2 is read-only so we only decompress here.
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	case formatXz:
+		xr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(xr), nil
+	case formatZstd:
+		d, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	case formatLzma:
+		lr, err := lzma.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(lr), nil
+	case formatLzip:
+		return io.NopCloser(newLzipReader(r)), nil
+	default:
+		return nil, fmt.Errorf("%s is not a compression format", f)
+	}
+}
+
+// spool copies r into a new file under dir and returns its path.
+func spool(ctx context.Context, r io.Reader, dir string) (string, error) {
+	out, err := os.CreateTemp(dir, "extract-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(out, ctxio.Reader(ctx, r))
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(out.Name())
+		return "", err
+	}
+	return out.Name(), nil
 }
 
 // matchesGlobs reports whether an archive entry at entryPath should be
@@ -140,10 +371,10 @@ func matchesGlobs(entryPath string, globs []string) (bool, error) {
 	return false, nil
 }
 
-// extractTar iterates over a tar stream and returns all regular files
-// that match globs.
-func extractTar(data []byte, globs []string) ([]ExtractedFile, error) {
-	tr := tar.NewReader(bytes.NewReader(data))
+// extractTar iterates over a tar stream and writes all regular files that
+// match globs into dir.
+func extractTar(ctx context.Context, r io.Reader, globs []string, dir string) ([]ExtractedFile, error) {
+	tr := tar.NewReader(r)
 	var results []ExtractedFile
 	for {
 		hdr, err := tr.Next()
@@ -163,19 +394,74 @@ func extractTar(data []byte, globs []string) ([]ExtractedFile, error) {
 		if !ok {
 			continue
 		}
-		contents, err := io.ReadAll(tr)
+		out, err := spool(ctx, tr, dir)
 		if err != nil {
 			return nil, err
 		}
-		results = append(results, ExtractedFile{SourcePath: hdr.Name, Data: contents})
+		results = append(results, ExtractedFile{SourcePath: hdr.Name, Path: out})
 	}
 	return results, nil
 }
 
-// extractZip iterates over a zip archive and returns all files that
-// match globs.
-func extractZip(data []byte, globs []string) ([]ExtractedFile, error) {
-	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+// listTar lists the regular files in a tar stream.
+func listTar(ctx context.Context, r io.Reader) ([]Entry, error) {
+	tr := tar.NewReader(r)
+	var entries []Entry
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		head, err := readHead(tr)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, Entry{SourcePath: hdr.Name, Head: head})
+	}
+	return entries, nil
+}
+
+// listZip lists the files in a zip archive.
+func listZip(ctx context.Context, ra io.ReaderAt, size int64) ([]Entry, error) {
+	r, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, f := range r.File {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		head, err := readHead(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, Entry{SourcePath: f.Name, Head: head})
+	}
+	return entries, nil
+}
+
+// extractZip iterates over a zip archive and writes all files that match
+// globs into dir.
+func extractZip(ctx context.Context, ra io.ReaderAt, size int64, globs []string, dir string) ([]ExtractedFile, error) {
+	r, err := zip.NewReader(ra, size)
 	if err != nil {
 		return nil, err
 	}
@@ -195,12 +481,12 @@ func extractZip(data []byte, globs []string) ([]ExtractedFile, error) {
 		if err != nil {
 			return nil, err
 		}
-		contents, err := io.ReadAll(rc)
+		out, err := spool(ctx, rc, dir)
 		rc.Close()
 		if err != nil {
 			return nil, err
 		}
-		results = append(results, ExtractedFile{SourcePath: f.Name, Data: contents})
+		results = append(results, ExtractedFile{SourcePath: f.Name, Path: out})
 	}
 	return results, nil
 }
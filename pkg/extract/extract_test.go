@@ -6,7 +6,19 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+	"testing/iotest"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+	"github.com/ulikunitz/xz/lzma"
 )
 
 // ============================================================
@@ -77,12 +89,129 @@ func makeGz(t *testing.T, data []byte) []byte {
 	return buf.Bytes()
 }
 
+// makeTar builds an uncompressed tar archive in memory.
+func makeTar(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, data := range files {
+		hdr := &tar.Header{
+			Name:     name,
+			Typeflag: tar.TypeReg,
+			Size:     int64(len(data)),
+			Mode:     0644,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("WriteHeader(%q): %v", name, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatalf("Write(%q): %v", name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal("tar close:", err)
+	}
+	return buf.Bytes()
+}
+
+// compress runs data through the writer returned by newWriter.
+func compress(t *testing.T, data []byte, newWriter func(io.Writer) (io.WriteCloser, error)) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := newWriter(&buf)
+	if err != nil {
+		t.Fatal("new writer:", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal("compress write:", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal("compress close:", err)
+	}
+	return buf.Bytes()
+}
+
+// makeXz wraps raw bytes in an xz stream.
+func makeXz(t *testing.T, data []byte) []byte {
+	t.Helper()
+	return compress(t, data, func(w io.Writer) (io.WriteCloser, error) { return xz.NewWriter(w) })
+}
+
+// makeZst wraps raw bytes in a zstd stream.
+func makeZst(t *testing.T, data []byte) []byte {
+	t.Helper()
+	return compress(t, data, func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) })
+}
+
+// makeLzma wraps raw bytes in a legacy .lzma stream.
+func makeLzma(t *testing.T, data []byte) []byte {
+	t.Helper()
+	return compress(t, data, func(w io.Writer) (io.WriteCloser, error) { return lzma.NewWriter(w) })
+}
+
+// makeLz wraps raw bytes in a single-member lzip stream by encoding a raw
+// LZMA stream with lzip's fixed properties and adding lzip framing.
+func makeLz(t *testing.T, data []byte) []byte {
+	t.Helper()
+	const dictLog = 20
+	raw := compress(t, data, func(w io.Writer) (io.WriteCloser, error) {
+		cfg := lzma.WriterConfig{
+			Properties: &lzma.Properties{LC: 3, LP: 0, PB: 2},
+			DictCap:    1 << dictLog,
+			EOSMarker:  true,
+		}
+		return cfg.NewWriter(w)
+	})
+	stream := raw[13:] // strip the classic .lzma header
+
+	var buf bytes.Buffer
+	buf.WriteString("LZIP")
+	buf.WriteByte(1)
+	buf.WriteByte(dictLog)
+	buf.Write(stream)
+	trailer := make([]byte, 20)
+	binary.LittleEndian.PutUint32(trailer[0:4], crc32.ChecksumIEEE(data))
+	binary.LittleEndian.PutUint64(trailer[4:12], uint64(len(data)))
+	binary.LittleEndian.PutUint64(trailer[12:20], uint64(6+len(stream)+20))
+	buf.Write(trailer)
+	return buf.Bytes()
+}
+
+// extracted is an ExtractedFile with its content read back from disk.
+type extracted struct {
+	SourcePath string
+	Data       []byte
+}
+
+// extractBytes spools data to a temp file, runs ex.Extract on it, and reads
+// every resulting file back into memory.
+func extractBytes(t *testing.T, ex Extractor, name string, data []byte, globs []string) ([]extracted, error) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "asset")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("write asset: %v", err)
+	}
+	files, err := ex.Extract(context.Background(), name, path, globs, dir)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]extracted, 0, len(files))
+	for _, f := range files {
+		content, err := os.ReadFile(f.Path)
+		if err != nil {
+			t.Fatalf("read extracted file %q: %v", f.Path, err)
+		}
+		result = append(result, extracted{SourcePath: f.SourcePath, Data: content})
+	}
+	return result, nil
+}
+
 // ============================================================
 // Tests
 // ============================================================
 
 func TestExtract(t *testing.T) {
-	ctx := context.Background()
 	ex := NewExtractor()
 
 	// Shared fixture: a tar.gz with three files across two directories.
@@ -94,7 +223,7 @@ func TestExtract(t *testing.T) {
 
 	t.Run("tar_gz_empty_globs_returns_all", func(t *testing.T) {
 		data := makeTarGz(t, tarFiles)
-		got, err := ex.Extract(ctx, "archive.tar.gz", data, nil)
+		got, err := extractBytes(t, ex, "archive.tar.gz", data, nil)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -106,7 +235,7 @@ func TestExtract(t *testing.T) {
 	t.Run("tar_gz_glob_basename_matches_basename_and_full_path", func(t *testing.T) {
 		data := makeTarGz(t, tarFiles)
 		// "tool" has no slash, so it should match by basename.
-		got, err := ex.Extract(ctx, "archive.tar.gz", data, []string{"tool"})
+		got, err := extractBytes(t, ex, "archive.tar.gz", data, []string{"tool"})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -124,7 +253,7 @@ func TestExtract(t *testing.T) {
 	t.Run("tar_gz_glob_with_slash_matches_full_path_only", func(t *testing.T) {
 		data := makeTarGz(t, tarFiles)
 		// "bin/tool" contains a slash → only full-path matching.
-		got, err := ex.Extract(ctx, "archive.tar.gz", data, []string{"bin/tool"})
+		got, err := extractBytes(t, ex, "archive.tar.gz", data, []string{"bin/tool"})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -139,7 +268,7 @@ func TestExtract(t *testing.T) {
 	t.Run("tar_gz_glob_with_slash_does_not_match_basename_alone", func(t *testing.T) {
 		data := makeTarGz(t, tarFiles)
 		// "lib/tool" contains a slash but the archive has "bin/tool", not "lib/tool".
-		got, err := ex.Extract(ctx, "archive.tar.gz", data, []string{"lib/tool"})
+		got, err := extractBytes(t, ex, "archive.tar.gz", data, []string{"lib/tool"})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -151,7 +280,7 @@ func TestExtract(t *testing.T) {
 	t.Run("tar_gz_wildcard_glob", func(t *testing.T) {
 		data := makeTarGz(t, tarFiles)
 		// "bin/*" matches both entries in bin/.
-		got, err := ex.Extract(ctx, "archive.tar.gz", data, []string{"bin/*"})
+		got, err := extractBytes(t, ex, "archive.tar.gz", data, []string{"bin/*"})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -162,7 +291,7 @@ func TestExtract(t *testing.T) {
 
 	t.Run("tgz_suffix_detected", func(t *testing.T) {
 		data := makeTarGz(t, tarFiles)
-		got, err := ex.Extract(ctx, "archive.tgz", data, nil)
+		got, err := extractBytes(t, ex, "archive.tgz", data, nil)
 		if err != nil {
 			t.Fatalf(".tgz suffix not recognized: %v", err)
 		}
@@ -171,11 +300,17 @@ func TestExtract(t *testing.T) {
 		}
 	})
 
-	t.Run("plain_gz_ignores_globs", func(t *testing.T) {
+	t.Run("plain_gz_with_globs_is_error", func(t *testing.T) {
+		data := makeGz(t, []byte("just a file"))
+		if _, err := extractBytes(t, ex, "myfile.bin.gz", data, []string{"*"}); err == nil {
+			t.Error("expected error when traversal globs are applied to a plain gz, got nil")
+		}
+	})
+
+	t.Run("plain_gz_decompressed", func(t *testing.T) {
 		payload := []byte("just a file")
 		data := makeGz(t, payload)
-		// Even with a glob that would match nothing, we get the decompressed content.
-		got, err := ex.Extract(ctx, "myfile.bin.gz", data, []string{"nomatch"})
+		got, err := extractBytes(t, ex, "myfile.bin.gz", data, nil)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -196,7 +331,7 @@ func TestExtract(t *testing.T) {
 			"b/bar": []byte("bar"),
 		}
 		data := makeZip(t, zipFiles)
-		got, err := ex.Extract(ctx, "archive.zip", data, nil)
+		got, err := extractBytes(t, ex, "archive.zip", data, nil)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -211,7 +346,7 @@ func TestExtract(t *testing.T) {
 			"b/bar": []byte("bar content"),
 		}
 		data := makeZip(t, zipFiles)
-		got, err := ex.Extract(ctx, "archive.zip", data, []string{"foo"})
+		got, err := extractBytes(t, ex, "archive.zip", data, []string{"foo"})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -229,7 +364,7 @@ func TestExtract(t *testing.T) {
 			"b/foo": []byte("other foo"),
 		}
 		data := makeZip(t, zipFiles)
-		got, err := ex.Extract(ctx, "archive.zip", data, []string{"a/foo"})
+		got, err := extractBytes(t, ex, "archive.zip", data, []string{"a/foo"})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -247,7 +382,7 @@ func TestExtract(t *testing.T) {
 		}
 		data := makeZip(t, zipFiles)
 		// "b/foo" has a slash but there is no "b/foo" entry.
-		got, err := ex.Extract(ctx, "archive.zip", data, []string{"b/foo"})
+		got, err := extractBytes(t, ex, "archive.zip", data, []string{"b/foo"})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -258,7 +393,7 @@ func TestExtract(t *testing.T) {
 
 	t.Run("plain_file_returned_as_is", func(t *testing.T) {
 		payload := []byte("raw binary")
-		got, err := ex.Extract(ctx, "mybinary", payload, []string{"anything"})
+		got, err := extractBytes(t, ex, "mybinary", payload, nil)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -287,7 +422,7 @@ func TestExtract(t *testing.T) {
 		t.Run("bzip2_suffix_detected_"+suffix[1:], func(t *testing.T) {
 			// Garbage data — decompression must fail, proving the bzip2
 			// path was entered rather than the plain-file fallback.
-			_, err := ex.Extract(ctx, "archive"+suffix, []byte("not bzip2 data"), nil)
+			_, err := extractBytes(t, ex, "archive"+suffix, []byte("not bzip2 data"), nil)
 			if err == nil {
 				t.Errorf("expected error for invalid bzip2 data with suffix %q, got nil", suffix)
 			}
@@ -296,9 +431,320 @@ func TestExtract(t *testing.T) {
 
 	t.Run("plain_bz2_suffix_detected", func(t *testing.T) {
 		// Same reasoning: garbage data must trigger a bzip2 error.
-		_, err := ex.Extract(ctx, "file.bz2", []byte("not bzip2"), nil)
+		_, err := extractBytes(t, ex, "file.bz2", []byte("not bzip2"), nil)
 		if err == nil {
 			t.Error("expected error for invalid bzip2 data with .bz2 suffix, got nil")
 		}
 	})
+
+	// ── xz, zstd, lzma and lzip ──────────────────────────────────────────
+	compressed := []struct {
+		name     string
+		compress func(*testing.T, []byte) []byte
+		tarNames []string
+		rawNames []string
+	}{
+		{"xz", makeXz, []string{"archive.tar.xz", "archive.txz"}, []string{"tool.xz"}},
+		{"zstd", makeZst, []string{"archive.tar.zst", "archive.tzst"}, []string{"tool.zst"}},
+		{"lzma", makeLzma, []string{"archive.tar.lzma"}, []string{"tool.lzma"}},
+		{"lzip", makeLz, []string{"archive.tar.lz"}, []string{"tool.lz"}},
+	}
+	for _, c := range compressed {
+		for _, name := range c.tarNames {
+			t.Run(c.name+"_tar_"+name, func(t *testing.T) {
+				data := c.compress(t, makeTar(t, tarFiles))
+				got, err := extractBytes(t, ex, name, data, []string{"tool"})
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(got) != 1 {
+					t.Fatalf("got %d files, want 1", len(got))
+				}
+				if got[0].SourcePath != "bin/tool" {
+					t.Errorf("SourcePath = %q, want %q", got[0].SourcePath, "bin/tool")
+				}
+				if string(got[0].Data) != "tool binary" {
+					t.Errorf("Data = %q, want %q", got[0].Data, "tool binary")
+				}
+			})
+		}
+		for _, name := range c.rawNames {
+			t.Run(c.name+"_plain_with_globs_is_error", func(t *testing.T) {
+				data := c.compress(t, []byte("just a file"))
+				if _, err := extractBytes(t, ex, name, data, []string{"*"}); err == nil {
+					t.Errorf("expected error when traversal globs are applied to a plain %s, got nil", c.name)
+				}
+			})
+			t.Run(c.name+"_plain_decompressed", func(t *testing.T) {
+				payload := []byte("just a file")
+				data := c.compress(t, payload)
+				got, err := extractBytes(t, ex, name, data, nil)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(got) != 1 {
+					t.Fatalf("got %d files, want 1", len(got))
+				}
+				if got[0].SourcePath != "" {
+					t.Errorf("SourcePath = %q, want empty string for plain %s", got[0].SourcePath, c.name)
+				}
+				if string(got[0].Data) != string(payload) {
+					t.Errorf("Data mismatch: got %q, want %q", got[0].Data, payload)
+				}
+			})
+		}
+	}
+
+	t.Run("lzip_multi_member_concatenated", func(t *testing.T) {
+		data := append(makeLz(t, []byte("first ")), makeLz(t, []byte("second"))...)
+		got, err := extractBytes(t, ex, "tool.lz", data, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != 1 || string(got[0].Data) != "first second" {
+			t.Errorf("got %+v, want concatenated members", got)
+		}
+	})
+
+	t.Run("lzip_corrupt_trailer_rejected", func(t *testing.T) {
+		data := makeLz(t, []byte("just a file"))
+		data[len(data)-20] ^= 0xff // flip a CRC byte
+		if _, err := extractBytes(t, ex, "tool.lz", data, nil); err == nil {
+			t.Error("expected error for lzip member with bad CRC, got nil")
+		}
+	})
+
+	t.Run("plain_file_with_globs_is_error", func(t *testing.T) {
+		_, err := extractBytes(t, ex, "mybinary", []byte("raw binary"), []string{"anything"})
+		if err == nil {
+			t.Error("expected error when traversal globs are applied to a non-archive, got nil")
+		}
+	})
+
+	// ── content sniffing ─────────────────────────────────────────────────
+	t.Run("gzip_without_suffix_sniffed", func(t *testing.T) {
+		payload := []byte("compressed binary")
+		got, err := extractBytes(t, ex, "tool-linux-amd64", makeGz(t, payload), nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != 1 || string(got[0].Data) != string(payload) {
+			t.Errorf("got %+v, want decompressed payload %q", got, payload)
+		}
+	})
+
+	t.Run("tar_gz_without_suffix_sniffed", func(t *testing.T) {
+		got, err := extractBytes(t, ex, "tool-linux-amd64", makeTarGz(t, tarFiles), []string{"tool"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != 1 || got[0].SourcePath != "bin/tool" {
+			t.Errorf("got %+v, want bin/tool", got)
+		}
+	})
+
+	t.Run("tar_gz_suffix_but_plain_tar", func(t *testing.T) {
+		got, err := extractBytes(t, ex, "archive.tar.gz", makeTar(t, tarFiles), []string{"tool"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != 1 || got[0].SourcePath != "bin/tool" {
+			t.Errorf("got %+v, want bin/tool", got)
+		}
+	})
+
+	t.Run("zip_content_with_tar_suffix", func(t *testing.T) {
+		data := makeZip(t, map[string][]byte{"a/foo": []byte("foo")})
+		got, err := extractBytes(t, ex, "archive.tar.gz", data, []string{"foo"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != 1 || got[0].SourcePath != "a/foo" {
+			t.Errorf("got %+v, want a/foo", got)
+		}
+	})
+
+	t.Run("xz_tar_with_gz_suffix", func(t *testing.T) {
+		data := makeXz(t, makeTar(t, tarFiles))
+		got, err := extractBytes(t, ex, "archive.tgz", data, []string{"tool"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != 1 || got[0].SourcePath != "bin/tool" {
+			t.Errorf("got %+v, want bin/tool", got)
+		}
+	})
+
+	t.Run("zip_suffix_with_unrecognised_content_is_error", func(t *testing.T) {
+		_, err := extractBytes(t, ex, "archive.zip", []byte("not a zip"), nil)
+		if err == nil {
+			t.Error("expected error for .zip name with non-zip content, got nil")
+		}
+	})
+}
+
+func TestDecompress(t *testing.T) {
+	ex := NewExtractor()
+	tarFiles := map[string][]byte{"bin/tool": []byte("tool binary")}
+
+	tests := []struct {
+		name     string
+		asset    string
+		data     []byte
+		want     []byte
+		passThru bool
+	}{
+		{name: "plain gz", asset: "tool.gz", data: makeGz(t, []byte("tool binary")), want: []byte("tool binary")},
+		{name: "plain xz", asset: "tool-linux-amd64", data: makeXz(t, []byte("tool binary")), want: []byte("tool binary")},
+		{name: "tar gz", asset: "tool.tar.gz", data: makeTarGz(t, tarFiles), passThru: true},
+		{name: "zip", asset: "tool.zip", data: makeZip(t, tarFiles), passThru: true},
+		{name: "uncompressed", asset: "tool", data: []byte("\x7fELF"), passThru: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "asset")
+			if err := os.WriteFile(path, tc.data, 0600); err != nil {
+				t.Fatalf("write asset: %v", err)
+			}
+			got, err := ex.Decompress(context.Background(), tc.asset, path, dir)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.passThru {
+				if got != path {
+					t.Errorf("Decompress = %q, want the input file %q", got, path)
+				}
+				return
+			}
+			data, err := os.ReadFile(got)
+			if err != nil {
+				t.Fatalf("read payload: %v", err)
+			}
+			if !bytes.Equal(data, tc.want) {
+				t.Errorf("payload = %q, want %q", data, tc.want)
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	ex := NewExtractor()
+	files := map[string][]byte{
+		"bin/tool":   []byte("\x7fELF tool binary with a long body"),
+		"README.md":  []byte("# tool"),
+		"bin/script": []byte("#!/bin/sh"),
+	}
+
+	tests := []struct {
+		name  string
+		asset string
+		data  []byte
+		want  map[string]string
+	}{
+		{name: "tar gz", asset: "tool.tar.gz", data: makeTarGz(t, files),
+			want: map[string]string{"bin/tool": "\x7fELF tool binary", "README.md": "# tool", "bin/script": "#!/bin/sh"}},
+		{name: "zip", asset: "tool.zip", data: makeZip(t, files),
+			want: map[string]string{"bin/tool": "\x7fELF tool binary", "README.md": "# tool", "bin/script": "#!/bin/sh"}},
+		{name: "plain gz", asset: "tool.gz", data: makeGz(t, []byte("\x7fELF compressed")),
+			want: map[string]string{"": "\x7fELF compressed"}},
+		{name: "uncompressed", asset: "tool", data: []byte("#!/bin/sh\nexit 0\n\n"),
+			want: map[string]string{"": "#!/bin/sh\nexit 0"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "asset")
+			if err := os.WriteFile(path, tc.data, 0600); err != nil {
+				t.Fatalf("write asset: %v", err)
+			}
+			entries, err := ex.List(context.Background(), tc.asset, path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := make(map[string]string, len(entries))
+			for _, e := range entries {
+				got[e.SourcePath] = string(e.Head)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("List = %q, want %q", got, tc.want)
+			}
+			if left, _ := os.ReadDir(dir); len(left) != 1 {
+				t.Errorf("List wrote %d files, want none", len(left)-1)
+			}
+		})
+	}
+}
+
+func TestSniffFormat(t *testing.T) {
+	tarData := makeTar(t, map[string][]byte{"f": []byte("x")})
+	tests := []struct {
+		name string
+		data []byte
+		want format
+	}{
+		{"gzip", makeGz(t, []byte("x")), formatGzip},
+		{"xz", makeXz(t, []byte("x")), formatXz},
+		{"zstd", makeZst(t, []byte("x")), formatZstd},
+		{"lzip", makeLz(t, []byte("x")), formatLzip},
+		{"bzip2", []byte("BZh91AY&SY"), formatBzip2},
+		{"zip", makeZip(t, map[string][]byte{"f": []byte("x")}), formatZip},
+		{"tar", tarData, formatTar},
+		{"elf", []byte("\x7fELF\x02\x01\x01"), formatUnknown},
+		{"empty", nil, formatUnknown},
+	}
+	for _, tt := range tests {
+		if got := sniffFormat(tt.data); got != tt.want {
+			t.Errorf("sniffFormat(%s) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestLzipReader checks multi-member streams whose members are larger than
+// the read buffer, read through sources that return short reads.
+func TestLzipReader(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	large := make([]byte, 64<<10)
+	for i := range large {
+		large[i] = byte(rng.UintN(256)) // incompressible, so the member spans many buffer fills
+	}
+	members := [][]byte{[]byte("first "), large, {}, []byte("last")}
+	var stream, want []byte
+	for _, m := range members {
+		stream = append(stream, makeLz(t, m)...)
+		want = append(want, m...)
+	}
+
+	sources := map[string]func(io.Reader) io.Reader{
+		"whole":    func(r io.Reader) io.Reader { return r },
+		"one_byte": iotest.OneByteReader,
+		"half":     iotest.HalfReader,
+	}
+	for name, wrap := range sources {
+		t.Run(name, func(t *testing.T) {
+			got, err := io.ReadAll(newLzipReader(wrap(bytes.NewReader(stream))))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("got %d bytes, want %d bytes of concatenated members", len(got), len(want))
+			}
+		})
+	}
+
+	t.Run("member_size_mismatch", func(t *testing.T) {
+		bad := makeLz(t, []byte("first "))
+		binary.LittleEndian.PutUint64(bad[len(bad)-8:], uint64(len(bad)+1))
+		bad = append(bad, makeLz(t, []byte("second"))...)
+		if _, err := io.ReadAll(newLzipReader(bytes.NewReader(bad))); err == nil {
+			t.Error("expected error for wrong member size, got nil")
+		}
+	})
+
+	t.Run("truncated_trailer", func(t *testing.T) {
+		data := append(makeLz(t, []byte("first ")), makeLz(t, []byte("second"))...)
+		if _, err := io.ReadAll(newLzipReader(bytes.NewReader(data[:len(data)-5]))); err == nil {
+			t.Error("expected error for truncated trailer, got nil")
+		}
+	})
 }
@@ -0,0 +1,184 @@
+package extract
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// lzipMagic is the four-byte signature at the start of every lzip member.
+var lzipMagic = []byte("LZIP")
+
+// lzipHeaderLen and lzipTrailerLen are the fixed sizes of an lzip member's
+// header (magic, version, coded dictionary size) and trailer (CRC32, data
+// size, member size).
+const (
+	lzipHeaderLen  = 6
+	lzipTrailerLen = 20
+)
+
+// lzipReader decompresses an lzip stream.  An lzip member is a raw LZMA
+// stream with fixed properties (lc=3, lp=0, pb=2) and an end-of-stream
+// marker, so each member's header is rewritten as a classic .lzma header
+// with an unknown size and handed to the lzma reader.  Multi-member files
+// are concatenated, and each member's trailer is checked as it is reached.
+//
+// The decoder pulls compressed bytes through an lzipMember, which counts
+// every byte it hands out; the trailer starts exactly where the decoder
+// stopped, and its member size is checked against that count.
+type lzipReader struct {
+	src    *countingReader
+	member io.Reader // current member's decoder; nil between members
+	start  int64     // offset of the current member's header
+	crc    hash.Hash32
+	size   uint64
+	seen   bool // at least one member has been read
+	err    error
+}
+
+func newLzipReader(r io.Reader) *lzipReader {
+	return &lzipReader{src: &countingReader{r: bufio.NewReader(r)}}
+}
+
+func (z *lzipReader) Read(p []byte) (int, error) {
+	for z.err == nil {
+		if z.member == nil {
+			if z.err = z.openMember(); z.err != nil {
+				break
+			}
+		}
+		n, err := z.member.Read(p)
+		z.crc.Write(p[:n])
+		z.size += uint64(n)
+		if errors.Is(err, io.EOF) {
+			z.err = z.closeMember()
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		if err != nil {
+			z.err = fmt.Errorf("lzip: %w", err)
+		}
+		return n, z.err
+	}
+	return 0, z.err
+}
+
+// openMember parses the next member header, or returns io.EOF when the
+// stream is exhausted after at least one member.
+func (z *lzipReader) openMember() error {
+	start := z.src.n
+	hdr := make([]byte, lzipHeaderLen)
+	n, err := io.ReadFull(z.src, hdr)
+	if n == 0 && errors.Is(err, io.EOF) && z.seen {
+		return io.EOF
+	}
+	if err != nil || !bytes.Equal(hdr[:4], lzipMagic) {
+		return fmt.Errorf("lzip: invalid header")
+	}
+	if hdr[4] != 1 {
+		return fmt.Errorf("lzip: unsupported version %d", hdr[4])
+	}
+
+	// Coded dictionary size: bits 4-0 are log2 of the base size, bits 7-5
+	// are the number of sixteenths of the base to subtract.
+	ds := hdr[5]
+	base := uint32(1) << (ds & 0x1f)
+	dictSize := base - uint32(ds>>5)*(base/16)
+
+	classic := make([]byte, 13)
+	classic[0] = 0x5d // lc=3, lp=0, pb=2
+	binary.LittleEndian.PutUint32(classic[1:5], dictSize)
+	binary.LittleEndian.PutUint64(classic[5:13], ^uint64(0))
+
+	lr, err := lzma.NewReader(&lzipMember{header: classic, src: z.src})
+	if err != nil {
+		return fmt.Errorf("lzip: %w", err)
+	}
+	z.member = lr
+	z.start = start
+	z.crc = crc32.NewIEEE()
+	z.size = 0
+	z.seen = true
+	return nil
+}
+
+// closeMember reads and checks the trailer of the current member.
+func (z *lzipReader) closeMember() error {
+	trailer := make([]byte, lzipTrailerLen)
+	if _, err := io.ReadFull(z.src, trailer); err != nil {
+		return fmt.Errorf("lzip: truncated member trailer")
+	}
+	if binary.LittleEndian.Uint32(trailer[0:4]) != z.crc.Sum32() {
+		return fmt.Errorf("lzip: CRC mismatch")
+	}
+	if binary.LittleEndian.Uint64(trailer[4:12]) != z.size {
+		return fmt.Errorf("lzip: data size mismatch")
+	}
+	if binary.LittleEndian.Uint64(trailer[12:20]) != uint64(z.src.n-z.start) {
+		return fmt.Errorf("lzip: member size mismatch")
+	}
+	z.member = nil
+	return nil
+}
+
+// lzipMember feeds one member to the lzma reader: the synthesized classic
+// header first, then the compressed data from src.  It is an io.ByteReader,
+// so the decoder takes bytes one at a time from the buffered source and
+// never consumes any of the trailer that follows the end-of-stream marker.
+type lzipMember struct {
+	header []byte
+	src    *countingReader
+}
+
+// Read serves only the header; the lzma reader reads it with io.ReadFull and
+// uses ReadByte for everything after.
+func (m *lzipMember) Read(p []byte) (int, error) {
+	if len(m.header) == 0 {
+		return 0, fmt.Errorf("lzip: unexpected bulk read of member data")
+	}
+	n := copy(p, m.header)
+	m.header = m.header[n:]
+	return n, nil
+}
+
+func (m *lzipMember) ReadByte() (byte, error) {
+	if len(m.header) > 0 {
+		b := m.header[0]
+		m.header = m.header[1:]
+		return b, nil
+	}
+	b, err := m.src.ReadByte()
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return b, err
+}
+
+// countingReader tracks how many bytes have been consumed from r.
+type countingReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
@@ -9,8 +9,15 @@ import (
 	"github.com/schollz/progressbar/v3"
 )
 
-// Fetcher downloads the content at a URL and returns it as bytes.
+// Fetcher downloads the content at a URL.
 type Fetcher interface {
+	// Open starts a download of url and returns its body as a stream. The
+	// caller must close the returned reader. Use Open for release assets,
+	// which may be arbitrarily large.
+	Open(ctx context.Context, url string) (io.ReadCloser, error)
+
+	// Fetch downloads url and returns its body as bytes. Intended for small
+	// metadata files such as checksum lists.
 	Fetch(ctx context.Context, url string) ([]byte, error)
 }
 
@@ -27,10 +34,10 @@ func NewFetcher() Fetcher {
 	}
 }
 
-// Fetch downloads url and returns its body as a byte slice. It displays a
-// progress bar on stderr during the download. A non-200 status code is
-// returned as an error.
-func (f *HTTPFetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
+// Open issues a GET for url and returns the response body, which advances a
+// progress bar on stderr as it is read. A non-200 status code is returned as
+// an error.
+func (f *HTTPFetcher) Open(ctx context.Context, url string) (io.ReadCloser, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
@@ -40,17 +47,40 @@ func (f *HTTPFetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("executing request: %w", err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
 		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
 	}
 
 	bar := progressbar.DefaultBytes(resp.ContentLength, "downloading")
-	body, err := io.ReadAll(io.TeeReader(resp.Body, bar))
+	return &progressBody{Reader: io.TeeReader(resp.Body, bar), body: resp.Body}, nil
+}
+
+// Fetch downloads url and returns its body as a byte slice. It displays a
+// progress bar on stderr during the download. A non-200 status code is
+// returned as an error.
+func (f *HTTPFetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
+	body, err := f.Open(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
 	if err != nil {
 		return nil, fmt.Errorf("reading response body: %w", err)
 	}
 
-	return body, nil
+	return data, nil
+}
+
+// progressBody reads through the progress bar and closes the response body.
+type progressBody struct {
+	io.Reader
+	body io.Closer
+}
+
+func (p *progressBody) Close() error {
+	return p.body.Close()
 }
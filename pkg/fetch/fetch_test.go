@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -46,3 +47,40 @@ func TestHTTPFetcher_Non200(t *testing.T) {
 		t.Errorf("expected error to contain status code 404, got: %v", err)
 	}
 }
+
+func TestHTTPFetcher_OpenStreams(t *testing.T) {
+	want := bytes.Repeat([]byte("0123456789"), 10000)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write(want)
+	}))
+	defer srv.Close()
+
+	f := NewFetcher()
+	body, err := f.Open(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("Open returned unexpected error: %v", err)
+	}
+	defer body.Close()
+
+	got, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("body mismatch: got %d bytes, want %d", len(got), len(want))
+	}
+}
+
+func TestHTTPFetcher_OpenNon200(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	f := NewFetcher()
+	if _, err := f.Open(context.Background(), srv.URL); err == nil {
+		t.Fatal("expected an error for non-200 status, got nil")
+	}
+}
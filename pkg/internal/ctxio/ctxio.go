@@ -0,0 +1,26 @@
+// Package ctxio provides context-aware I/O helpers shared by the packages
+// that stream downloads and archives.
+package ctxio
+
+import (
+	"context"
+	"io"
+)
+
+// Reader returns a reader that reads from r until ctx is cancelled, after
+// which every Read fails with ctx's error. It lets a long io.Copy be aborted.
+func Reader(ctx context.Context, r io.Reader) io.Reader {
+	return reader{ctx, r}
+}
+
+type reader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c reader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
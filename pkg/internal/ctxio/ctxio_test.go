@@ -0,0 +1,22 @@
+package ctxio
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	got, err := io.ReadAll(Reader(context.Background(), strings.NewReader("hello")))
+	if err != nil || string(got) != "hello" {
+		t.Errorf("ReadAll = %q, %v; want %q", got, err, "hello")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := io.ReadAll(Reader(ctx, strings.NewReader("hello"))); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReadAll after cancel = %v, want context.Canceled", err)
+	}
+}
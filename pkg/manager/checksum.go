@@ -5,44 +5,15 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/ventifus/binmgr/pkg/backend"
+	"github.com/ventifus/binmgr/pkg/verify"
 )
 
-// parseSha256Sums parses the standard sha256sums format.
-// Accepts both text mode ("{hex}  {filename}", two spaces) and binary mode
-// ("{hex} *{filename}", space + asterisk — the asterisk is stripped from the
-// filename). Returns map[filename]hex. Errors on malformed lines.
-func parseSha256Sums(data []byte) (map[string]string, error) {
-	result := make(map[string]string)
-	scanner := bufio.NewScanner(bytes.NewReader(data))
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-		var hex, filename string
-		if idx := strings.Index(line, "  "); idx >= 0 {
-			// Text mode: "{hex}  {filename}"
-			hex = line[:idx]
-			filename = line[idx+2:]
-		} else if idx := strings.Index(line, " *"); idx >= 0 {
-			// Binary mode: "{hex} *{filename}"
-			hex = line[:idx]
-			filename = line[idx+2:]
-		} else {
-			return nil, fmt.Errorf("parseSha256Sums: malformed line (missing two-space or space-asterisk separator): %q", line)
-		}
-		result[filename] = hex
-	}
-	if err := scanner.Err(); err != nil {
-		return nil, err
-	}
-	return result, nil
-}
-
 // parseMultisum parses HashiCorp multisum format.
 // orderFile has one algorithm name per line (in column order).
 // dataFile has one asset per line with whitespace-separated hex columns.
@@ -97,16 +68,29 @@ func parseMultisum(dataFile, orderFile []byte, targetName string) (map[string]st
 	return nil, fmt.Errorf("parseMultisum: target %q not found in data file", targetName)
 }
 
-// normalizeAlgorithm converts algorithm names from the order file (e.g. "SHA256", "SHA512")
-// to canonical "sha-NNN" keys.
+// normalizeAlgorithm converts algorithm names from the order file (e.g.
+// "SHA256", "SHA3-512") to the canonical keys the verifier uses ("sha-256",
+// "sha3-512").
 func normalizeAlgorithm(name string) string {
-	switch strings.ToUpper(name) {
-	case "SHA256":
+	switch strings.ReplaceAll(strings.ToUpper(name), "_", "-") {
+	case "SHA1", "SHA-1":
+		return "sha-1"
+	case "SHA224", "SHA-224", "SHA2-224":
+		return "sha-224"
+	case "SHA256", "SHA-256", "SHA2-256":
 		return "sha-256"
-	case "SHA512":
+	case "SHA384", "SHA-384", "SHA2-384":
+		return "sha-384"
+	case "SHA512", "SHA-512", "SHA2-512":
 		return "sha-512"
-	case "SHA1":
-		return "sha-1"
+	case "SHA3-256", "SHA3256":
+		return "sha3-256"
+	case "SHA3-512", "SHA3512":
+		return "sha3-512"
+	case "BLAKE2B", "BLAKE2B-512", "BLAKE2B512", "B2":
+		return "blake2b"
+	case "BLAKE2B-256", "BLAKE2B256":
+		return "blake2b-256"
 	case "MD5":
 		return "md5"
 	default:
@@ -129,62 +113,104 @@ func findAsset(resolution *backend.Resolution, glob string) (*backend.Asset, err
 	return nil, fmt.Errorf("findAsset: no asset matched glob %q", glob)
 }
 
+// checksumResult is what resolveChecksums found: the expected checksums and
+// how they were obtained, which the manifest records for auditing.
+type checksumResult struct {
+	checksums  map[string]string // nil for strategy "none"
+	sourceURL  string            // where the checksums were read from
+	strategy   string            // the strategy that supplied them; "backend" for checksums listed by the backend
+	algorithms []string          // the algorithms of checksums the verifier checks, sorted
+	signedBy   string            // verified signer of the checksum file, if a signature was required
+}
+
 // resolveChecksums determines the expected checksums for an asset given the strategy.
-// Returns nil for "none" (caller skips Verify).
-// assetName is the filename of the downloaded asset.
-// assetData is the downloaded asset bytes (used only for "embedded" strategy).
-// assetURL is the full URL of the downloaded asset (needed for per-asset strategy).
-// assetChecksums is the pre-populated checksum from the shasumurl backend (non-nil = use directly).
+// Returns a result without checksums for "none" (caller skips Verify).
+// asset is the asset being downloaded; its Checksums, pre-populated by the
+// shasumurl backend, are used directly if non-empty.
+// assetPath is the spooled asset download (used only for "embedded" strategy).
 // resolution is the full Resolution from the backend.
 // tag is the raw release tag for ${TAG}/${VERSION} expansion.
 func (m *mgr) resolveChecksums(
 	ctx context.Context,
 	opts ChecksumOpts,
-	assetName string,
-	assetData []byte,
-	assetURL string,
-	assetChecksums map[string]string,
+	asset *backend.Asset,
+	assetPath string,
 	resolution *backend.Resolution,
 	tag string,
-) (map[string]string, error) {
-	// shasumurl shortcut: if the backend already provided checksums, use them directly.
-	if len(assetChecksums) > 0 {
-		return assetChecksums, nil
-	}
+) (*checksumResult, error) {
+	var res *checksumResult
+	var err error
+	switch {
+	case len(asset.Checksums) > 0:
+		// shasumurl shortcut: the backend already provided checksums, use them
+		// directly. There is no separate checksum file to check a signature over.
+		if s := opts.Signer; s != nil && (s.Keyring != "" || s.Minisign != "") {
+			return nil, fmt.Errorf("resolveChecksums: cannot check a checksum file signature for checksums supplied by the backend")
+		}
+		res = &checksumResult{checksums: asset.Checksums, sourceURL: asset.ChecksumURL, strategy: "backend"}
 
-	switch opts.Strategy {
-	case "none":
-		return nil, nil
+	case opts.Strategy == "none":
+		return &checksumResult{strategy: "none"}, nil
 
-	case "auto":
-		return m.resolveChecksumsAuto(ctx, assetName, resolution)
+	case opts.Strategy == "auto":
+		res, err = m.resolveChecksumsAuto(ctx, opts, asset.Name, resolution)
 
-	case "shared-file":
-		return m.resolveChecksumsSharedFile(ctx, opts, assetName, resolution, tag)
+	case opts.Strategy == "shared-file":
+		res, err = m.resolveChecksumsSharedFile(ctx, opts, asset.Name, resolution, tag)
 
-	case "per-asset":
-		return m.resolveChecksumsPerAsset(ctx, opts, assetURL)
+	case opts.Strategy == "per-asset":
+		res, err = m.resolveChecksumsPerAsset(ctx, opts, asset.URL)
 
-	case "multisum":
-		return m.resolveChecksumsMultisum(ctx, opts, assetName, resolution, tag)
+	case opts.Strategy == "multisum":
+		res, err = m.resolveChecksumsMultisum(ctx, opts, asset.Name, resolution, tag)
 
-	case "embedded":
-		return m.resolveChecksumsEmbedded(ctx, opts, assetName, assetData, tag)
+	case opts.Strategy == "embedded":
+		res, err = m.resolveChecksumsEmbedded(ctx, opts, asset, assetPath, tag)
 
 	default:
 		return nil, fmt.Errorf("resolveChecksums: unknown strategy %q", opts.Strategy)
 	}
+	if err != nil {
+		return nil, err
+	}
+	res.algorithms = checkedAlgorithms(res.checksums)
+	return res, nil
 }
 
-// resolveChecksumsAuto implements the "auto" heuristic.
-// Searches resolution.Assets names for known checksum file patterns.
-func (m *mgr) resolveChecksumsAuto(ctx context.Context, assetName string, resolution *backend.Resolution) (map[string]string, error) {
+// checkedAlgorithms returns the algorithms in checksums that the verifier
+// checks, sorted; others are skipped by Verify.
+func checkedAlgorithms(checksums map[string]string) []string {
+	var algos []string
+	for algo := range checksums {
+		if verify.Supported(algo) {
+			algos = append(algos, algo)
+		}
+	}
+	sort.Strings(algos)
+	return algos
+}
+
+// resolveChecksumsAuto implements the "auto" heuristic: the checksum file
+// detectChecksumFile finds is read as a shared file. Install locks the
+// detected file in as a "shared-file" glob first, so this only runs for
+// callers that skip that step.
+func (m *mgr) resolveChecksumsAuto(ctx context.Context, opts ChecksumOpts, assetName string, resolution *backend.Resolution) (*checksumResult, error) {
+	file, err := detectChecksumFile(resolution)
+	if err != nil {
+		return nil, err
+	}
+	return m.fetchAndParseChecksums(ctx, opts, file, assetName, resolution)
+}
+
+// detectChecksumFile searches resolution.Assets names for known checksum
+// file patterns.
+func detectChecksumFile(resolution *backend.Resolution) (*backend.Asset, error) {
 	// Step 1: exact name matches in priority order.
 	exactNames := []string{"SHA256SUMS", "SHA256SUMS.txt", "checksums.txt", "checksums.sha256"}
 	for _, candidate := range exactNames {
 		for i := range resolution.Assets {
 			if resolution.Assets[i].Name == candidate {
-				return m.fetchAndParseSha256Sums(ctx, resolution.Assets[i].URL, assetName)
+				return &resolution.Assets[i], nil
 			}
 		}
 	}
@@ -198,7 +224,7 @@ func (m *mgr) resolveChecksumsAuto(ctx context.Context, assetName string, resolu
 				continue
 			}
 			if matched {
-				return m.fetchAndParseSha256Sums(ctx, resolution.Assets[i].URL, assetName)
+				return &resolution.Assets[i], nil
 			}
 		}
 	}
@@ -207,62 +233,56 @@ func (m *mgr) resolveChecksumsAuto(ctx context.Context, assetName string, resolu
 }
 
 // resolveChecksumsSharedFile implements the "shared-file" strategy.
-func (m *mgr) resolveChecksumsSharedFile(ctx context.Context, opts ChecksumOpts, assetName string, resolution *backend.Resolution, tag string) (map[string]string, error) {
+func (m *mgr) resolveChecksumsSharedFile(ctx context.Context, opts ChecksumOpts, assetName string, resolution *backend.Resolution, tag string) (*checksumResult, error) {
 	expandedGlob := ExpandVars(opts.FileGlob, tag)
 	asset, err := findAsset(resolution, expandedGlob)
 	if err != nil {
 		return nil, fmt.Errorf("shared-file: %w", err)
 	}
-	return m.fetchAndParseSha256Sums(ctx, asset.URL, assetName)
+	return m.fetchAndParseChecksums(ctx, opts, asset, assetName, resolution)
 }
 
-// fetchAndParseSha256Sums fetches a checksum file and looks up assetName in it.
-func (m *mgr) fetchAndParseSha256Sums(ctx context.Context, url, assetName string) (map[string]string, error) {
-	data, err := m.fetcher.Fetch(ctx, url)
+// fetchAndParseChecksums fetches the shared checksum file, checking its
+// signature if opts requires one, and looks up assetName in it.
+func (m *mgr) fetchAndParseChecksums(ctx context.Context, opts ChecksumOpts, file *backend.Asset, assetName string, resolution *backend.Resolution) (*checksumResult, error) {
+	data, signedBy, err := m.fetchChecksumFile(ctx, opts, file, resolution)
 	if err != nil {
-		return nil, fmt.Errorf("fetch checksum file %q: %w", url, err)
+		return nil, err
 	}
-	sums, err := parseSha256Sums(data)
+	list, err := parseChecksumFile(data, file.Name)
 	if err != nil {
 		return nil, err
 	}
-	hex, ok := sums[assetName]
+	sums, ok := list.lookup(assetName)
 	if !ok {
-		return nil, fmt.Errorf("asset %q not found in checksum file %q", assetName, url)
+		return nil, fmt.Errorf("asset %q not found in checksum file %q", assetName, file.URL)
 	}
-	return map[string]string{"sha-256": hex}, nil
+	return &checksumResult{checksums: sums, sourceURL: file.URL, strategy: "shared-file", signedBy: signedBy}, nil
 }
 
 // resolveChecksumsPerAsset implements the "per-asset" strategy.
-func (m *mgr) resolveChecksumsPerAsset(ctx context.Context, opts ChecksumOpts, assetURL string) (map[string]string, error) {
+func (m *mgr) resolveChecksumsPerAsset(ctx context.Context, opts ChecksumOpts, assetURL string) (*checksumResult, error) {
 	checksumURL := assetURL + opts.Suffix
 	data, err := m.fetcher.Fetch(ctx, checksumURL)
 	if err != nil {
 		return nil, fmt.Errorf("per-asset: fetch checksum file %q: %w", checksumURL, err)
 	}
 	// Derive assetName from the URL (everything after the last slash, before the suffix).
+	// The file may hold a bare digest or lines naming the asset.
 	assetName := assetURL[strings.LastIndex(assetURL, "/")+1:]
-	content := strings.TrimSpace(string(data))
-	var hexDigest string
-	if strings.Contains(content, "  ") || strings.Contains(content, " *") {
-		// sha256sums-format line: "<hex>  <filename>" or "<hex> *<filename>"
-		parsed, err := parseSha256Sums(data)
-		if err != nil {
-			return nil, fmt.Errorf("per-asset: parsing checksum file: %w", err)
-		}
-		digest, ok := parsed[assetName]
-		if !ok {
-			return nil, fmt.Errorf("per-asset: asset %q not found in checksum file", assetName)
-		}
-		hexDigest = digest
-	} else {
-		hexDigest = content
+	list, err := parseChecksumFile(data, assetName+opts.Suffix)
+	if err != nil {
+		return nil, fmt.Errorf("per-asset: parsing checksum file: %w", err)
+	}
+	sums, ok := list.lookup(assetName)
+	if !ok {
+		return nil, fmt.Errorf("per-asset: asset %q not found in checksum file", assetName)
 	}
-	return map[string]string{"sha-256": hexDigest}, nil
+	return &checksumResult{checksums: sums, sourceURL: checksumURL, strategy: "per-asset"}, nil
 }
 
 // resolveChecksumsMultisum implements the "multisum" strategy.
-func (m *mgr) resolveChecksumsMultisum(ctx context.Context, opts ChecksumOpts, assetName string, resolution *backend.Resolution, tag string) (map[string]string, error) {
+func (m *mgr) resolveChecksumsMultisum(ctx context.Context, opts ChecksumOpts, assetName string, resolution *backend.Resolution, tag string) (*checksumResult, error) {
 	expandedFileGlob := ExpandVars(opts.FileGlob, tag)
 	expandedOrderGlob := ExpandVars(opts.OrderGlob, tag)
 
@@ -275,35 +295,45 @@ func (m *mgr) resolveChecksumsMultisum(ctx context.Context, opts ChecksumOpts, a
 		return nil, fmt.Errorf("multisum order file: %w", err)
 	}
 
-	dataFile, err := m.fetcher.Fetch(ctx, dataAsset.URL)
+	dataFile, signedBy, err := m.fetchChecksumFile(ctx, opts, dataAsset, resolution)
 	if err != nil {
-		return nil, fmt.Errorf("multisum: fetch data file %q: %w", dataAsset.URL, err)
+		return nil, fmt.Errorf("multisum: data file: %w", err)
 	}
 	orderFile, err := m.fetcher.Fetch(ctx, orderAsset.URL)
 	if err != nil {
 		return nil, fmt.Errorf("multisum: fetch order file %q: %w", orderAsset.URL, err)
 	}
 
-	return parseMultisum(dataFile, orderFile, assetName)
+	sums, err := parseMultisum(dataFile, orderFile, assetName)
+	if err != nil {
+		return nil, err
+	}
+	return &checksumResult{checksums: sums, sourceURL: dataAsset.URL, strategy: "multisum", signedBy: signedBy}, nil
 }
 
 // resolveChecksumsEmbedded implements the "embedded" strategy.
-func (m *mgr) resolveChecksumsEmbedded(ctx context.Context, opts ChecksumOpts, assetName string, assetData []byte, tag string) (map[string]string, error) {
+// The checksum file is extracted alongside the spooled asset. Its source is
+// recorded as the asset's URL and the file's path inside it, joined by "!".
+func (m *mgr) resolveChecksumsEmbedded(ctx context.Context, opts ChecksumOpts, asset *backend.Asset, assetPath string, tag string) (*checksumResult, error) {
 	expandedGlob := ExpandVars(opts.TraversalGlob, tag)
-	files, err := m.extractor.Extract(ctx, assetName, assetData, []string{expandedGlob})
+	files, err := m.extractor.Extract(ctx, asset.Name, assetPath, []string{expandedGlob}, filepath.Dir(assetPath))
 	if err != nil {
 		return nil, fmt.Errorf("embedded: extract checksum file: %w", err)
 	}
 	if len(files) == 0 {
-		return nil, fmt.Errorf("embedded: no file matched traversal glob %q inside %q", expandedGlob, assetName)
+		return nil, fmt.Errorf("embedded: no file matched traversal glob %q inside %q", expandedGlob, asset.Name)
+	}
+	data, err := os.ReadFile(files[0].Path)
+	if err != nil {
+		return nil, fmt.Errorf("embedded: read checksum file: %w", err)
 	}
-	sums, err := parseSha256Sums(files[0].Data)
+	list, err := parseChecksumFile(data, expandedGlob)
 	if err != nil {
 		return nil, fmt.Errorf("embedded: parse checksum file: %w", err)
 	}
-	hex, ok := sums[assetName]
+	sums, ok := list.lookup(asset.Name)
 	if !ok {
-		return nil, fmt.Errorf("embedded: asset %q not found in embedded checksum file", assetName)
+		return nil, fmt.Errorf("embedded: asset %q not found in embedded checksum file", asset.Name)
 	}
-	return map[string]string{"sha-256": hex}, nil
+	return &checksumResult{checksums: sums, sourceURL: asset.URL + traverseSeparator + files[0].SourcePath, strategy: "embedded"}, nil
 }
@@ -4,72 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/ventifus/binmgr/pkg/backend"
-	"github.com/ventifus/binmgr/pkg/extract"
 )
 
-// ========== parseSha256Sums tests ==========
-
-func TestParseSha256Sums(t *testing.T) {
-	t.Run("standard format with multiple entries", func(t *testing.T) {
-		input := "" +
-			"abc123  foo.tar.gz\n" +
-			"def456  bar.zip\n" +
-			"789abc  baz.bin\n"
-		got, err := parseSha256Sums([]byte(input))
-		if err != nil {
-			t.Fatalf("unexpected error: %v", err)
-		}
-		want := map[string]string{
-			"foo.tar.gz": "abc123",
-			"bar.zip":    "def456",
-			"baz.bin":    "789abc",
-		}
-		if len(got) != len(want) {
-			t.Fatalf("got %d entries, want %d", len(got), len(want))
-		}
-		for filename, hex := range want {
-			if got[filename] != hex {
-				t.Errorf("got[%q] = %q, want %q", filename, got[filename], hex)
-			}
-		}
-	})
-
-	t.Run("blank lines and comment lines are skipped", func(t *testing.T) {
-		input := "" +
-			"# This is a comment\n" +
-			"\n" +
-			"abc123  foo.tar.gz\n" +
-			"\n" +
-			"# Another comment\n" +
-			"def456  bar.zip\n"
-		got, err := parseSha256Sums([]byte(input))
-		if err != nil {
-			t.Fatalf("unexpected error: %v", err)
-		}
-		if len(got) != 2 {
-			t.Fatalf("got %d entries, want 2", len(got))
-		}
-		if got["foo.tar.gz"] != "abc123" {
-			t.Errorf("got[foo.tar.gz] = %q, want abc123", got["foo.tar.gz"])
-		}
-		if got["bar.zip"] != "def456" {
-			t.Errorf("got[bar.zip] = %q, want def456", got["bar.zip"])
-		}
-	})
-
-	t.Run("malformed line returns error", func(t *testing.T) {
-		input := "abc123 foo.tar.gz\n" // only one space, not two
-		_, err := parseSha256Sums([]byte(input))
-		if err == nil {
-			t.Fatal("expected error for malformed line, got nil")
-		}
-	})
-}
-
 // ========== parseMultisum tests ==========
 
 func TestParseMultisum(t *testing.T) {
@@ -113,6 +56,17 @@ func TestParseMultisum(t *testing.T) {
 			t.Errorf("error should mention target name, got: %v", err)
 		}
 	})
+
+	t.Run("order file names map to verifier keys", func(t *testing.T) {
+		got, err := parseMultisum([]byte("foo.tar.gz  a  b  c  d  e\n"), []byte("MD5\nSHA1\nSHA384\nSHA3-256\nBLAKE2b\n"), "foo.tar.gz")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := map[string]string{"md5": "a", "sha-1": "b", "sha-384": "c", "sha3-256": "d", "blake2b": "e"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
 }
 
 // ========== resolveChecksums tests ==========
@@ -135,12 +89,12 @@ func TestResolveChecksums_None(t *testing.T) {
 		},
 	}
 
-	got, err := m.resolveChecksums(context.Background(), opts, "tool.tar.gz", nil, "", nil, resolution, "v1.0.0")
+	got, err := m.resolveChecksums(context.Background(), opts, &resolution.Assets[0], "", resolution, "v1.0.0")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if got != nil {
-		t.Errorf("expected nil for strategy none, got %v", got)
+	if got.checksums != nil {
+		t.Errorf("expected no checksums for strategy none, got %v", got.checksums)
 	}
 }
 
@@ -164,12 +118,16 @@ func TestResolveChecksums_Auto_Found(t *testing.T) {
 		},
 	}
 
-	got, err := m.resolveChecksums(context.Background(), opts, "tool.tar.gz", nil, "", nil, resolution, "v1.0.0")
+	got, err := m.resolveChecksums(context.Background(), opts, &resolution.Assets[0], "", resolution, "v1.0.0")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if got["sha-256"] != "deadbeef" {
-		t.Errorf("got sha-256 = %q, want deadbeef", got["sha-256"])
+	if got.checksums["sha-256"] != "deadbeef" {
+		t.Errorf("got sha-256 = %q, want deadbeef", got.checksums["sha-256"])
+	}
+	// The strategy auto chose is recorded, with the file it read.
+	if got.strategy != "shared-file" || got.sourceURL != "https://example.com/SHA256SUMS" {
+		t.Errorf("got strategy %q, source %q", got.strategy, got.sourceURL)
 	}
 }
 
@@ -184,7 +142,7 @@ func TestResolveChecksums_Auto_NotFound(t *testing.T) {
 		},
 	}
 
-	_, err := m.resolveChecksums(context.Background(), opts, "tool.tar.gz", nil, "", nil, resolution, "v1.0.0")
+	_, err := m.resolveChecksums(context.Background(), opts, &resolution.Assets[0], "", resolution, "v1.0.0")
 	if err == nil {
 		t.Fatal("expected error when no checksum file found, got nil")
 	}
@@ -220,12 +178,12 @@ func TestResolveChecksums_SharedFile(t *testing.T) {
 		},
 	}
 
-	got, err := m.resolveChecksums(context.Background(), opts, "mytool.zip", nil, "", nil, resolution, "v2.0.0")
+	got, err := m.resolveChecksums(context.Background(), opts, &resolution.Assets[0], "", resolution, "v2.0.0")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if got["sha-256"] != "cafebabe" {
-		t.Errorf("got sha-256 = %q, want cafebabe", got["sha-256"])
+	if got.checksums["sha-256"] != "cafebabe" {
+		t.Errorf("got sha-256 = %q, want cafebabe", got.checksums["sha-256"])
 	}
 }
 
@@ -252,12 +210,12 @@ func TestResolveChecksums_SharedFile_VersionExpansion(t *testing.T) {
 		},
 	}
 
-	got, err := m.resolveChecksums(context.Background(), opts, "tool.tar.gz", nil, "", nil, resolution, "v1.2.3")
+	got, err := m.resolveChecksums(context.Background(), opts, &resolution.Assets[0], "", resolution, "v1.2.3")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if got["sha-256"] != "112233aa" {
-		t.Errorf("got sha-256 = %q, want 112233aa", got["sha-256"])
+	if got.checksums["sha-256"] != "112233aa" {
+		t.Errorf("got sha-256 = %q, want 112233aa", got.checksums["sha-256"])
 	}
 }
 
@@ -283,12 +241,15 @@ func TestResolveChecksums_PerAsset(t *testing.T) {
 			},
 		}
 
-		got, err := m.resolveChecksums(context.Background(), opts, "kubectl", nil, "https://example.com/kubectl", nil, resolution, "v1.30.0")
+		got, err := m.resolveChecksums(context.Background(), opts, &resolution.Assets[0], "", resolution, "v1.30.0")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
-		if got["sha-256"] != "aabbccdd" {
-			t.Errorf("got sha-256 = %q, want aabbccdd", got["sha-256"])
+		if got.checksums["sha-256"] != "aabbccdd" {
+			t.Errorf("got sha-256 = %q, want aabbccdd", got.checksums["sha-256"])
+		}
+		if got.sourceURL != "https://example.com/kubectl.sha256" {
+			t.Errorf("got source %q, want the per-asset checksum file", got.sourceURL)
 		}
 	})
 
@@ -314,12 +275,12 @@ func TestResolveChecksums_PerAsset(t *testing.T) {
 			},
 		}
 
-		got, err := m.resolveChecksums(context.Background(), opts, "kubectl", nil, "https://example.com/kubectl", nil, resolution, "v1.30.0")
+		got, err := m.resolveChecksums(context.Background(), opts, &resolution.Assets[0], "", resolution, "v1.30.0")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
-		if got["sha-256"] != "aabbccdd" {
-			t.Errorf("got sha-256 = %q, want aabbccdd", got["sha-256"])
+		if got.checksums["sha-256"] != "aabbccdd" {
+			t.Errorf("got sha-256 = %q, want aabbccdd", got.checksums["sha-256"])
 		}
 	})
 
@@ -344,7 +305,7 @@ func TestResolveChecksums_PerAsset(t *testing.T) {
 			},
 		}
 
-		_, err := m.resolveChecksums(context.Background(), opts, "kubectl", nil, "https://example.com/kubectl", nil, resolution, "v1.30.0")
+		_, err := m.resolveChecksums(context.Background(), opts, &resolution.Assets[0], "", resolution, "v1.30.0")
 		if err == nil {
 			t.Fatal("expected error when asset name not found in checksum file, got nil")
 		}
@@ -355,7 +316,7 @@ func TestResolveChecksums_PerAsset(t *testing.T) {
 }
 
 func TestResolveChecksums_ShasumURLShortcut(t *testing.T) {
-	// Fetcher should never be called when assetChecksums is non-nil and non-empty.
+	// Fetcher should never be called when the asset's checksums are non-empty.
 	fetcher := &MockFetcher{
 		FetchFn: func(ctx context.Context, url string) ([]byte, error) {
 			return nil, errors.New("fetcher should not be called")
@@ -365,19 +326,24 @@ func TestResolveChecksums_ShasumURLShortcut(t *testing.T) {
 
 	// Even with strategy "shared-file", the shortcut should fire.
 	opts := ChecksumOpts{Strategy: "shared-file", FileGlob: "SHA256SUMS"}
-	prePopulated := map[string]string{"sha-256": "precomputed99"}
 	resolution := &backend.Resolution{
-		Assets: []backend.Asset{
-			{Name: "oc.tar.gz", URL: "https://example.com/oc.tar.gz"},
-		},
+		Assets: []backend.Asset{{
+			Name:        "oc.tar.gz",
+			URL:         "https://example.com/oc.tar.gz",
+			Checksums:   map[string]string{"sha-256": "precomputed99"},
+			ChecksumURL: "https://example.com/sha256sum.txt",
+		}},
 	}
 
-	got, err := m.resolveChecksums(context.Background(), opts, "oc.tar.gz", nil, "", prePopulated, resolution, "")
+	got, err := m.resolveChecksums(context.Background(), opts, &resolution.Assets[0], "", resolution, "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if got["sha-256"] != "precomputed99" {
-		t.Errorf("got sha-256 = %q, want precomputed99", got["sha-256"])
+	if got.checksums["sha-256"] != "precomputed99" {
+		t.Errorf("got sha-256 = %q, want precomputed99", got.checksums["sha-256"])
+	}
+	if got.strategy != "backend" || got.sourceURL != "https://example.com/sha256sum.txt" {
+		t.Errorf("got strategy %q, source %q", got.strategy, got.sourceURL)
 	}
 }
 
@@ -412,23 +378,26 @@ func TestResolveChecksums_Multisum(t *testing.T) {
 		},
 	}
 
-	got, err := m.resolveChecksums(context.Background(), opts, "yq_linux_amd64.tar.gz", nil, "", nil, resolution, "v4.50.1")
+	got, err := m.resolveChecksums(context.Background(), opts, &resolution.Assets[0], "", resolution, "v4.50.1")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if got["sha-256"] != "aa11bb22" {
-		t.Errorf("got sha-256 = %q, want aa11bb22", got["sha-256"])
+	if got.checksums["sha-256"] != "aa11bb22" {
+		t.Errorf("got sha-256 = %q, want aa11bb22", got.checksums["sha-256"])
+	}
+	if got.checksums["sha-512"] != "cc33dd44" {
+		t.Errorf("got sha-512 = %q, want cc33dd44", got.checksums["sha-512"])
 	}
-	if got["sha-512"] != "cc33dd44" {
-		t.Errorf("got sha-512 = %q, want cc33dd44", got["sha-512"])
+	if want := []string{"sha-256", "sha-512"}; !reflect.DeepEqual(got.algorithms, want) {
+		t.Errorf("got algorithms %v, want %v", got.algorithms, want)
 	}
 }
 
 func TestResolveChecksums_Embedded(t *testing.T) {
 	checksumFileContent := []byte("ffee1122  mytool.tar.gz\n")
 	extractor := &MockExtractor{
-		ExtractFn: func(ctx context.Context, name string, data []byte, globs []string) ([]extract.ExtractedFile, error) {
-			return []extract.ExtractedFile{
+		ExtractFn: func(ctx context.Context, name string, data []byte, globs []string) ([]mockEntry, error) {
+			return []mockEntry{
 				{SourcePath: "SHA256SUMS", Data: checksumFileContent},
 			}, nil
 		},
@@ -444,13 +413,19 @@ func TestResolveChecksums_Embedded(t *testing.T) {
 			{Name: "mytool.tar.gz", URL: "https://example.com/mytool.tar.gz"},
 		},
 	}
-	assetData := []byte("fake-archive-content")
+	assetPath := filepath.Join(t.TempDir(), "mytool.tar.gz")
+	if err := os.WriteFile(assetPath, []byte("fake-archive-content"), 0600); err != nil {
+		t.Fatalf("write asset: %v", err)
+	}
 
-	got, err := m.resolveChecksums(context.Background(), opts, "mytool.tar.gz", assetData, "", nil, resolution, "v1.0.0")
+	got, err := m.resolveChecksums(context.Background(), opts, &resolution.Assets[0], assetPath, resolution, "v1.0.0")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if got["sha-256"] != "ffee1122" {
-		t.Errorf("got sha-256 = %q, want ffee1122", got["sha-256"])
+	if got.checksums["sha-256"] != "ffee1122" {
+		t.Errorf("got sha-256 = %q, want ffee1122", got.checksums["sha-256"])
+	}
+	if want := "https://example.com/mytool.tar.gz!SHA256SUMS"; got.sourceURL != want {
+		t.Errorf("got source %q, want %q", got.sourceURL, want)
 	}
 }
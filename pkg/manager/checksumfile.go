@@ -0,0 +1,289 @@
+package manager
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/apex/log"
+)
+
+// checksumEntry is one digest listed in a checksum file. name is empty for a
+// file holding a bare digest.
+type checksumEntry struct {
+	name   string
+	algo   string
+	digest string
+}
+
+// checksumList is the parsed content of a checksum file.
+type checksumList []checksumEntry
+
+// checksumFormat parses one checksum file format. parse reports false if
+// data is not in its format, so the next format can be tried. hint is the
+// checksum file's name, used to infer the algorithm of unlabelled digests.
+type checksumFormat struct {
+	name  string
+	parse func(data []byte, hint string) (checksumList, bool)
+}
+
+// checksumFormats is the registry of checksum file formats, in the order
+// they are tried. The GNU format comes last since it is the loosest.
+var checksumFormats = []checksumFormat{
+	{"json", parseJSONSums},
+	{"bsd", parseBSDSums},
+	{"gnu", parseGNUSums},
+}
+
+// parseChecksumFile detects the format of a checksum file and parses it.
+// name is the file's name, a hint for the algorithm (e.g. "SHA512SUMS",
+// "tool.sha1").
+func parseChecksumFile(data []byte, name string) (checksumList, error) {
+	for _, f := range checksumFormats {
+		if list, ok := f.parse(data, name); ok {
+			log.WithField("file", name).WithField("format", f.name).Debug("parsed checksum file")
+			return list, nil
+		}
+	}
+	return nil, fmt.Errorf("unrecognized checksum file format in %q", name)
+}
+
+// lookup returns the checksums listed for assetName, keyed by algorithm.
+// Entries naming exactly assetName win over entries whose path ends in it
+// ("./dist/tool"); a file of bare digests applies to any asset.
+func (l checksumList) lookup(assetName string) (map[string]string, bool) {
+	matches := []func(name string) bool{
+		func(name string) bool { return name == assetName },
+		func(name string) bool { return path.Base(name) == assetName },
+		func(name string) bool { return name == "" },
+	}
+	for _, match := range matches {
+		result := make(map[string]string)
+		for _, e := range l {
+			if match(e.name) {
+				result[e.algo] = e.digest
+			}
+		}
+		if len(result) > 0 {
+			return result, true
+		}
+	}
+	return nil, false
+}
+
+// parseGNUSums parses coreutils output ("sha256sum", "sha512sum", ...):
+// "{hex}  {name}" in text mode, "{hex} *{name}" in binary mode, or a single
+// space as some tools write it. A line holding only a digest is a bare
+// digest. Blank lines and "#" comments are skipped.
+func parseGNUSums(data []byte, hint string) (checksumList, bool) {
+	var list checksumList
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// A leading backslash marks a name with escaped characters.
+		digest, name, _ := strings.Cut(strings.TrimPrefix(line, "\\"), " ")
+		if !isHex(digest) {
+			return nil, false
+		}
+		name = strings.TrimPrefix(strings.TrimLeft(name, " "), "*")
+		list = append(list, checksumEntry{name: name, algo: inferAlgorithm(digest, hint), digest: strings.ToLower(digest)})
+	}
+	return list, scanner.Err() == nil && len(list) > 0
+}
+
+// bsdLine matches BSD-style tagged lines, as written by "sha256sum --tag",
+// "shasum --tag" and "openssl dgst": "SHA256 (tool) = {hex}".
+var bsdLine = regexp.MustCompile(`^([A-Za-z0-9_-]+) ?\((.*)\) ?= ?([0-9A-Fa-f]+)$`)
+
+// parseBSDSums parses BSD-style tagged lines. The tag names the algorithm.
+func parseBSDSums(data []byte, hint string) (checksumList, bool) {
+	var list checksumList
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m := bsdLine.FindStringSubmatch(line)
+		if m == nil {
+			return nil, false
+		}
+		algo := normalizeAlgorithm(m[1])
+		if _, ok := digestSizes[algo]; !ok {
+			algo = inferAlgorithm(m[3], hint)
+		}
+		list = append(list, checksumEntry{name: m[2], algo: algo, digest: strings.ToLower(m[3])})
+	}
+	return list, scanner.Err() == nil && len(list) > 0
+}
+
+// jsonNameKeys name the artifact in an object of a JSON checksum manifest;
+// jsonDigestKeys hold a digest of unstated algorithm.
+var (
+	jsonNameKeys   = []string{"name", "file", "filename", "path"}
+	jsonDigestKeys = map[string]bool{"checksum": true, "digest": true, "hash": true, "sum": true}
+)
+
+// parseJSONSums parses JSON checksum manifests. Several shapes are common:
+//
+//	{"tool": "{hex}"}
+//	{"tool": {"sha256": "{hex}", "sha512": "{hex}"}}
+//	[{"name": "tool", "sha256": "{hex}"}]
+//	[{"name": "tool", "extra": {"Checksum": "sha256:{hex}"}}]
+//
+// An object naming its artifact applies to the digests inside it; otherwise
+// each key names an artifact.
+func parseJSONSums(data []byte, hint string) (checksumList, bool) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return nil, false
+	}
+	var v any
+	if err := json.Unmarshal(trimmed, &v); err != nil {
+		return nil, false
+	}
+	var list checksumList
+	walkJSONSums(v, "", hint, &list)
+	return list, len(list) > 0
+}
+
+// walkJSONSums collects the digests in v for the artifact called name.
+func walkJSONSums(v any, name, hint string, list *checksumList) {
+	switch v := v.(type) {
+	case []any:
+		for _, item := range v {
+			walkJSONSums(item, name, hint, list)
+		}
+	case map[string]any:
+		named := false
+		for _, k := range jsonNameKeys {
+			if s, ok := v[k].(string); ok && s != "" {
+				name, named = s, true
+				break
+			}
+		}
+		for k, val := range v {
+			key := strings.ToLower(k)
+			switch val := val.(type) {
+			case string:
+				if algo, digest, ok := jsonDigest(key, val, hint); ok && (named || name != "") {
+					*list = append(*list, checksumEntry{name: name, algo: algo, digest: digest})
+				} else if !named && isHex(val) {
+					*list = append(*list, checksumEntry{name: k, algo: inferAlgorithm(val, hint), digest: strings.ToLower(val)})
+				}
+			case map[string]any, []any:
+				// Without a name of its own, a nested value is either the
+				// artifact k's digests or a container of named artifacts.
+				child := name
+				if !named {
+					child = k
+				}
+				walkJSONSums(val, child, hint, list)
+			}
+		}
+	}
+}
+
+// jsonDigest interprets a string field of a JSON checksum manifest: a digest
+// under an algorithm key ("sha256") or a generic one ("checksum"), the
+// latter possibly prefixed with its algorithm ("sha256:{hex}").
+func jsonDigest(key, val, hint string) (algo, digest string, ok bool) {
+	if a := normalizeAlgorithm(key); digestSizes[a] != 0 && isHex(val) {
+		return a, strings.ToLower(val), true
+	}
+	if !jsonDigestKeys[key] {
+		return "", "", false
+	}
+	if prefix, rest, found := strings.Cut(val, ":"); found && isHex(rest) {
+		if a := normalizeAlgorithm(prefix); digestSizes[a] != 0 {
+			return a, strings.ToLower(rest), true
+		}
+	}
+	if isHex(val) {
+		return inferAlgorithm(val, hint), strings.ToLower(val), true
+	}
+	return "", "", false
+}
+
+// digestSizes maps each algorithm the verifier supports to its digest size
+// in bytes.
+var digestSizes = map[string]int{
+	"md5":         16,
+	"sha-1":       20,
+	"sha-224":     28,
+	"sha-256":     32,
+	"sha-384":     48,
+	"sha-512":     64,
+	"sha3-256":    32,
+	"sha3-512":    64,
+	"blake2b":     64,
+	"blake2b-256": 32,
+	"blake3":      32,
+}
+
+// bySize names the usual algorithm for each digest size.
+var bySize = map[int]string{16: "md5", 20: "sha-1", 28: "sha-224", 32: "sha-256", 48: "sha-384", 64: "sha-512"}
+
+// algorithmHints maps substrings of checksum file names to the algorithm
+// they suggest, most specific first.
+var algorithmHints = []struct{ substr, algo string }{
+	{"sha3-256", "sha3-256"}, {"sha3_256", "sha3-256"},
+	{"sha3-512", "sha3-512"}, {"sha3_512", "sha3-512"},
+	{"sha224", "sha-224"}, {"sha384", "sha-384"}, {"sha512", "sha-512"}, {"sha256", "sha-256"},
+	{"sha1", "sha-1"}, {"md5", "md5"},
+	{"blake2b", "blake2b"}, {".b2", "blake2b"}, {"blake3", "blake3"}, {".b3", "blake3"},
+}
+
+// inferAlgorithm names the algorithm of an unlabelled digest from the
+// checksum file's name, if that agrees with the digest's length or names a
+// family with a variant of that length (b2sum -l 256 gives blake2b-256),
+// otherwise from the length alone. Digests of no known length are taken to
+// be sha-256.
+func inferAlgorithm(digest, fileName string) string {
+	size := len(digest) / 2
+	lower := strings.ToLower(fileName)
+	for _, h := range algorithmHints {
+		if strings.Contains(lower, h.substr) {
+			if digestSizes[h.algo] == size || bySize[size] == "" {
+				return h.algo
+			}
+			if algo := sizedVariant(h.algo, size); algo != "" {
+				return algo
+			}
+			break
+		}
+	}
+	if algo, ok := bySize[size]; ok {
+		return algo
+	}
+	return "sha-256"
+}
+
+// sizedVariant returns the algorithm in algo's family, such as blake2b and
+// blake2b-256, whose digests are size bytes long; "" if there is none.
+func sizedVariant(algo string, size int) string {
+	family, _, _ := strings.Cut(algo, "-")
+	for a, n := range digestSizes {
+		if n == size && (a == family || strings.HasPrefix(a, family+"-")) {
+			return a
+		}
+	}
+	return ""
+}
+
+// isHex reports whether s is a non-empty even-length hex string.
+func isHex(s string) bool {
+	if s == "" || len(s)%2 != 0 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,190 @@
+package manager
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Digests of the empty string, for algorithm inference by length.
+const (
+	md5Empty    = "d41d8cd98f00b204e9800998ecf8427e"
+	sha1Empty   = "da39a3ee5e6b4b0d3255bfef95601890afd80709"
+	sha256Empty = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	sha512Empty = "cf83e1357eefb8bdf1542850d66d8007d620e4050b5715dc83f4a921d36ce9ce47d0d13c5d85f2b0ff8318d2877eec2f63b931bd47417a81a538327af927da3e"
+)
+
+func TestParseChecksumFile_GNU(t *testing.T) {
+	t.Run("standard format with multiple entries", func(t *testing.T) {
+		input := "" +
+			"abc123  foo.tar.gz\n" +
+			"def456  bar.zip\n" +
+			"789abc  baz.bin\n"
+		list, err := parseChecksumFile([]byte(input), "SHA256SUMS")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := map[string]string{
+			"foo.tar.gz": "abc123",
+			"bar.zip":    "def456",
+			"baz.bin":    "789abc",
+		}
+		for filename, hex := range want {
+			got, ok := list.lookup(filename)
+			if !ok || got["sha-256"] != hex {
+				t.Errorf("lookup(%q) = %v, want sha-256 %q", filename, got, hex)
+			}
+		}
+	})
+
+	t.Run("blank lines and comment lines are skipped", func(t *testing.T) {
+		input := "" +
+			"# This is a comment\n" +
+			"\n" +
+			"abc123  foo.tar.gz\n" +
+			"\n" +
+			"# Another comment\n" +
+			"def456  bar.zip\n"
+		list, err := parseChecksumFile([]byte(input), "SHA256SUMS")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(list) != 2 {
+			t.Fatalf("got %d entries, want 2", len(list))
+		}
+	})
+
+	t.Run("binary mode and single space", func(t *testing.T) {
+		input := "abc123 *foo.tar.gz\n" + "def456 bar.zip\n"
+		list, err := parseChecksumFile([]byte(input), "checksums.txt")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for name, hex := range map[string]string{"foo.tar.gz": "abc123", "bar.zip": "def456"} {
+			if got, _ := list.lookup(name); got["sha-256"] != hex {
+				t.Errorf("lookup(%q) = %v, want sha-256 %q", name, got, hex)
+			}
+		}
+	})
+
+	t.Run("sha512sum output is labelled sha-512 by length", func(t *testing.T) {
+		list, err := parseChecksumFile([]byte(sha512Empty+"  tool\n"), "checksums.txt")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got, _ := list.lookup("tool"); got["sha-512"] != sha512Empty {
+			t.Errorf("got %v, want sha-512", got)
+		}
+	})
+
+	t.Run("paths match on their base name", func(t *testing.T) {
+		list, err := parseChecksumFile([]byte(sha256Empty+"  ./dist/tool\n"), "SHA256SUMS")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, ok := list.lookup("tool"); !ok {
+			t.Error("expected ./dist/tool to match tool")
+		}
+	})
+
+	t.Run("malformed line returns error", func(t *testing.T) {
+		if _, err := parseChecksumFile([]byte("not a checksum line\n"), "SHA256SUMS"); err == nil {
+			t.Fatal("expected error for malformed line, got nil")
+		}
+	})
+}
+
+func TestParseChecksumFile_BSD(t *testing.T) {
+	input := "" +
+		"SHA256 (tool) = " + sha256Empty + "\n" +
+		"SHA512 (tool) = " + sha512Empty + "\n" +
+		"MD5(other)= " + md5Empty + "\n"
+	list, err := parseChecksumFile([]byte(input), "CHECKSUMS")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, _ := list.lookup("tool")
+	want := map[string]string{"sha-256": sha256Empty, "sha-512": sha512Empty}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("lookup(tool) = %v, want %v", got, want)
+	}
+	if got, _ := list.lookup("other"); got["md5"] != md5Empty {
+		t.Errorf("lookup(other) = %v, want md5", got)
+	}
+}
+
+func TestParseChecksumFile_JSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]string
+	}{
+		{
+			"name to digest",
+			`{"tool": "` + sha256Empty + `", "other": "` + sha1Empty + `"}`,
+			map[string]string{"sha-256": sha256Empty},
+		},
+		{
+			"name to algorithms",
+			`{"tool": {"sha256": "` + sha256Empty + `", "sha512": "` + sha512Empty + `"}}`,
+			map[string]string{"sha-256": sha256Empty, "sha-512": sha512Empty},
+		},
+		{
+			"list of named objects",
+			`{"files": [{"name": "other", "sha256": "` + strings.Repeat("0", 64) + `"}, {"name": "tool", "sha256": "` + sha256Empty + `"}]}`,
+			map[string]string{"sha-256": sha256Empty},
+		},
+		{
+			"prefixed digest",
+			`[{"name": "tool", "path": "dist/tool", "extra": {"Checksum": "sha256:` + sha256Empty + `"}}]`,
+			map[string]string{"sha-256": sha256Empty},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := parseChecksumFile([]byte(tt.input), "checksums.json")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, ok := list.lookup("tool")
+			if !ok || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lookup(tool) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseChecksumFile_BareDigest(t *testing.T) {
+	list, err := parseChecksumFile([]byte("  "+strings.ToUpper(sha1Empty)+"\n"), "tool.sha1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, ok := list.lookup("anything")
+	if !ok || got["sha-1"] != sha1Empty {
+		t.Errorf("got %v, want lower-case sha-1", got)
+	}
+}
+
+func TestInferAlgorithm(t *testing.T) {
+	tests := []struct {
+		digest, file, want string
+	}{
+		{md5Empty, "tool.md5", "md5"},
+		{sha1Empty, "checksums.txt", "sha-1"},
+		{sha256Empty, "SHA256SUMS", "sha-256"},
+		{sha256Empty, "tool.sha3-256", "sha3-256"},
+		{sha256Empty, "tool.b3", "blake3"},
+		{sha512Empty, "tool.b2", "blake2b"},
+		{sha256Empty, "tool.b2", "blake2b-256"}, // b2sum -l 256
+		{sha256Empty, "tool.blake2b", "blake2b-256"},
+		{sha512Empty, "tool.sha3-256", "sha3-512"}, // a sized variant of the named family
+		{sha512Empty, "SHA256SUMS", "sha-512"},     // the length wins over a contradicting name
+		{"abc123", "tool.sha512", "sha-512"},       // no known length: the name decides
+		{"abc123", "checksums.txt", "sha-256"},     // neither: sha-256
+	}
+	for _, tt := range tests {
+		if got := inferAlgorithm(tt.digest, tt.file); got != tt.want {
+			t.Errorf("inferAlgorithm(%d hex digits, %q) = %q, want %q", len(tt.digest), tt.file, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/apex/log"
+	"github.com/ventifus/binmgr/pkg/manifest"
+)
+
+// historyLimit is the number of previous versions of each package kept for
+// rollback.
+const historyLimit = 3
+
+// Rollback restores a previously installed version of a package from its
+// history. The files and manifest are restored from the local snapshot; the
+// backend is not contacted. The version being replaced is itself kept in the
+// history, so a rollback can be undone by rolling back again.
+func (m *mgr) Rollback(ctx context.Context, opts RollbackOptions) (result *RollbackResult, retErr error) {
+	current, err := manifest.Load(opts.ID, m.libDir)
+	if err != nil {
+		return nil, fmt.Errorf("rollback: %w", err)
+	}
+
+	history, err := manifest.LoadHistory(opts.ID, m.libDir)
+	if err != nil {
+		return nil, fmt.Errorf("rollback: %w", err)
+	}
+	var target *manifest.Snapshot
+	for _, s := range history {
+		if opts.Version == "" || s.Package.Version == opts.Version {
+			target = s
+			break
+		}
+	}
+	if target == nil {
+		if opts.Version != "" {
+			return nil, fmt.Errorf("rollback: no previous version %q of %q is kept", opts.Version, opts.ID)
+		}
+		return nil, fmt.Errorf("rollback: no previous version of %q is kept", opts.ID)
+	}
+
+	// Stage every file of the snapshot, checking it still matches the
+	// checksum recorded when it was installed.
+	var files fileSet
+	defer func() {
+		if retErr != nil {
+			if err := files.rollback(); err != nil {
+				retErr = fmt.Errorf("%w (rollback: %v)", retErr, err)
+			}
+		}
+	}()
+
+	restored := make(map[string]bool)
+	for i, spec := range target.Package.Specs {
+		for j, f := range spec.InstalledFiles {
+			checksums, err := m.stage(ctx, &files, target.File(i, j), f.LocalPath)
+			if err != nil {
+				return nil, fmt.Errorf("rollback: stage %q: %w", f.LocalPath, err)
+			}
+			if want := f.Checksums["sha-256"]; want != "" && checksums["sha-256"] != want {
+				return nil, fmt.Errorf("rollback: kept copy of %q does not match its recorded sha-256", f.LocalPath)
+			}
+			restored[f.LocalPath] = true
+		}
+	}
+
+	// Keep the version being replaced so the rollback can itself be undone.
+	snap, err := m.snapshot(current)
+	if err != nil {
+		return nil, fmt.Errorf("rollback: %w", err)
+	}
+	defer func() {
+		if retErr != nil {
+			os.RemoveAll(snap.Dir)
+		}
+	}()
+
+	if err := files.commit(); err != nil {
+		return nil, fmt.Errorf("rollback: %w", err)
+	}
+
+	pkg := target.Package
+	pkg.Pinned = current.Pinned
+	if err := manifest.Save(pkg, m.libDir); err != nil {
+		return nil, fmt.Errorf("rollback: save manifest for %q: %w", opts.ID, err)
+	}
+	files.cleanup()
+
+	// Files that only the replaced version installed no longer belong to the
+	// package; they are kept in its snapshot.
+	for _, spec := range current.Specs {
+		for _, f := range spec.InstalledFiles {
+			if !restored[f.LocalPath] {
+				if err := os.Remove(f.LocalPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+					log.WithField("file", f.LocalPath).WithError(err).Warn("failed to remove file not present in restored version")
+				}
+			}
+		}
+	}
+
+	// The restored version is current again; it no longer needs a snapshot.
+	if err := os.RemoveAll(target.Dir); err != nil {
+		log.WithField("dir", target.Dir).WithError(err).Warn("failed to remove restored snapshot")
+	}
+	m.pruneHistory(opts.ID)
+
+	return &RollbackResult{
+		ID:         opts.ID,
+		OldVersion: current.Version,
+		NewVersion: pkg.Version,
+	}, nil
+}
+
+// snapshot records pkg and a copy of each of its installed files in the
+// package history. Installed files that no longer exist are skipped; a
+// rollback to this snapshot will report them.
+func (m *mgr) snapshot(pkg *manifest.Package) (*manifest.Snapshot, error) {
+	s, err := manifest.NewSnapshot(pkg, m.libDir)
+	if err != nil {
+		return nil, err
+	}
+	for i, spec := range pkg.Specs {
+		for j, f := range spec.InstalledFiles {
+			if err := preserve(f.LocalPath, s.File(i, j)); err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				}
+				os.RemoveAll(s.Dir)
+				return nil, fmt.Errorf("keep copy of %q: %w", f.LocalPath, err)
+			}
+		}
+	}
+	if err := s.Commit(); err != nil {
+		os.RemoveAll(s.Dir)
+		return nil, err
+	}
+	return s, nil
+}
+
+// pruneHistory drops snapshots of id beyond historyLimit. Failing to prune
+// does not affect the installed package, so it is only logged.
+func (m *mgr) pruneHistory(id string) {
+	if err := manifest.PruneHistory(id, m.libDir, historyLimit); err != nil {
+		log.WithField("package", id).WithError(err).Warn("failed to prune history")
+	}
+}
+
+// preserve copies src to dst. The snapshot must not share an inode with the
+// installed file, which anything outside binmgr may rewrite in place. Copying
+// between files lets the kernel clone extents on filesystems that support it,
+// so the copy is cheap where it can be.
+func preserve(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst)
+	}
+	return err
+}
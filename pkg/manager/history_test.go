@@ -0,0 +1,299 @@
+package manager
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ventifus/binmgr/pkg/backend"
+	"github.com/ventifus/binmgr/pkg/manifest"
+)
+
+const historyPkgID = "example.com/owner/mytool"
+
+// contentCompute returns the real sha-256 of data so tests can detect
+// changed file content.
+func contentCompute(ctx context.Context, data []byte, algorithms []string) (map[string]string, error) {
+	sum := sha256.Sum256(data)
+	return map[string]string{"sha-256": hex.EncodeToString(sum[:])}, nil
+}
+
+// newHistoryManager returns a manager whose backend resolves to
+// resolution.Version and serves "binary-<version>" as the asset. Tests change
+// the version between installs. It also returns the installed file's path and
+// the manifest lib directory.
+func newHistoryManager(t *testing.T) (Manager, *backend.Resolution, string, string) {
+	t.Helper()
+
+	resolution := &backend.Resolution{
+		Assets: []backend.Asset{
+			{Name: "mytool-linux-amd64", URL: "https://example.com/mytool-linux-amd64"},
+		},
+	}
+	fetcher := &MockFetcher{
+		FetchFn: func(ctx context.Context, u string) ([]byte, error) {
+			return []byte("binary-" + resolution.Version), nil
+		},
+	}
+	verifier := &MockVerifier{
+		VerifyFn:  func(ctx context.Context, data []byte, expected map[string]string) error { return nil },
+		ComputeFn: contentCompute,
+	}
+	extractor := &MockExtractor{ExtractFn: noExtract}
+
+	m, home := newInstallManager(t, fetcher, extractor, verifier, "github", resolution)
+	return m, resolution,
+		filepath.Join(home, ".local", "bin", "mytool"),
+		filepath.Join(home, ".local", "share", "binmgr")
+}
+
+// installVersions installs each version in turn.
+func installVersions(t *testing.T, m Manager, resolution *backend.Resolution, versions ...string) {
+	t.Helper()
+	opts := InstallOptions{
+		SourceURL: "https://" + historyPkgID,
+		Specs: []SpecOpts{
+			{AssetGlob: "mytool-linux-amd64", LocalName: "mytool", Checksum: ChecksumOpts{Strategy: "none"}},
+		},
+	}
+	for _, v := range versions {
+		resolution.Version = v
+		if err := m.Install(context.Background(), opts); err != nil {
+			t.Fatalf("Install %s: %v", v, err)
+		}
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %q: %v", path, err)
+	}
+	return string(data)
+}
+
+// TestRollback_RestoresPreviousVersion verifies that rollback restores the
+// previous version's files and manifest, and keeps the replaced version so
+// the rollback can be undone.
+func TestRollback_RestoresPreviousVersion(t *testing.T) {
+	m, resolution, binPath, libDir := newHistoryManager(t)
+	installVersions(t, m, resolution, "v1.0.0", "v2.0.0")
+
+	if got := readFile(t, binPath); got != "binary-v2.0.0" {
+		t.Fatalf("installed content = %q, want binary-v2.0.0", got)
+	}
+
+	result, err := m.Rollback(context.Background(), RollbackOptions{ID: historyPkgID})
+	if err != nil {
+		t.Fatalf("Rollback returned error: %v", err)
+	}
+	if result.OldVersion != "v2.0.0" || result.NewVersion != "v1.0.0" {
+		t.Errorf("result = %+v, want v2.0.0 → v1.0.0", result)
+	}
+	if got := readFile(t, binPath); got != "binary-v1.0.0" {
+		t.Errorf("installed content = %q, want binary-v1.0.0", got)
+	}
+
+	pkg, err := manifest.Load(historyPkgID, libDir)
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	if pkg.Version != "v1.0.0" {
+		t.Errorf("manifest version = %q, want v1.0.0", pkg.Version)
+	}
+
+	history, err := manifest.LoadHistory(historyPkgID, libDir)
+	if err != nil {
+		t.Fatalf("LoadHistory: %v", err)
+	}
+	if len(history) != 1 || history[0].Package.Version != "v2.0.0" {
+		t.Fatalf("expected history to hold only v2.0.0 after rollback, got %d snapshots", len(history))
+	}
+
+	// Rolling back again returns to the newer version.
+	if _, err := m.Rollback(context.Background(), RollbackOptions{ID: historyPkgID}); err != nil {
+		t.Fatalf("second Rollback returned error: %v", err)
+	}
+	if got := readFile(t, binPath); got != "binary-v2.0.0" {
+		t.Errorf("installed content = %q, want binary-v2.0.0", got)
+	}
+}
+
+// TestRollback_ToVersion verifies that --to selects a specific kept version
+// and that an unknown version is an error that changes nothing.
+func TestRollback_ToVersion(t *testing.T) {
+	m, resolution, binPath, _ := newHistoryManager(t)
+	installVersions(t, m, resolution, "v1.0.0", "v2.0.0", "v3.0.0")
+
+	if _, err := m.Rollback(context.Background(), RollbackOptions{ID: historyPkgID, Version: "v9.9.9"}); err == nil {
+		t.Fatal("expected error rolling back to a version that is not kept, got nil")
+	}
+	if got := readFile(t, binPath); got != "binary-v3.0.0" {
+		t.Errorf("installed content = %q after failed rollback, want binary-v3.0.0", got)
+	}
+
+	result, err := m.Rollback(context.Background(), RollbackOptions{ID: historyPkgID, Version: "v1.0.0"})
+	if err != nil {
+		t.Fatalf("Rollback returned error: %v", err)
+	}
+	if result.NewVersion != "v1.0.0" {
+		t.Errorf("NewVersion = %q, want v1.0.0", result.NewVersion)
+	}
+	if got := readFile(t, binPath); got != "binary-v1.0.0" {
+		t.Errorf("installed content = %q, want binary-v1.0.0", got)
+	}
+}
+
+// TestRollback_NoHistory verifies that a package installed only once has
+// nothing to roll back to, and that reinstalling the same version does not
+// create a snapshot.
+func TestRollback_NoHistory(t *testing.T) {
+	m, resolution, _, _ := newHistoryManager(t)
+	installVersions(t, m, resolution, "v1.0.0", "v1.0.0")
+
+	if _, err := m.Rollback(context.Background(), RollbackOptions{ID: historyPkgID}); err == nil {
+		t.Fatal("expected error when no previous version is kept, got nil")
+	}
+}
+
+// TestRollback_SnapshotIndependentOfInstalledFile verifies that a kept copy
+// does not share storage with the installed file, so rewriting the installed
+// file in place leaves the snapshot intact.
+func TestRollback_SnapshotIndependentOfInstalledFile(t *testing.T) {
+	m, resolution, binPath, libDir := newHistoryManager(t)
+	installVersions(t, m, resolution, "v1.0.0", "v2.0.0")
+
+	history, err := manifest.LoadHistory(historyPkgID, libDir)
+	if err != nil || len(history) != 1 {
+		t.Fatalf("LoadHistory = %d snapshots, %v; want 1", len(history), err)
+	}
+	// Rolling back snapshots v2.0.0 from the installed file.
+	if _, err := m.Rollback(context.Background(), RollbackOptions{ID: historyPkgID}); err != nil {
+		t.Fatalf("Rollback returned error: %v", err)
+	}
+	history, err = manifest.LoadHistory(historyPkgID, libDir)
+	if err != nil || len(history) != 1 {
+		t.Fatalf("LoadHistory = %d snapshots, %v; want 1", len(history), err)
+	}
+	live, err := os.Stat(binPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, err := os.Stat(history[0].File(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if os.SameFile(live, kept) {
+		t.Fatal("kept copy is the installed file")
+	}
+
+	if err := os.WriteFile(binPath, []byte("edited"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Rollback(context.Background(), RollbackOptions{ID: historyPkgID}); err != nil {
+		t.Fatalf("Rollback after in-place edit returned error: %v", err)
+	}
+	if got := readFile(t, binPath); got != "binary-v2.0.0" {
+		t.Errorf("installed content = %q, want binary-v2.0.0", got)
+	}
+}
+
+// TestSnapshot_FailedCopyNotListed verifies that a snapshot whose files
+// could not all be copied is removed and never listed as complete.
+func TestSnapshot_FailedCopyNotListed(t *testing.T) {
+	m, resolution, binPath, libDir := newHistoryManager(t)
+	installVersions(t, m, resolution, "v1.0.0")
+
+	pkg, err := manifest.Load(historyPkgID, libDir)
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	// A directory in place of the installed file cannot be copied.
+	if err := os.Remove(binPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(binPath, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.(*mgr).snapshot(pkg); err == nil {
+		t.Fatal("expected error copying a directory, got nil")
+	}
+	history, err := manifest.LoadHistory(historyPkgID, libDir)
+	if err != nil {
+		t.Fatalf("LoadHistory: %v", err)
+	}
+	if len(history) != 0 {
+		t.Errorf("LoadHistory returned %d snapshots after a failed copy, want 0", len(history))
+	}
+}
+
+// TestRollback_CorruptedSnapshot verifies that a kept file whose content no
+// longer matches its recorded checksum is refused and the current version is
+// left in place.
+func TestRollback_CorruptedSnapshot(t *testing.T) {
+	m, resolution, binPath, libDir := newHistoryManager(t)
+	installVersions(t, m, resolution, "v1.0.0", "v2.0.0")
+
+	history, err := manifest.LoadHistory(historyPkgID, libDir)
+	if err != nil || len(history) != 1 {
+		t.Fatalf("LoadHistory = %d snapshots, %v; want 1", len(history), err)
+	}
+	kept := history[0].File(0, 0)
+	if err := os.WriteFile(kept, []byte("tampered"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Rollback(context.Background(), RollbackOptions{ID: historyPkgID}); err == nil {
+		t.Fatal("expected error restoring a tampered snapshot, got nil")
+	}
+	if got := readFile(t, binPath); got != "binary-v2.0.0" {
+		t.Errorf("installed content = %q, want binary-v2.0.0 left in place", got)
+	}
+	pkg, err := manifest.Load(historyPkgID, libDir)
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	if pkg.Version != "v2.0.0" {
+		t.Errorf("manifest version = %q, want v2.0.0", pkg.Version)
+	}
+}
+
+// TestInstall_HistoryPruned verifies that only historyLimit previous
+// versions are kept.
+func TestInstall_HistoryPruned(t *testing.T) {
+	m, resolution, _, libDir := newHistoryManager(t)
+	installVersions(t, m, resolution, "v1", "v2", "v3", "v4", "v5")
+
+	history, err := manifest.LoadHistory(historyPkgID, libDir)
+	if err != nil {
+		t.Fatalf("LoadHistory: %v", err)
+	}
+	if len(history) != historyLimit {
+		t.Fatalf("expected %d snapshots, got %d", historyLimit, len(history))
+	}
+	want := []string{"v4", "v3", "v2"}
+	for i, s := range history {
+		if s.Package.Version != want[i] {
+			t.Errorf("history[%d] = %q, want %q", i, s.Package.Version, want[i])
+		}
+	}
+}
+
+// TestUninstall_RemovesHistory verifies that uninstall also deletes kept
+// previous versions.
+func TestUninstall_RemovesHistory(t *testing.T) {
+	m, resolution, _, libDir := newHistoryManager(t)
+	installVersions(t, m, resolution, "v1.0.0", "v2.0.0")
+
+	if err := m.Uninstall(context.Background(), []string{historyPkgID}); err != nil {
+		t.Fatalf("Uninstall returned error: %v", err)
+	}
+	if _, err := os.Stat(manifest.HistoryDir(historyPkgID, libDir)); !os.IsNotExist(err) {
+		t.Errorf("expected history directory to be removed, got stat err: %v", err)
+	}
+}
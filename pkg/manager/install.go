@@ -3,11 +3,17 @@ package manager
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/apex/log"
 	"github.com/ventifus/binmgr/pkg/backend"
 	"github.com/ventifus/binmgr/pkg/extract"
 	"github.com/ventifus/binmgr/pkg/manifest"
@@ -15,7 +21,15 @@ import (
 
 // Install downloads and installs binaries from a source URL, then records a
 // manifest for future update and status operations.
-func (m *mgr) Install(ctx context.Context, opts InstallOptions) error {
+//
+// If the resolved version is already installed from the same specs and every
+// installed file still matches its recorded checksum, nothing is downloaded
+// unless opts.Force is set.
+//
+// The install is all-or-nothing: every file is staged beside its destination
+// before any is replaced, and if a later step fails (including saving the
+// manifest) all of the package's replaced files are restored.
+func (m *mgr) Install(ctx context.Context, opts InstallOptions) (retErr error) {
 	// 1. Parse the source URL; prepend https:// if no scheme is present.
 	sourceURL := opts.SourceURL
 	if !strings.HasPrefix(sourceURL, "http") {
@@ -37,8 +51,27 @@ func (m *mgr) Install(ctx context.Context, opts InstallOptions) error {
 		return fmt.Errorf("install: select backend: %w", err)
 	}
 
-	// 3. Resolve version and asset list.
-	resolution, err := b.Resolve(ctx, parsedURL, backend.ResolveOptions{Version: opts.Version})
+	// 3. Resolve version and asset list. The minimum release age only
+	//    holds back the latest release; an explicit version is taken as is.
+	var minAge time.Duration
+	if opts.Version == "" && opts.ReleaseID == 0 {
+		age := opts.MinReleaseAge
+		if age == "" {
+			age = opts.DefaultMinReleaseAge
+		}
+		if age != "" {
+			if minAge, err = manifest.ParseAge(age); err != nil {
+				return fmt.Errorf("install: minimum release age: %w", err)
+			}
+		}
+	}
+	resolution, err := b.Resolve(ctx, parsedURL, backend.ResolveOptions{
+		Version:       opts.Version,
+		ReleaseID:     opts.ReleaseID,
+		Channel:       opts.Channel,
+		Constraint:    opts.Constraint,
+		MinReleaseAge: minAge,
+	})
 	if err != nil {
 		return fmt.Errorf("install: resolve %q: %w", parsedURL, err)
 	}
@@ -52,6 +85,11 @@ func (m *mgr) Install(ctx context.Context, opts InstallOptions) error {
 		if len(opts.Specs) == 0 {
 			return fmt.Errorf("install: kubeurl backend requires at least one spec")
 		}
+		for _, spec := range opts.Specs {
+			if spec.AssetGlob == "" {
+				return fmt.Errorf("install: kubeurl backend cannot select an asset automatically; specify one with --file")
+			}
+		}
 		pkgID = parsedURL.Host + "/" + opts.Specs[0].AssetGlob
 		assets := make([]backend.Asset, 0, len(opts.Specs))
 		for _, spec := range opts.Specs {
@@ -70,60 +108,119 @@ func (m *mgr) Install(ctx context.Context, opts InstallOptions) error {
 
 	// 5-6. For each spec, expand vars and find the matching asset.
 	//      Group by expanded AssetGlob to deduplicate downloads.
-	type specWork struct {
-		spec         SpecOpts
-		expandedGlob string
-		expandedCSum ChecksumOpts
-		expandedTrav []string
-		asset        *backend.Asset
-	}
 	works := make([]specWork, 0, len(opts.Specs))
 	for i := range opts.Specs {
 		spec := opts.Specs[i]
+
+		// No asset glob: pick the best asset for this platform and record it
+		// as a glob so updates select the same asset deterministically.
+		var auto *backend.Asset
+		if spec.AssetGlob == "" {
+			auto, err = selectAsset(resolution.Assets, platformOS, platformArch)
+			if err != nil {
+				return fmt.Errorf("install: spec %d: %w", i, err)
+			}
+			spec.AssetGlob = templatize(auto.Name, resolution.Version)
+		}
+
 		expandedGlob := ExpandVars(spec.AssetGlob, resolution.Version)
 		expandedTrav := make([]string, len(spec.TraversalGlobs))
 		for j, g := range spec.TraversalGlobs {
 			expandedTrav[j] = ExpandVars(g, resolution.Version)
 		}
-		expandedCSum := ChecksumOpts{
-			Strategy:      spec.Checksum.Strategy,
-			FileGlob:      ExpandVars(spec.Checksum.FileGlob, resolution.Version),
-			OrderGlob:     ExpandVars(spec.Checksum.OrderGlob, resolution.Version),
-			Suffix:        spec.Checksum.Suffix,
-			TraversalGlob: ExpandVars(spec.Checksum.TraversalGlob, resolution.Version),
-		}
 
 		// Find matching asset for this spec's expanded glob.
-		var matched *backend.Asset
-		for j := range resolution.Assets {
+		matched := auto
+		for j := 0; matched == nil && j < len(resolution.Assets); j++ {
 			ok, err := filepath.Match(expandedGlob, resolution.Assets[j].Name)
 			if err != nil {
 				return fmt.Errorf("install: spec %d: invalid asset glob %q: %w", i, expandedGlob, err)
 			}
 			if ok {
 				matched = &resolution.Assets[j]
-				break
 			}
 		}
 		if matched == nil {
 			return fmt.Errorf("install: spec %d: no asset matched glob %q", i, expandedGlob)
 		}
 
+		// Checksum strategy "auto": record the checksum file it detects as a
+		// shared-file glob, so updates verify against the same file instead
+		// of re-running the heuristic. Checksums listed by the backend need
+		// no file.
+		if spec.Checksum.Strategy == "auto" && len(matched.Checksums) == 0 {
+			file, err := detectChecksumFile(resolution)
+			if err != nil {
+				return fmt.Errorf("install: spec %d: %w", i, err)
+			}
+			spec.Checksum.Strategy = "shared-file"
+			spec.Checksum.FileGlob = templatize(file.Name, resolution.Version)
+			log.WithField("file", file.Name).WithField("glob", spec.Checksum.FileGlob).Debug("detected checksum file")
+		}
+		expandedCSum := ChecksumOpts{
+			Strategy:      spec.Checksum.Strategy,
+			FileGlob:      ExpandVars(spec.Checksum.FileGlob, resolution.Version),
+			OrderGlob:     ExpandVars(spec.Checksum.OrderGlob, resolution.Version),
+			Suffix:        spec.Checksum.Suffix,
+			TraversalGlob: ExpandVars(spec.Checksum.TraversalGlob, resolution.Version),
+			Signer:        spec.Checksum.Signer,
+		}
+
 		works = append(works, specWork{
 			spec:         spec,
 			expandedGlob: expandedGlob,
 			expandedCSum: expandedCSum,
 			expandedTrav: expandedTrav,
 			asset:        matched,
+			auto:         auto != nil,
 		})
 	}
 
-	// 7. Download and verify each unique asset URL exactly once.
-	//    Key: asset URL → downloaded bytes + resolved checksums.
-	type downloadResult struct {
-		data      []byte
-		checksums map[string]string
+	defaultDir := opts.DefaultDir
+	if defaultDir == "" {
+		defaultDir = filepath.Join(os.Getenv("HOME"), ".local", "bin")
+	} else if strings.HasPrefix(defaultDir, "~/") {
+		defaultDir = filepath.Join(os.Getenv("HOME"), defaultDir[2:])
 	}
+
+	// 7. If this exact install is already on disk, intact, there is nothing
+	//    to download. Only the pin status and release selection settings may
+	//    need recording.
+	//    A change in the provenance required means the files on disk were
+	//    never checked against it, so they are downloaded again.
+	if !opts.Force {
+		if prev, err := manifest.Load(pkgID, m.libDir); err == nil && reflect.DeepEqual(prev.Provenance, opts.Provenance) {
+			ok, err := m.alreadyInstalled(ctx, prev, resolution.Version, works, defaultDir)
+			if err != nil {
+				return fmt.Errorf("install: check existing install of %q: %w", pkgID, err)
+			}
+			if ok {
+				log.WithField("package", pkgID).WithField("version", prev.Version).Info("already installed")
+				if prev.Pinned != opts.Pin || !reflect.DeepEqual(prev.Channel, opts.Channel) ||
+					prev.Constraint != opts.Constraint || prev.MinReleaseAge != opts.MinReleaseAge {
+					prev.Pinned = opts.Pin
+					prev.Channel = opts.Channel
+					prev.Constraint = opts.Constraint
+					prev.MinReleaseAge = opts.MinReleaseAge
+					if err := manifest.Save(prev, m.libDir); err != nil {
+						return fmt.Errorf("install: save manifest for %q: %w", pkgID, err)
+					}
+				}
+				return nil
+			}
+		}
+	}
+
+	// 8. Download and verify each unique asset URL exactly once.
+	//    Downloads are spooled into a scratch directory rather than held in
+	//    memory; everything in it is removed when Install returns.
+	//    Key: asset URL → spooled file + resolved checksums.
+	scratch, err := os.MkdirTemp("", "binmgr-")
+	if err != nil {
+		return fmt.Errorf("install: create scratch directory: %w", err)
+	}
+	defer os.RemoveAll(scratch)
+
 	downloads := make(map[string]*downloadResult)
 
 	for i := range works {
@@ -132,77 +229,75 @@ func (m *mgr) Install(ctx context.Context, opts InstallOptions) error {
 			continue
 		}
 
-		// Fetch.
-		data, err := m.fetcher.Fetch(ctx, assetURL)
+		dl, err := m.download(ctx, works[i].asset, works[i].expandedCSum, resolution, scratch)
 		if err != nil {
-			return fmt.Errorf("install: fetch %q: %w", assetURL, err)
-		}
-
-		// Resolve checksums.
-		checksums, err := m.resolveChecksums(
-			ctx,
-			works[i].expandedCSum,
-			works[i].asset.Name,
-			data,
-			assetURL,
-			works[i].asset.Checksums,
-			resolution,
-			resolution.Version,
-		)
-		if err != nil {
-			return fmt.Errorf("install: resolve checksums for %q: %w", works[i].asset.Name, err)
+			return fmt.Errorf("install: %w", err)
 		}
-
-		// Verify if checksums were returned.
-		if checksums != nil {
-			if err := m.verifier.Verify(ctx, data, checksums); err != nil {
-				return fmt.Errorf("install: verify %q: %w", works[i].asset.Name, err)
+		if opts.Provenance != nil {
+			prov, err := m.verifyProvenance(ctx, opts.Provenance, works[i].asset, dl.path, normalizedSourceURL, resolution)
+			if err != nil {
+				return fmt.Errorf("install: %w", err)
 			}
+			dl.builder, dl.sourceCommit = prov.Builder, prov.SourceCommit
 		}
-
-		downloads[assetURL] = &downloadResult{data: data, checksums: checksums}
+		downloads[assetURL] = dl
 	}
 
-	// 8. For each spec, extract and write files.
-	defaultDir := opts.DefaultDir
-	if defaultDir == "" {
-		defaultDir = filepath.Join(os.Getenv("HOME"), ".local", "bin")
-	} else if strings.HasPrefix(defaultDir, "~/") {
-		defaultDir = filepath.Join(os.Getenv("HOME"), defaultDir[2:])
+	// For automatically selected assets, find what to install inside the
+	// download and record it as ordinary traversal globs and local name.
+	for i := range works {
+		w := &works[i]
+		if w.auto {
+			if err := m.selectTarget(ctx, w, downloads[w.asset.URL].path, path.Base(parsedURL.Path), resolution.Version); err != nil {
+				return fmt.Errorf("install: %w", err)
+			}
+		}
 	}
 
+	// 9. For each spec, extract and stage files. Nothing is replaced on disk
+	//    until every spec has been staged.
+	var files fileSet
+	defer func() {
+		if retErr != nil {
+			if err := files.rollback(); err != nil {
+				retErr = fmt.Errorf("%w (rollback: %v)", retErr, err)
+			}
+		}
+	}()
+
 	manifestSpecs := make([]manifest.InstallSpec, 0, len(opts.Specs))
 
 	for i := range works {
 		w := &works[i]
 		dl := downloads[w.asset.URL]
 
-		// Extract or treat as direct bytes.
-		var files []extract.ExtractedFile
+		// Extract, or take the download itself, decompressed if it is a
+		// plain compressed file.
+		var extracted []extract.ExtractedFile
 		if len(w.expandedTrav) > 0 {
-			files, err = m.extractor.Extract(ctx, w.asset.Name, dl.data, w.expandedTrav)
+			extracted, err = m.traverse(ctx, w.asset.Name, dl.path, w.expandedTrav, "", scratch)
 			if err != nil {
 				return fmt.Errorf("install: extract %q: %w", w.asset.Name, err)
 			}
 		} else {
-			files = []extract.ExtractedFile{{SourcePath: "", Data: dl.data}}
+			payload, err := m.extractor.Decompress(ctx, w.asset.Name, dl.path, scratch)
+			if err != nil {
+				return fmt.Errorf("install: decompress %q: %w", w.asset.Name, err)
+			}
+			extracted = []extract.ExtractedFile{{SourcePath: "", Path: payload}}
 		}
 
 		var installedFiles []manifest.InstalledFile
-		for _, file := range files {
+		for _, file := range extracted {
 			localPath := resolveLocalPath(w.spec.LocalName, file.SourcePath, w.asset.Name, defaultDir)
 
 			if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
 				return fmt.Errorf("install: create directory for %q: %w", localPath, err)
 			}
 
-			fileChecksums, err := m.verifier.Compute(ctx, file.Data, []string{"sha-256"})
+			fileChecksums, err := m.stage(ctx, &files, file.Path, localPath)
 			if err != nil {
-				return fmt.Errorf("install: compute checksums for %q: %w", localPath, err)
-			}
-
-			if err := os.WriteFile(localPath, file.Data, 0755); err != nil {
-				return fmt.Errorf("install: write %q: %w", localPath, err)
+				return fmt.Errorf("install: stage %q: %w", localPath, err)
 			}
 
 			installedFiles = append(installedFiles, manifest.InstalledFile{
@@ -213,29 +308,20 @@ func (m *mgr) Install(ctx context.Context, opts InstallOptions) error {
 		}
 
 		// Build manifest spec using original unexpanded patterns.
-		// ChecksumOpts.FileGlob doubles as the data file glob for "multisum";
-		// store it in the correct manifest field based on strategy.
-		csumCfg := manifest.ChecksumConfig{
-			Strategy:      w.spec.Checksum.Strategy,
-			Suffix:        w.spec.Checksum.Suffix,
-			OrderGlob:     w.spec.Checksum.OrderGlob,
-			TraversalGlob: w.spec.Checksum.TraversalGlob,
-		}
-		switch w.spec.Checksum.Strategy {
-		case "multisum":
-			csumCfg.DataGlob = w.spec.Checksum.FileGlob
-		default:
-			csumCfg.FileGlob = w.spec.Checksum.FileGlob
-		}
-
 		mspec := manifest.InstallSpec{
 			AssetGlob:      w.spec.AssetGlob,
 			TraversalGlobs: w.spec.TraversalGlobs,
 			LocalName:      w.spec.LocalName,
-			Checksum:       csumCfg,
+			Checksum:       checksumConfig(w.spec.Checksum),
 			Asset: &manifest.DownloadedAsset{
-				URL:       w.asset.URL,
-				Checksums: dl.checksums,
+				URL:                w.asset.URL,
+				Checksums:          dl.checksums,
+				ChecksumSourceURL:  dl.sourceURL,
+				ChecksumStrategy:   dl.strategy,
+				VerifiedAlgorithms: dl.algorithms,
+				SignedBy:           dl.signedBy,
+				Builder:            dl.builder,
+				SourceCommit:       dl.sourceCommit,
 			},
 			InstalledFiles: installedFiles,
 		}
@@ -243,21 +329,295 @@ func (m *mgr) Install(ctx context.Context, opts InstallOptions) error {
 		manifestSpecs = append(manifestSpecs, mspec)
 	}
 
-	// 9. Build and save the manifest.
+	// 10. Keep the previously installed version, if any, so it can be rolled
+	//    back to. Reinstalling the same version replaces nothing worth keeping.
+	prev, err := manifest.Load(pkgID, m.libDir)
+	if err == nil && prev.Version != resolution.Version {
+		snap, err := m.snapshot(prev)
+		if err != nil {
+			return fmt.Errorf("install: %w", err)
+		}
+		defer func() {
+			if retErr != nil {
+				os.RemoveAll(snap.Dir)
+			}
+		}()
+	}
+
+	// 11. Move every staged file into place.
+	if err := files.commit(); err != nil {
+		return fmt.Errorf("install: %w", err)
+	}
+
+	// 12. Build and save the manifest.
 	pkg := &manifest.Package{
-		ID:        pkgID,
-		Backend:   b.Type(),
-		SourceURL: normalizedSourceURL,
-		Version:   resolution.Version,
-		Pinned:    opts.Pin,
-		Specs:     manifestSpecs,
+		ID:            pkgID,
+		Backend:       b.Type(),
+		SourceURL:     normalizedSourceURL,
+		Version:       resolution.Version,
+		ReleaseID:     resolution.ReleaseID,
+		Pinned:        opts.Pin,
+		Channel:       opts.Channel,
+		Constraint:    opts.Constraint,
+		MinReleaseAge: opts.MinReleaseAge,
+		Provenance:    opts.Provenance,
+		Specs:         manifestSpecs,
 	}
 
-	// 10. Save.
 	if err := manifest.Save(pkg, m.libDir); err != nil {
 		return fmt.Errorf("install: save manifest for %q: %w", pkgID, err)
 	}
 
+	files.cleanup()
+	m.pruneHistory(pkgID)
+	return nil
+}
+
+// specWork is one install spec with its patterns expanded for the resolved
+// version, and the asset it selected.
+type specWork struct {
+	spec         SpecOpts
+	expandedGlob string
+	expandedCSum ChecksumOpts
+	expandedTrav []string
+	asset        *backend.Asset
+	auto         bool // asset (and traversal) chosen by selectAsset / selectTarget
+}
+
+// alreadyInstalled reports whether prev records exactly the install described
+// by works at version and every file it installed is still intact on disk:
+// present, executable, and matching its recorded checksums. Files recorded
+// without checksums cannot be confirmed, so they never count as intact.
+func (m *mgr) alreadyInstalled(ctx context.Context, prev *manifest.Package, version string, works []specWork, defaultDir string) (bool, error) {
+	if prev.Version != version || len(prev.Specs) != len(works) {
+		return false, nil
+	}
+	for i, w := range works {
+		ps := prev.Specs[i]
+		if w.auto {
+			// What to take from the asset is only chosen after downloading
+			// it; trust the previous choice for the same asset.
+			w.spec.TraversalGlobs = ps.TraversalGlobs
+			w.spec.LocalName = ps.LocalName
+		}
+		// Files checked one way were never checked another: a new signer
+		// or checksum source means downloading and verifying again.
+		if ps.AssetGlob != w.spec.AssetGlob ||
+			!slices.Equal(ps.TraversalGlobs, w.spec.TraversalGlobs) ||
+			!reflect.DeepEqual(ps.Checksum, checksumConfig(w.spec.Checksum)) {
+			return false, nil
+		}
+		if ps.Asset == nil || ps.Asset.URL != w.asset.URL || len(ps.InstalledFiles) == 0 {
+			return false, nil
+		}
+		for _, f := range ps.InstalledFiles {
+			if len(f.Checksums) == 0 {
+				return false, nil
+			}
+			if f.LocalPath != resolveLocalPath(w.spec.LocalName, f.SourcePath, w.asset.Name, defaultDir) {
+				return false, nil
+			}
+			problem, err := m.checkFile(ctx, f)
+			if err != nil {
+				return false, err
+			}
+			if problem != "" {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// checksumConfig returns the manifest form of c, with its patterns as given.
+// ChecksumOpts.FileGlob doubles as the data file glob for "multisum"; it is
+// stored in the manifest field for the strategy.
+func checksumConfig(c ChecksumOpts) manifest.ChecksumConfig {
+	cfg := manifest.ChecksumConfig{
+		Strategy:      c.Strategy,
+		Suffix:        c.Suffix,
+		OrderGlob:     c.OrderGlob,
+		TraversalGlob: c.TraversalGlob,
+		Signer:        c.Signer,
+	}
+	switch c.Strategy {
+	case "multisum":
+		cfg.DataGlob = c.FileGlob
+	default:
+		cfg.FileGlob = c.FileGlob
+	}
+	return cfg
+}
+
+// downloadResult is a spooled asset download and how it was verified. For
+// strategy "sigstore", signedBy is the signer of the asset or of its
+// checksum file.
+type downloadResult struct {
+	path string
+	checksumResult
+
+	// From verified provenance, if required.
+	builder      string
+	sourceCommit string
+}
+
+// download streams asset into a file under scratch. When the expected
+// checksums can be resolved up front they are verified while the download
+// is written, so the asset is read exactly once. The "embedded" strategy
+// needs the asset itself to find its checksums, so there the download is
+// spooled first and verified from disk afterwards. The "sigstore" strategy
+// checks a signature instead; see downloadSigned.
+func (m *mgr) download(ctx context.Context, asset *backend.Asset, csum ChecksumOpts, resolution *backend.Resolution, scratch string) (*downloadResult, error) {
+	if csum.Strategy == "sigstore" {
+		return m.downloadSigned(ctx, asset, csum, resolution, scratch)
+	}
+	needsAsset := len(asset.Checksums) == 0 && csum.Strategy == "embedded"
+
+	var res *checksumResult
+	var err error
+	if !needsAsset {
+		res, err = m.resolveChecksums(ctx, csum, asset, "", resolution, resolution.Version)
+		if err != nil {
+			return nil, fmt.Errorf("resolve checksums for %q: %w", asset.Name, err)
+		}
+	}
+
+	var expected map[string]string
+	if res != nil {
+		expected = res.checksums
+	}
+	path, err := m.fetchVerified(ctx, asset.Name, asset.URL, expected, scratch)
+	if err != nil {
+		return nil, err
+	}
+
+	if needsAsset {
+		res, err = m.resolveChecksums(ctx, csum, asset, path, resolution, resolution.Version)
+		if err != nil {
+			return nil, fmt.Errorf("resolve checksums for %q: %w", asset.Name, err)
+		}
+		if err := m.verifyFile(ctx, path, res.checksums); err != nil {
+			return nil, fmt.Errorf("verify %q: %w", asset.Name, err)
+		}
+	}
+
+	return &downloadResult{path: path, checksumResult: *res}, nil
+}
+
+// fetchVerified streams url into a new file under scratch and returns its
+// path. If checksums is non-nil the content is verified against it as it is
+// written; name identifies the asset in errors.
+func (m *mgr) fetchVerified(ctx context.Context, name, url string, checksums map[string]string, scratch string) (string, error) {
+	body, err := m.fetcher.Open(ctx, url)
+	if err != nil {
+		return "", fmt.Errorf("fetch %q: %w", url, err)
+	}
+	defer body.Close()
+
+	out, err := os.CreateTemp(scratch, "download-*")
+	if err != nil {
+		return "", fmt.Errorf("fetch %q: %w", url, err)
+	}
+	defer out.Close()
+
+	// Verify reads through the tee, so the file is written as it is hashed.
+	// Drain whatever Verify left unread (all of it for "none").
+	tee := io.TeeReader(body, out)
+	if checksums != nil {
+		if err := m.verifier.Verify(ctx, tee, checksums); err != nil {
+			return "", fmt.Errorf("verify %q: %w", name, err)
+		}
+	}
+	if _, err := io.Copy(io.Discard, tee); err != nil {
+		return "", fmt.Errorf("fetch %q: %w", url, err)
+	}
+	if err := out.Close(); err != nil {
+		return "", fmt.Errorf("fetch %q: %w", url, err)
+	}
+	return out.Name(), nil
+}
+
+// verifyFile checks the file at path against expected.
+func (m *mgr) verifyFile(ctx context.Context, path string, expected map[string]string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return m.verifier.Verify(ctx, f, expected)
+}
+
+// traverseSeparator joins the per-level entry paths of a nested archive into
+// a single SourcePath, mirroring the "!" separator used by --file specs.
+const traverseSeparator = "!"
+
+// traverse applies globs to the archive at path one layer at a time: globs[0]
+// selects entries from the outer archive, each match is then treated as an
+// archive in its own right and globs[1] is applied to it, and so on. The
+// returned files carry the full nested path (e.g. "inner.tar.gz!bin/tool")
+// in SourcePath and are spooled under scratch.
+//
+// name is the filename used for format detection at the current level; parent
+// is the nested path accumulated so far (empty at the outermost level).
+func (m *mgr) traverse(ctx context.Context, name, path string, globs []string, parent, scratch string) ([]extract.ExtractedFile, error) {
+	files, err := m.extractor.Extract(ctx, name, path, globs[:1], scratch)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no entry matched traversal glob %q in %q", globs[0], name)
+	}
+
+	var results []extract.ExtractedFile
+	for _, f := range files {
+		sourcePath := f.SourcePath
+		if parent != "" {
+			sourcePath = parent + traverseSeparator + f.SourcePath
+		}
+
+		if len(globs) == 1 {
+			results = append(results, extract.ExtractedFile{SourcePath: sourcePath, Path: f.Path})
+			continue
+		}
+
+		nested, err := m.traverse(ctx, f.SourcePath, f.Path, globs[1:], sourcePath, scratch)
+		if err != nil {
+			return nil, fmt.Errorf("traverse %q: %w", f.SourcePath, err)
+		}
+		results = append(results, nested...)
+	}
+	return results, nil
+}
+
+// selectTarget decides what to install from an automatically selected asset
+// spooled at p, and records it in w. The asset's entries are only listed,
+// not extracted. An archive's executable becomes the traversal glob; a
+// plain-compressed executable needs no glob, since it is decompressed on
+// install; an uncompressed executable is installed as-is. Files that are not
+// taken from an archive are named after the asset with its platform and
+// version suffix removed.
+func (m *mgr) selectTarget(ctx context.Context, w *specWork, p, project, tag string) error {
+	entries, err := m.extractor.List(ctx, w.asset.Name, p)
+	if err != nil {
+		return fmt.Errorf("inspect %q: %w", w.asset.Name, err)
+	}
+
+	if len(entries) == 1 && entries[0].SourcePath == "" {
+		if executableKind(entries[0].Head) == kindNone {
+			return fmt.Errorf("selected asset %q is not an executable or archive; specify one with --file", w.asset.Name)
+		}
+		if w.spec.LocalName == "" {
+			w.spec.LocalName = binaryName(w.asset.Name)
+		}
+		return nil
+	}
+
+	exe, err := selectExecutable(entries, project)
+	if err != nil {
+		return fmt.Errorf("%q: %w", w.asset.Name, err)
+	}
+	w.spec.TraversalGlobs = []string{templatize(exe.SourcePath, tag)}
+	w.expandedTrav = []string{globEscaper.Replace(exe.SourcePath)}
 	return nil
 }
 
@@ -10,3 +10,9 @@ import (
 func (m *mgr) List(_ context.Context) ([]*manifest.Package, error) {
 	return manifest.LoadAll(m.libDir)
 }
+
+// Info returns the manifest of the installed package id, which records how
+// each of its assets was verified.
+func (m *mgr) Info(_ context.Context, id string) (*manifest.Package, error) {
+	return manifest.Load(id, m.libDir)
+}
@@ -10,23 +10,34 @@ import (
 	"github.com/ventifus/binmgr/pkg/verify"
 )
 
-// Manager orchestrates install, update, status, list, and uninstall operations.
+// Manager orchestrates install, update, status, list, info, and uninstall operations.
 type Manager interface {
 	Install(ctx context.Context, opts InstallOptions) error
 	Update(ctx context.Context, opts UpdateOptions) ([]*UpdateResult, error)
-	Status(ctx context.Context, packages []string) ([]*StatusResult, error)
+	Status(ctx context.Context, opts StatusOptions) ([]*StatusResult, error)
 	List(ctx context.Context) ([]*manifest.Package, error)
+	Info(ctx context.Context, id string) (*manifest.Package, error)
 	Uninstall(ctx context.Context, packages []string) error
+	Rollback(ctx context.Context, opts RollbackOptions) (*RollbackResult, error)
+	Verify(ctx context.Context, opts VerifyOptions) ([]*VerifyResult, error)
 }
 
 // InstallOptions carries parameters for an install operation.
 type InstallOptions struct {
-	SourceURL   string
-	Version     string // from @VERSION suffix; empty = latest
-	Specs       []SpecOpts
-	DefaultDir  string // empty = ~/.local/bin/
-	BackendType string // --type override; empty = auto-detect
-	Pin         bool
+	SourceURL     string
+	Version       string                     // from @VERSION suffix; empty = latest
+	ReleaseID     int64                      // from @id:ID suffix; non-zero overrides Version
+	Channel       *manifest.Channel          // releases considered for latest; nil = stable only
+	Constraint    string                     // version constraint for latest, e.g. "~1.29"; empty = any
+	MinReleaseAge string                     // hold back releases younger than this, e.g. "7d"; empty = global default
+	Provenance    *manifest.ProvenanceConfig // require signed SLSA provenance for each asset; nil = not checked
+	Specs         []SpecOpts
+	DefaultDir    string // empty = ~/.local/bin/
+	BackendType   string // --type override; empty = auto-detect
+	Pin           bool
+	Force         bool // reinstall even if already installed and intact
+
+	DefaultMinReleaseAge string // minimum release age for latest when MinReleaseAge is empty
 }
 
 // SpecOpts describes one file (or set of files) to install from a package.
@@ -39,24 +50,30 @@ type SpecOpts struct {
 
 // ChecksumOpts specifies how to locate and verify checksums for a downloaded asset.
 type ChecksumOpts struct {
-	Strategy      string // "auto"|"none"|"shared-file"|"per-asset"|"multisum"|"embedded"
-	FileGlob      string // shared-file: asset glob; multisum: data file glob
-	OrderGlob     string // multisum: algorithm ordering file glob
-	Suffix        string // per-asset: suffix appended to asset URL
-	TraversalGlob string // embedded: traversal glob inside archive
+	Strategy      string           // "auto"|"none"|"shared-file"|"per-asset"|"multisum"|"embedded"|"sigstore"
+	FileGlob      string           // shared-file: asset glob; multisum: data file glob; sigstore: signed checksum file glob, empty = asset is signed
+	OrderGlob     string           // multisum: algorithm ordering file glob
+	Suffix        string           // per-asset: suffix appended to asset URL
+	TraversalGlob string           // embedded: traversal glob inside archive
+	Signer        *manifest.Signer // sigstore: whose signature is accepted; shared-file, multisum, auto: who must sign the checksum file
 }
 
 // UpdateOptions carries parameters for an update operation.
 type UpdateOptions struct {
-	Packages []PackageTarget // empty = all non-pinned packages
-	Pin      bool
-	Unpin    bool
+	Packages             []PackageTarget // empty = all non-pinned packages
+	Pin                  bool
+	Unpin                bool
+	AllowDowngrade       bool    // install the latest release even if it is older than the installed one
+	Constraint           *string // non-nil: set each package's version constraint first; "" clears it
+	MinReleaseAge        *string // non-nil: set each package's minimum release age first; "" clears it
+	DefaultMinReleaseAge string  // for packages without a minimum release age of their own
 }
 
 // PackageTarget identifies a package and optional target version for update.
 type PackageTarget struct {
-	ID      string
-	Version string // empty = latest
+	ID        string
+	Version   string // empty = latest
+	ReleaseID int64  // non-zero = this release by numeric ID
 }
 
 // UpdateResult reports what happened during an update for one package.
@@ -65,15 +82,59 @@ type UpdateResult struct {
 	OldVersion string
 	NewVersion string
 	Updated    bool
+	Downgrade  bool // NewVersion is older than OldVersion; only installed with AllowDowngrade
+}
+
+// RollbackOptions carries parameters for a rollback operation.
+type RollbackOptions struct {
+	ID      string
+	Version string // empty = most recent previous version
+}
+
+// RollbackResult reports the versions a package was rolled back between.
+type RollbackResult struct {
+	ID         string
+	OldVersion string
+	NewVersion string
+}
+
+// VerifyOptions carries parameters for a verify operation.
+type VerifyOptions struct {
+	Packages []string // empty = all installed packages
+	Repair   bool
+}
+
+// VerifyResult reports the installed files of one package that no longer
+// match its manifest.
+type VerifyResult struct {
+	ID        string
+	Problems  []FileProblem // empty if every file matches
+	Repaired  bool          // Problems were fixed by re-downloading
+	RepairErr error         // why Problems could not be repaired; nil if not attempted or repaired
+}
+
+// FileProblem describes one installed file that failed verification.
+type FileProblem struct {
+	LocalPath string
+	Problem   string // FileMissing | FileModified | FileNotExecutable
+}
+
+// StatusOptions carries parameters for a status operation.
+type StatusOptions struct {
+	Packages             []string // empty = all installed packages
+	DefaultMinReleaseAge string   // for packages without a minimum release age of their own
 }
 
 // StatusResult reports the current and available versions for one package.
 type StatusResult struct {
 	ID               string
 	InstalledVersion string
-	LatestVersion    string
+	LatestVersion    string // highest release allowed by the package's constraint
+	LatestOverall    string // highest release ignoring the constraint; empty without one
+	HeldBack         string // newer release younger than the minimum release age
+	Change           string // "" if up to date; VersionNewer | VersionOlder | VersionDifferent
 	Pinned           bool
-	UpdateAvailable  bool
+	UpdateAvailable  bool // Change is VersionNewer or VersionDifferent
 }
 
 type mgr struct {
@@ -104,3 +165,5 @@ func New(
 // Install is implemented in install.go.
 // Update is implemented in update.go.
 // Status is implemented in status.go.
+// Rollback is implemented in history.go.
+// Verify is implemented in verify.go.
@@ -1,13 +1,19 @@
 package manager
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/ventifus/binmgr/pkg/backend"
 	"github.com/ventifus/binmgr/pkg/extract"
@@ -16,7 +22,8 @@ import (
 
 // ========== Mock implementations ==========
 
-// MockFetcher is a configurable mock for fetch.Fetcher.
+// MockFetcher is a configurable mock for fetch.Fetcher. Open is served from
+// FetchFn so tests only need to describe the bytes behind each URL.
 type MockFetcher struct {
 	FetchFn func(ctx context.Context, url string) ([]byte, error)
 }
@@ -25,26 +32,113 @@ func (m *MockFetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
 	return m.FetchFn(ctx, url)
 }
 
-// MockExtractor is a configurable mock for extract.Extractor.
+func (m *MockFetcher) Open(ctx context.Context, url string) (io.ReadCloser, error) {
+	data, err := m.FetchFn(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// mockEntry is one file returned by a MockExtractor, held in memory.
+type mockEntry struct {
+	SourcePath string
+	Data       []byte
+}
+
+// MockExtractor is a configurable mock for extract.Extractor. ExtractFn
+// receives the archive's bytes and returns in-memory entries, which the mock
+// writes into the scratch directory. DecompressFn, if set, returns the
+// payload of a plain compressed asset; otherwise every asset is taken to be
+// uncompressed.
 type MockExtractor struct {
-	ExtractFn func(ctx context.Context, name string, data []byte, globs []string) ([]extract.ExtractedFile, error)
+	ExtractFn    func(ctx context.Context, name string, data []byte, globs []string) ([]mockEntry, error)
+	DecompressFn func(ctx context.Context, name string, data []byte) ([]byte, error)
+}
+
+// List lists what ExtractFn returns with no globs.
+func (m *MockExtractor) List(ctx context.Context, name, path string) ([]extract.Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := m.ExtractFn(ctx, name, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]extract.Entry, len(entries))
+	for i, e := range entries {
+		list[i] = extract.Entry{SourcePath: e.SourcePath, Head: e.Data[:min(len(e.Data), 16)]}
+	}
+	return list, nil
+}
+
+func (m *MockExtractor) Decompress(ctx context.Context, name, path string, dir string) (string, error) {
+	if m.DecompressFn == nil {
+		return path, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	payload, err := m.DecompressFn(ctx, name, data)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(dir, "mock-decompress-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(payload)
+	f.Close()
+	return f.Name(), err
 }
 
-func (m *MockExtractor) Extract(ctx context.Context, name string, data []byte, globs []string) ([]extract.ExtractedFile, error) {
-	return m.ExtractFn(ctx, name, data, globs)
+func (m *MockExtractor) Extract(ctx context.Context, name, path string, globs []string, dir string) ([]extract.ExtractedFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := m.ExtractFn(ctx, name, data, globs)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]extract.ExtractedFile, 0, len(entries))
+	for _, e := range entries {
+		f, err := os.CreateTemp(dir, "mock-extract-*")
+		if err != nil {
+			return nil, err
+		}
+		_, err = f.Write(e.Data)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, extract.ExtractedFile{SourcePath: e.SourcePath, Path: f.Name()})
+	}
+	return files, nil
 }
 
-// MockVerifier is a configurable mock for verify.Verifier.
+// MockVerifier is a configurable mock for verify.Verifier. Streams are read
+// into memory before being handed to VerifyFn / ComputeFn.
 type MockVerifier struct {
 	VerifyFn  func(ctx context.Context, data []byte, expected map[string]string) error
 	ComputeFn func(ctx context.Context, data []byte, algorithms []string) (map[string]string, error)
 }
 
-func (m *MockVerifier) Verify(ctx context.Context, data []byte, expected map[string]string) error {
+func (m *MockVerifier) Verify(ctx context.Context, r io.Reader, expected map[string]string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
 	return m.VerifyFn(ctx, data, expected)
 }
 
-func (m *MockVerifier) Compute(ctx context.Context, data []byte, algorithms []string) (map[string]string, error) {
+func (m *MockVerifier) Compute(ctx context.Context, r io.Reader, algorithms []string) (map[string]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
 	return m.ComputeFn(ctx, data, algorithms)
 }
 
@@ -87,6 +181,23 @@ func writeManifest(t *testing.T, dir string, pkg *manifest.Package) {
 	}
 }
 
+// manifestFiles lists the manifest files in libDir, skipping subdirectories
+// such as the version history.
+func manifestFiles(t *testing.T, libDir string) []os.DirEntry {
+	t.Helper()
+	entries, err := os.ReadDir(libDir)
+	if err != nil {
+		t.Fatalf("read libDir: %v", err)
+	}
+	var files []os.DirEntry
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			files = append(files, e)
+		}
+	}
+	return files
+}
+
 // newTestManager creates a manager wired to a fake registry and returns it
 // along with the manifest lib directory (which is HOME/.local/share/binmgr/).
 func newTestManager(t *testing.T) (Manager, string) {
@@ -159,6 +270,25 @@ func TestList_EmptyWhenNoneInstalled(t *testing.T) {
 	}
 }
 
+// ========== Info tests ==========
+
+func TestInfo_ReturnsPackage(t *testing.T) {
+	mgr, libDir := newTestManager(t)
+	writeManifest(t, libDir, &manifest.Package{ID: "tool-a", Backend: "github", Version: "v1.0.0"})
+
+	pkg, err := mgr.Info(context.Background(), "tool-a")
+	if err != nil {
+		t.Fatalf("Info returned error: %v", err)
+	}
+	if pkg.Version != "v1.0.0" {
+		t.Errorf("Version = %q, want v1.0.0", pkg.Version)
+	}
+
+	if _, err := mgr.Info(context.Background(), "tool-b"); err == nil {
+		t.Error("expected error for a package that is not installed")
+	}
+}
+
 // ========== Uninstall tests ==========
 
 func TestUninstall_DeletesFilesAndManifest(t *testing.T) {
@@ -298,8 +428,8 @@ func defaultCompute(ctx context.Context, data []byte, algorithms []string) (map[
 }
 
 // noExtract returns the input as a single file with an empty SourcePath.
-func noExtract(ctx context.Context, name string, data []byte, globs []string) ([]extract.ExtractedFile, error) {
-	return []extract.ExtractedFile{{SourcePath: "", Data: data}}, nil
+func noExtract(ctx context.Context, name string, data []byte, globs []string) ([]mockEntry, error) {
+	return []mockEntry{{SourcePath: "", Data: data}}, nil
 }
 
 // TestInstall_NoneChecksum verifies that strategy "none" skips Verify but still
@@ -372,6 +502,70 @@ func TestInstall_NoneChecksum(t *testing.T) {
 	}
 }
 
+// TestInstall_MinReleaseAge verifies that installing the latest release
+// passes the package's minimum release age, or the global default, to the
+// backend, and that an explicit version is not held back.
+func TestInstall_MinReleaseAge(t *testing.T) {
+	tests := []struct {
+		name       string
+		version    string
+		age        string
+		defaultAge string
+		want       time.Duration
+	}{
+		{name: "flag", age: "7d", want: 7 * 24 * time.Hour},
+		{name: "config default", defaultAge: "36h", want: 36 * time.Hour},
+		{name: "flag over default", age: "2d", defaultAge: "36h", want: 48 * time.Hour},
+		{name: "none", want: 0},
+		{name: "explicit version", version: "v1.0.0", age: "7d", defaultAge: "36h", want: 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fetcher := &MockFetcher{
+				FetchFn: func(ctx context.Context, u string) ([]byte, error) {
+					return []byte("binary-content"), nil
+				},
+			}
+			verifier := &MockVerifier{ComputeFn: defaultCompute}
+			resolution := &backend.Resolution{
+				Version: "v1.0.0",
+				Assets: []backend.Asset{
+					{Name: "mytool-linux-amd64", URL: "https://example.com/mytool-linux-amd64"},
+				},
+			}
+			m, _ := newInstallManager(t, fetcher, &MockExtractor{ExtractFn: noExtract}, verifier, "github", resolution)
+
+			var gotOpts backend.ResolveOptions
+			reg := backend.NewRegistry()
+			reg.Register(&MockBackend{
+				TypeFn:      func() string { return "github" },
+				CanHandleFn: func(u *url.URL) bool { return true },
+				ResolveFn: func(ctx context.Context, sourceURL *url.URL, opts backend.ResolveOptions) (*backend.Resolution, error) {
+					gotOpts = opts
+					return resolution, nil
+				},
+			})
+			m.(*mgr).registry = reg
+
+			err := m.Install(context.Background(), InstallOptions{
+				SourceURL:            "https://example.com/owner/mytool",
+				Version:              tc.version,
+				MinReleaseAge:        tc.age,
+				DefaultMinReleaseAge: tc.defaultAge,
+				Specs: []SpecOpts{
+					{AssetGlob: "mytool-linux-amd64", LocalName: "mytool", Checksum: ChecksumOpts{Strategy: "none"}},
+				},
+			})
+			if err != nil {
+				t.Fatalf("Install returned error: %v", err)
+			}
+			if gotOpts.MinReleaseAge != tc.want {
+				t.Errorf("ResolveOptions.MinReleaseAge = %v, want %v", gotOpts.MinReleaseAge, tc.want)
+			}
+		})
+	}
+}
+
 // TestInstall_Deduplication verifies that two specs sharing the same expanded
 // AssetGlob result in only one Fetch call.
 func TestInstall_Deduplication(t *testing.T) {
@@ -389,14 +583,14 @@ func TestInstall_Deduplication(t *testing.T) {
 	}
 	extractCount := 0
 	extractor := &MockExtractor{
-		ExtractFn: func(ctx context.Context, name string, data []byte, globs []string) ([]extract.ExtractedFile, error) {
+		ExtractFn: func(ctx context.Context, name string, data []byte, globs []string) ([]mockEntry, error) {
 			extractCount++
 			// Return one file named after the first glob.
 			sourcePath := "bin"
 			if len(globs) > 0 {
 				sourcePath = globs[0]
 			}
-			return []extract.ExtractedFile{{SourcePath: sourcePath, Data: data}}, nil
+			return []mockEntry{{SourcePath: sourcePath, Data: data}}, nil
 		},
 	}
 
@@ -562,7 +756,7 @@ func TestInstall_VersionExpansionUnexpandedInManifest(t *testing.T) {
 
 	// Read the manifest and verify AssetGlob is stored unexpanded.
 	libDir := filepath.Join(home, ".local", "share", "binmgr")
-	entries, _ := os.ReadDir(libDir)
+	entries := manifestFiles(t, libDir)
 	if len(entries) != 1 {
 		t.Fatalf("expected 1 manifest, got %d", len(entries))
 	}
@@ -679,170 +873,843 @@ func TestInstall_LocalNameResolution(t *testing.T) {
 	}
 }
 
-// ========== Update tests ==========
-
-// newUpdateManager creates a manager with a mock backend whose Check function
-// returns checkResolution and whose Resolve function returns resolveResolution.
-// It also writes the provided manifest into the libDir so Update can load it.
-// The installed binary is created at binPath so Install can overwrite it.
-func newUpdateManager(
-	t *testing.T,
-	pkg *manifest.Package,
-	binPath string,
-	checkResolution *backend.Resolution,
-	resolveResolution *backend.Resolution,
-) (Manager, string) {
-	t.Helper()
-
-	home := t.TempDir()
-	t.Setenv("HOME", home)
-
+// TestInstall_NestedTraversal verifies that each traversal glob descends one
+// archive layer: the first glob is applied to the outer archive, the second to
+// each entry it matched, and the manifest records the full nested path.
+func TestInstall_NestedTraversal(t *testing.T) {
 	fetcher := &MockFetcher{
 		FetchFn: func(ctx context.Context, u string) ([]byte, error) {
-			return []byte("binary-content"), nil
+			return []byte("outer-zip"), nil
 		},
 	}
-	extractor := &MockExtractor{ExtractFn: noExtract}
 	verifier := &MockVerifier{
 		VerifyFn:  func(ctx context.Context, data []byte, expected map[string]string) error { return nil },
 		ComputeFn: defaultCompute,
 	}
 
-	mb := &MockBackend{
-		TypeFn: func() string { return pkg.Backend },
-		CanHandleFn: func(u *url.URL) bool {
-			return true
-		},
-		ResolveFn: func(ctx context.Context, sourceURL *url.URL, opts backend.ResolveOptions) (*backend.Resolution, error) {
-			return resolveResolution, nil
+	type extractCall struct {
+		name  string
+		data  string
+		globs []string
+	}
+	var calls []extractCall
+	extractor := &MockExtractor{
+		ExtractFn: func(ctx context.Context, name string, data []byte, globs []string) ([]mockEntry, error) {
+			calls = append(calls, extractCall{name: name, data: string(data), globs: globs})
+			switch name {
+			case "tool-linux-amd64.zip":
+				return []mockEntry{{SourcePath: "dist/tool.tar.gz", Data: []byte("inner-tgz")}}, nil
+			case "dist/tool.tar.gz":
+				return []mockEntry{{SourcePath: "bin/tool", Data: []byte("tool-binary")}}, nil
+			default:
+				return nil, fmt.Errorf("unexpected extract of %q", name)
+			}
 		},
-		CheckFn: func(ctx context.Context, p *manifest.Package) (*backend.Resolution, error) {
-			return checkResolution, nil
+	}
+
+	resolution := &backend.Resolution{
+		Version: "v1.0.0",
+		Assets: []backend.Asset{
+			{Name: "tool-linux-amd64.zip", URL: "https://example.com/tool-linux-amd64.zip"},
 		},
 	}
 
-	reg := backend.NewRegistry()
-	reg.Register(mb)
+	m, home := newInstallManager(t, fetcher, extractor, verifier, "github", resolution)
 
-	libDir := filepath.Join(home, ".local", "share", "binmgr")
-	if err := os.MkdirAll(libDir, 0700); err != nil {
-		t.Fatalf("create libDir: %v", err)
+	opts := InstallOptions{
+		SourceURL: "https://example.com/owner/tool",
+		Specs: []SpecOpts{
+			{
+				AssetGlob:      "tool-linux-amd64.zip",
+				TraversalGlobs: []string{"*.tar.gz", "tool"},
+				Checksum:       ChecksumOpts{Strategy: "none"},
+			},
+		},
 	}
 
-	// If a binPath is specified, update the package's InstalledFiles to point there
-	// and create a placeholder file.
-	if binPath != "" {
-		if err := os.MkdirAll(filepath.Dir(binPath), 0755); err != nil {
-			t.Fatalf("create bin dir: %v", err)
-		}
-		if err := os.WriteFile(binPath, []byte("old-binary"), 0755); err != nil {
-			t.Fatalf("create installed binary: %v", err)
-		}
-		// Patch the first spec's InstalledFiles to the provided binPath.
-		if len(pkg.Specs) > 0 {
-			pkg.Specs[0].InstalledFiles = []manifest.InstalledFile{
-				{LocalPath: binPath, Checksums: map[string]string{}},
-			}
-		}
+	ctx := context.Background()
+	if err := m.Install(ctx, opts); err != nil {
+		t.Fatalf("Install returned error: %v", err)
 	}
 
-	writeManifest(t, libDir, pkg)
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 Extract calls (one per layer), got %d", len(calls))
+	}
+	if calls[0].data != "outer-zip" || len(calls[0].globs) != 1 || calls[0].globs[0] != "*.tar.gz" {
+		t.Errorf("first Extract call = %+v, want outer data with glob *.tar.gz", calls[0])
+	}
+	if calls[1].data != "inner-tgz" || len(calls[1].globs) != 1 || calls[1].globs[0] != "tool" {
+		t.Errorf("second Extract call = %+v, want inner data with glob tool", calls[1])
+	}
 
-	m := New(reg, fetcher, extractor, verifier, libDir)
-	return m, home
-}
+	installedPath := filepath.Join(home, ".local", "bin", "tool")
+	got, err := os.ReadFile(installedPath)
+	if err != nil {
+		t.Fatalf("installed file not found at %q: %v", installedPath, err)
+	}
+	if string(got) != "tool-binary" {
+		t.Errorf("installed content = %q, want %q", got, "tool-binary")
+	}
 
-// TestUpdate_NewVersionInstalled verifies that when the backend reports a new
-// version, Update calls Install and returns Updated=true.
-func TestUpdate_NewVersionInstalled(t *testing.T) {
-	binDir := t.TempDir()
-	binPath := filepath.Join(binDir, "mytool")
+	libDir := filepath.Join(home, ".local", "share", "binmgr")
+	pkg, err := manifest.Load("example.com/owner/tool", libDir)
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	wantSource := "dist/tool.tar.gz!bin/tool"
+	if gotSource := pkg.Specs[0].InstalledFiles[0].SourcePath; gotSource != wantSource {
+		t.Errorf("SourcePath = %q, want %q", gotSource, wantSource)
+	}
+}
 
-	pkg := &manifest.Package{
-		ID:        "example.com/owner/mytool",
-		Backend:   "github",
-		SourceURL: "https://example.com/owner/mytool",
-		Version:   "v1.0.0",
-		Specs: []manifest.InstallSpec{
-			{
-				AssetGlob: "mytool-linux-amd64",
-				LocalName: "mytool",
-				Checksum:  manifest.ChecksumConfig{Strategy: "none"},
-			},
+// TestInstall_NestedTraversalNoMatch verifies that a traversal level which
+// matches nothing is reported as an error rather than installing nothing.
+func TestInstall_NestedTraversalNoMatch(t *testing.T) {
+	fetcher := &MockFetcher{
+		FetchFn: func(ctx context.Context, u string) ([]byte, error) {
+			return []byte("outer-zip"), nil
 		},
 	}
-
-	checkResolution := &backend.Resolution{
-		Version: "v1.1.0",
-		Assets: []backend.Asset{
-			{Name: "mytool-linux-amd64", URL: "https://example.com/mytool-linux-amd64"},
+	verifier := &MockVerifier{
+		VerifyFn:  func(ctx context.Context, data []byte, expected map[string]string) error { return nil },
+		ComputeFn: defaultCompute,
+	}
+	extractor := &MockExtractor{
+		ExtractFn: func(ctx context.Context, name string, data []byte, globs []string) ([]mockEntry, error) {
+			if name == "tool.zip" {
+				return []mockEntry{{SourcePath: "tool.tar.gz", Data: []byte("inner")}}, nil
+			}
+			return nil, nil
 		},
 	}
-	resolveResolution := &backend.Resolution{
-		Version: "v1.1.0",
+
+	resolution := &backend.Resolution{
+		Version: "v1.0.0",
 		Assets: []backend.Asset{
-			{Name: "mytool-linux-amd64", URL: "https://example.com/mytool-linux-amd64"},
+			{Name: "tool.zip", URL: "https://example.com/tool.zip"},
 		},
 	}
 
-	m, _ := newUpdateManager(t, pkg, binPath, checkResolution, resolveResolution)
+	m, _ := newInstallManager(t, fetcher, extractor, verifier, "github", resolution)
 
-	ctx := context.Background()
-	results, err := m.Update(ctx, UpdateOptions{})
-	if err != nil {
-		t.Fatalf("Update returned error: %v", err)
+	opts := InstallOptions{
+		SourceURL: "https://example.com/owner/tool",
+		Specs: []SpecOpts{
+			{
+				AssetGlob:      "tool.zip",
+				TraversalGlobs: []string{"*.tar.gz", "missing"},
+				Checksum:       ChecksumOpts{Strategy: "none"},
+			},
+		},
 	}
 
-	if len(results) != 1 {
-		t.Fatalf("expected 1 result, got %d", len(results))
+	if err := m.Install(context.Background(), opts); err == nil {
+		t.Fatal("expected error when inner traversal glob matches nothing, got nil")
 	}
-	r := results[0]
-	if !r.Updated {
-		t.Errorf("expected Updated=true, got false")
+}
+
+// TestInstall_VerifiesStreamedDownload verifies that the asset is checked
+// against its resolved checksum as it is downloaded, and that a mismatch
+// leaves nothing installed.
+func TestInstall_VerifiesStreamedDownload(t *testing.T) {
+	assetData := []byte("binary-content")
+	fetcher := &MockFetcher{
+		FetchFn: func(ctx context.Context, u string) ([]byte, error) {
+			if u == "https://example.com/SHA256SUMS" {
+				return []byte("abc123  mytool-linux-amd64\n"), nil
+			}
+			return assetData, nil
+		},
 	}
-	if r.OldVersion != "v1.0.0" {
-		t.Errorf("OldVersion = %q, want %q", r.OldVersion, "v1.0.0")
+	var verified []byte
+	verifier := &MockVerifier{
+		VerifyFn: func(ctx context.Context, data []byte, expected map[string]string) error {
+			verified = data
+			if expected["sha-256"] != "abc123" {
+				t.Errorf("expected sha-256 abc123, got %v", expected)
+			}
+			return fmt.Errorf("sha-256 mismatch")
+		},
+		ComputeFn: defaultCompute,
 	}
-	if r.NewVersion != "v1.1.0" {
-		t.Errorf("NewVersion = %q, want %q", r.NewVersion, "v1.1.0")
+	extractor := &MockExtractor{ExtractFn: noExtract}
+
+	resolution := &backend.Resolution{
+		Version: "v1.0.0",
+		Assets: []backend.Asset{
+			{Name: "mytool-linux-amd64", URL: "https://example.com/mytool-linux-amd64"},
+			{Name: "SHA256SUMS", URL: "https://example.com/SHA256SUMS"},
+		},
 	}
-}
 
-// TestUpdate_SameVersionSkipped verifies that when the backend reports the same
-// version, Update does not call Install and returns Updated=false.
-func TestUpdate_SameVersionSkipped(t *testing.T) {
-	binDir := t.TempDir()
-	binPath := filepath.Join(binDir, "mytool")
+	m, home := newInstallManager(t, fetcher, extractor, verifier, "github", resolution)
 
-	pkg := &manifest.Package{
-		ID:        "example.com/owner/mytool",
-		Backend:   "github",
+	opts := InstallOptions{
 		SourceURL: "https://example.com/owner/mytool",
-		Version:   "v1.0.0",
-		Specs: []manifest.InstallSpec{
+		Specs: []SpecOpts{
 			{
 				AssetGlob: "mytool-linux-amd64",
 				LocalName: "mytool",
-				Checksum:  manifest.ChecksumConfig{Strategy: "none"},
+				Checksum:  ChecksumOpts{Strategy: "shared-file", FileGlob: "SHA256SUMS"},
 			},
 		},
 	}
 
-	sameResolution := &backend.Resolution{
-		Version: "v1.0.0",
-		Assets: []backend.Asset{
-			{Name: "mytool-linux-amd64", URL: "https://example.com/mytool-linux-amd64"},
-		},
+	if err := m.Install(context.Background(), opts); err == nil {
+		t.Fatal("expected verification error, got nil")
 	}
+	if string(verified) != string(assetData) {
+		t.Errorf("Verify saw %q, want the asset content %q", verified, assetData)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".local", "bin", "mytool")); !os.IsNotExist(err) {
+		t.Errorf("expected no installed file after verification failure, got stat err: %v", err)
+	}
+}
 
-	fetchCount := 0
-	home := t.TempDir()
-	t.Setenv("HOME", home)
-
+// TestInstall_RecordsChecksumSource verifies that the manifest records where
+// the checksums came from and which strategy "auto" chose.
+func TestInstall_RecordsChecksumSource(t *testing.T) {
 	fetcher := &MockFetcher{
 		FetchFn: func(ctx context.Context, u string) ([]byte, error) {
-			fetchCount++
-			return []byte("binary"), nil
+			if u == "https://example.com/checksums.txt" {
+				return []byte("abc123  mytool-linux-amd64\n"), nil
+			}
+			return []byte("binary-content"), nil
+		},
+	}
+	verifier := &MockVerifier{
+		VerifyFn:  func(ctx context.Context, data []byte, expected map[string]string) error { return nil },
+		ComputeFn: defaultCompute,
+	}
+	resolution := &backend.Resolution{
+		Version: "v1.0.0",
+		Assets: []backend.Asset{
+			{Name: "mytool-linux-amd64", URL: "https://example.com/mytool-linux-amd64"},
+			{Name: "checksums.txt", URL: "https://example.com/checksums.txt"},
+		},
+	}
+	m, home := newInstallManager(t, fetcher, &MockExtractor{ExtractFn: noExtract}, verifier, "github", resolution)
+
+	opts := InstallOptions{
+		SourceURL: "https://example.com/owner/mytool",
+		Specs: []SpecOpts{{
+			AssetGlob: "mytool-linux-amd64",
+			LocalName: "mytool",
+			Checksum:  ChecksumOpts{Strategy: "auto"},
+		}},
+	}
+	if err := m.Install(context.Background(), opts); err != nil {
+		t.Fatalf("Install returned error: %v", err)
+	}
+
+	pkg, err := manifest.Load("example.com/owner/mytool", filepath.Join(home, ".local", "share", "binmgr"))
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	a := pkg.Specs[0].Asset
+	if a.ChecksumSourceURL != "https://example.com/checksums.txt" {
+		t.Errorf("ChecksumSourceURL = %q, want the checksum file", a.ChecksumSourceURL)
+	}
+	if a.ChecksumStrategy != "shared-file" {
+		t.Errorf("ChecksumStrategy = %q, want shared-file", a.ChecksumStrategy)
+	}
+	if !slices.Equal(a.VerifiedAlgorithms, []string{"sha-256"}) {
+		t.Errorf("VerifiedAlgorithms = %v, want [sha-256]", a.VerifiedAlgorithms)
+	}
+}
+
+// TestInstall_AutoChecksumLockedIn verifies that the checksum file "auto"
+// detects is recorded as a versioned shared-file glob, and that updates
+// verify against it even once the heuristic would pick another file.
+func TestInstall_AutoChecksumLockedIn(t *testing.T) {
+	var fetched []string
+	fetcher := &MockFetcher{
+		FetchFn: func(ctx context.Context, u string) ([]byte, error) {
+			fetched = append(fetched, u)
+			switch u {
+			case "https://example.com/mytool_1.0.0_checksums.txt", "https://example.com/mytool_1.1.0_checksums.txt":
+				return []byte("abc123  mytool-linux-amd64\n"), nil
+			case "https://example.com/SHA256SUMS":
+				return []byte("def456  sbom.spdx.json\n"), nil
+			}
+			return []byte("binary-content"), nil
+		},
+	}
+	verifier := &MockVerifier{
+		VerifyFn:  func(ctx context.Context, data []byte, expected map[string]string) error { return nil },
+		ComputeFn: defaultCompute,
+	}
+	resolution := &backend.Resolution{
+		Version: "v1.0.0",
+		Assets: []backend.Asset{
+			{Name: "mytool-linux-amd64", URL: "https://example.com/mytool-linux-amd64"},
+			{Name: "mytool_1.0.0_checksums.txt", URL: "https://example.com/mytool_1.0.0_checksums.txt"},
+		},
+	}
+	m, home := newInstallManager(t, fetcher, &MockExtractor{ExtractFn: noExtract}, verifier, "github", resolution)
+	libDir := filepath.Join(home, ".local", "share", "binmgr")
+
+	opts := InstallOptions{
+		SourceURL: "https://example.com/owner/mytool",
+		Specs: []SpecOpts{{
+			AssetGlob: "mytool-linux-amd64",
+			LocalName: "mytool",
+			Checksum:  ChecksumOpts{Strategy: "auto"},
+		}},
+	}
+	if err := m.Install(context.Background(), opts); err != nil {
+		t.Fatalf("Install returned error: %v", err)
+	}
+	pkg, err := manifest.Load("example.com/owner/mytool", libDir)
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	want := manifest.ChecksumConfig{Strategy: "shared-file", FileGlob: "mytool_${VERSION}_checksums.txt"}
+	if got := pkg.Specs[0].Checksum; !reflect.DeepEqual(got, want) {
+		t.Fatalf("recorded checksum config %+v, want %+v", got, want)
+	}
+
+	// A new release adds a file the heuristic prefers.
+	*resolution = backend.Resolution{
+		Version: "v1.1.0",
+		Assets: []backend.Asset{
+			{Name: "mytool-linux-amd64", URL: "https://example.com/mytool-linux-amd64"},
+			{Name: "SHA256SUMS", URL: "https://example.com/SHA256SUMS"},
+			{Name: "mytool_1.1.0_checksums.txt", URL: "https://example.com/mytool_1.1.0_checksums.txt"},
+		},
+	}
+	fetched = nil
+	if _, err := m.Update(context.Background(), UpdateOptions{}); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if !slices.Contains(fetched, "https://example.com/mytool_1.1.0_checksums.txt") || slices.Contains(fetched, "https://example.com/SHA256SUMS") {
+		t.Errorf("update fetched %v, want the locked-in checksum file only", fetched)
+	}
+}
+
+// TestInstall_EmbeddedChecksumVerifiesSpooledAsset verifies that the
+// "embedded" strategy reads its checksum file out of the spooled download and
+// then verifies that same download.
+func TestInstall_EmbeddedChecksumVerifiesSpooledAsset(t *testing.T) {
+	assetData := []byte("archive-content")
+	fetcher := &MockFetcher{
+		FetchFn: func(ctx context.Context, u string) ([]byte, error) {
+			return assetData, nil
+		},
+	}
+	var verified []byte
+	verifier := &MockVerifier{
+		VerifyFn: func(ctx context.Context, data []byte, expected map[string]string) error {
+			verified = data
+			if expected["sha-256"] != "ffee1122" {
+				t.Errorf("expected sha-256 ffee1122, got %v", expected)
+			}
+			return nil
+		},
+		ComputeFn: defaultCompute,
+	}
+	extractor := &MockExtractor{
+		ExtractFn: func(ctx context.Context, name string, data []byte, globs []string) ([]mockEntry, error) {
+			if string(data) != string(assetData) {
+				t.Errorf("Extract saw %q, want the spooled asset", data)
+			}
+			if globs[0] == "SHA256SUMS" {
+				return []mockEntry{{SourcePath: "SHA256SUMS", Data: []byte("ffee1122  tool.tar.gz\n")}}, nil
+			}
+			return []mockEntry{{SourcePath: "bin/tool", Data: []byte("tool-binary")}}, nil
+		},
+	}
+
+	resolution := &backend.Resolution{
+		Version: "v1.0.0",
+		Assets: []backend.Asset{
+			{Name: "tool.tar.gz", URL: "https://example.com/tool.tar.gz"},
+		},
+	}
+
+	m, home := newInstallManager(t, fetcher, extractor, verifier, "github", resolution)
+
+	opts := InstallOptions{
+		SourceURL: "https://example.com/owner/tool",
+		Specs: []SpecOpts{
+			{
+				AssetGlob:      "tool.tar.gz",
+				TraversalGlobs: []string{"tool"},
+				Checksum:       ChecksumOpts{Strategy: "embedded", TraversalGlob: "SHA256SUMS"},
+			},
+		},
+	}
+
+	if err := m.Install(context.Background(), opts); err != nil {
+		t.Fatalf("Install returned error: %v", err)
+	}
+	if string(verified) != string(assetData) {
+		t.Errorf("Verify saw %q, want the asset content %q", verified, assetData)
+	}
+	got, err := os.ReadFile(filepath.Join(home, ".local", "bin", "tool"))
+	if err != nil {
+		t.Fatalf("read installed file: %v", err)
+	}
+	if string(got) != "tool-binary" {
+		t.Errorf("installed content = %q, want %q", got, "tool-binary")
+	}
+}
+
+// TestInstall_SkipsWhenAlreadyInstalled verifies that reinstalling the same
+// version from the same specs downloads nothing while the installed files are
+// intact, and that --force, a damaged file or a pin change are handled.
+func TestInstall_SkipsWhenAlreadyInstalled(t *testing.T) {
+	fetchCount := 0
+	fetcher := &MockFetcher{
+		FetchFn: func(ctx context.Context, u string) ([]byte, error) {
+			fetchCount++
+			return []byte("binary-content"), nil
+		},
+	}
+	verifier := &MockVerifier{
+		VerifyFn:  func(ctx context.Context, data []byte, expected map[string]string) error { return nil },
+		ComputeFn: contentCompute,
+	}
+	extractor := &MockExtractor{ExtractFn: noExtract}
+
+	resolution := &backend.Resolution{
+		Version: "v1.0.0",
+		Assets: []backend.Asset{
+			{Name: "mytool-linux-amd64", URL: "https://example.com/mytool-linux-amd64"},
+		},
+	}
+
+	m, home := newInstallManager(t, fetcher, extractor, verifier, "github", resolution)
+	binPath := filepath.Join(home, ".local", "bin", "mytool")
+	libDir := filepath.Join(home, ".local", "share", "binmgr")
+
+	opts := InstallOptions{
+		SourceURL: "https://example.com/owner/mytool",
+		Specs: []SpecOpts{
+			{AssetGlob: "mytool-linux-amd64", LocalName: "mytool", Checksum: ChecksumOpts{Strategy: "none"}},
+		},
+	}
+	ctx := context.Background()
+	install := func(opts InstallOptions, wantFetches int) {
+		t.Helper()
+		if err := m.Install(ctx, opts); err != nil {
+			t.Fatalf("Install returned error: %v", err)
+		}
+		if fetchCount != wantFetches {
+			t.Fatalf("fetch count = %d, want %d", fetchCount, wantFetches)
+		}
+	}
+
+	install(opts, 1)
+	install(opts, 1) // intact: skipped
+
+	forced := opts
+	forced.Force = true
+	install(forced, 2)
+
+	if err := os.WriteFile(binPath, []byte("tampered"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	install(opts, 3) // damaged: reinstalled
+	if got, _ := os.ReadFile(binPath); string(got) != "binary-content" {
+		t.Errorf("installed content = %q, want binary-content", got)
+	}
+
+	renamed := opts
+	renamed.Specs = []SpecOpts{
+		{AssetGlob: "mytool-linux-amd64", LocalName: "mytool2", Checksum: ChecksumOpts{Strategy: "none"}},
+	}
+	install(renamed, 4) // different spec: reinstalled
+
+	renamed.Pin = true
+	install(renamed, 4) // pin change alone: recorded without downloading
+	pkg, err := manifest.Load("example.com/owner/mytool", libDir)
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	if !pkg.Pinned {
+		t.Error("expected pin to be recorded for an already-installed package")
+	}
+}
+
+// TestInstall_RollbackOnLaterSpecFailure verifies that when one spec of a
+// multi-spec package cannot be moved into place, files already replaced by
+// earlier specs are restored and no staged temp files are left behind.
+func TestInstall_RollbackOnLaterSpecFailure(t *testing.T) {
+	fetcher := &MockFetcher{
+		FetchFn: func(ctx context.Context, u string) ([]byte, error) {
+			return []byte("archive"), nil
+		},
+	}
+	verifier := &MockVerifier{
+		VerifyFn:  func(ctx context.Context, data []byte, expected map[string]string) error { return nil },
+		ComputeFn: defaultCompute,
+	}
+	extractor := &MockExtractor{
+		ExtractFn: func(ctx context.Context, name string, data []byte, globs []string) ([]mockEntry, error) {
+			return []mockEntry{{SourcePath: globs[0], Data: []byte("new-" + globs[0])}}, nil
+		},
+	}
+
+	resolution := &backend.Resolution{
+		Version: "v2.0.0",
+		Assets: []backend.Asset{
+			{Name: "tool.tar.gz", URL: "https://example.com/tool.tar.gz"},
+		},
+	}
+
+	m, home := newInstallManager(t, fetcher, extractor, verifier, "github", resolution)
+
+	binDir := filepath.Join(home, ".local", "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	toolPath := filepath.Join(binDir, "tool")
+	if err := os.WriteFile(toolPath, []byte("old-tool"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A directory where the second file should go cannot be replaced, so
+	// commit fails after the first file has already been replaced.
+	libPath := filepath.Join(binDir, "libtool.so")
+	if err := os.MkdirAll(libPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := InstallOptions{
+		SourceURL: "https://example.com/owner/tool",
+		Specs: []SpecOpts{
+			{AssetGlob: "tool.tar.gz", TraversalGlobs: []string{"tool"}, Checksum: ChecksumOpts{Strategy: "none"}},
+			{AssetGlob: "tool.tar.gz", TraversalGlobs: []string{"libtool.so"}, Checksum: ChecksumOpts{Strategy: "none"}},
+		},
+	}
+
+	if err := m.Install(context.Background(), opts); err == nil {
+		t.Fatal("expected error when second file cannot be replaced, got nil")
+	}
+
+	got, err := os.ReadFile(toolPath)
+	if err != nil {
+		t.Fatalf("read %q: %v", toolPath, err)
+	}
+	if string(got) != "old-tool" {
+		t.Errorf("tool content = %q, want previous version %q restored", got, "old-tool")
+	}
+
+	entries, err := os.ReadDir(binDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != "tool" && e.Name() != "libtool.so" {
+			t.Errorf("unexpected leftover file %q in %q", e.Name(), binDir)
+		}
+	}
+
+	libDir := filepath.Join(home, ".local", "share", "binmgr")
+	if _, err := manifest.Load("example.com/owner/tool", libDir); err == nil {
+		t.Error("expected no manifest to be saved after a failed install")
+	}
+}
+
+// TestInstall_ReplacesExistingFileAtomically verifies that a successful
+// install overwrites the previous file and cleans up its staging files.
+func TestInstall_ReplacesExistingFileAtomically(t *testing.T) {
+	fetcher := &MockFetcher{
+		FetchFn: func(ctx context.Context, u string) ([]byte, error) {
+			return []byte("new-binary"), nil
+		},
+	}
+	verifier := &MockVerifier{
+		VerifyFn:  func(ctx context.Context, data []byte, expected map[string]string) error { return nil },
+		ComputeFn: defaultCompute,
+	}
+	extractor := &MockExtractor{ExtractFn: noExtract}
+
+	resolution := &backend.Resolution{
+		Version: "v2.0.0",
+		Assets: []backend.Asset{
+			{Name: "mytool-linux-amd64", URL: "https://example.com/mytool-linux-amd64"},
+		},
+	}
+
+	m, home := newInstallManager(t, fetcher, extractor, verifier, "github", resolution)
+
+	binDir := filepath.Join(home, ".local", "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	toolPath := filepath.Join(binDir, "mytool")
+	if err := os.WriteFile(toolPath, []byte("old-binary-with-longer-content"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := InstallOptions{
+		SourceURL: "https://example.com/owner/mytool",
+		Specs: []SpecOpts{
+			{AssetGlob: "mytool-linux-amd64", LocalName: "mytool", Checksum: ChecksumOpts{Strategy: "none"}},
+		},
+	}
+
+	if err := m.Install(context.Background(), opts); err != nil {
+		t.Fatalf("Install returned error: %v", err)
+	}
+
+	got, err := os.ReadFile(toolPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "new-binary" {
+		t.Errorf("installed content = %q, want %q", got, "new-binary")
+	}
+	info, err := os.Stat(toolPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("installed mode = %v, want 0755", info.Mode().Perm())
+	}
+
+	entries, err := os.ReadDir(binDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("expected only the installed file in %q, got %v", binDir, names)
+	}
+}
+
+// ========== Update tests ==========
+
+// newUpdateManager creates a manager with a mock backend whose Check function
+// returns checkResolution and whose Resolve function returns resolveResolution.
+// It also writes the provided manifest into the libDir so Update can load it.
+// The installed binary is created at binPath so Install can overwrite it.
+func newUpdateManager(
+	t *testing.T,
+	pkg *manifest.Package,
+	binPath string,
+	checkResolution *backend.Resolution,
+	resolveResolution *backend.Resolution,
+) (Manager, string) {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	fetcher := &MockFetcher{
+		FetchFn: func(ctx context.Context, u string) ([]byte, error) {
+			return []byte("binary-content"), nil
+		},
+	}
+	extractor := &MockExtractor{ExtractFn: noExtract}
+	verifier := &MockVerifier{
+		VerifyFn:  func(ctx context.Context, data []byte, expected map[string]string) error { return nil },
+		ComputeFn: defaultCompute,
+	}
+
+	mb := &MockBackend{
+		TypeFn: func() string { return pkg.Backend },
+		CanHandleFn: func(u *url.URL) bool {
+			return true
+		},
+		ResolveFn: func(ctx context.Context, sourceURL *url.URL, opts backend.ResolveOptions) (*backend.Resolution, error) {
+			return resolveResolution, nil
+		},
+		CheckFn: func(ctx context.Context, p *manifest.Package) (*backend.Resolution, error) {
+			return checkResolution, nil
+		},
+	}
+
+	reg := backend.NewRegistry()
+	reg.Register(mb)
+
+	libDir := filepath.Join(home, ".local", "share", "binmgr")
+	if err := os.MkdirAll(libDir, 0700); err != nil {
+		t.Fatalf("create libDir: %v", err)
+	}
+
+	// If a binPath is specified, update the package's InstalledFiles to point there
+	// and create a placeholder file.
+	if binPath != "" {
+		if err := os.MkdirAll(filepath.Dir(binPath), 0755); err != nil {
+			t.Fatalf("create bin dir: %v", err)
+		}
+		if err := os.WriteFile(binPath, []byte("old-binary"), 0755); err != nil {
+			t.Fatalf("create installed binary: %v", err)
+		}
+		// Patch the first spec's InstalledFiles to the provided binPath.
+		if len(pkg.Specs) > 0 {
+			pkg.Specs[0].InstalledFiles = []manifest.InstalledFile{
+				{LocalPath: binPath, Checksums: map[string]string{}},
+			}
+		}
+	}
+
+	writeManifest(t, libDir, pkg)
+
+	m := New(reg, fetcher, extractor, verifier, libDir)
+	return m, home
+}
+
+// TestUpdate_NewVersionInstalled verifies that when the backend reports a new
+// version, Update calls Install and returns Updated=true.
+func TestUpdate_NewVersionInstalled(t *testing.T) {
+	binDir := t.TempDir()
+	binPath := filepath.Join(binDir, "mytool")
+
+	pkg := &manifest.Package{
+		ID:        "example.com/owner/mytool",
+		Backend:   "github",
+		SourceURL: "https://example.com/owner/mytool",
+		Version:   "v1.0.0",
+		Specs: []manifest.InstallSpec{
+			{
+				AssetGlob: "mytool-linux-amd64",
+				LocalName: "mytool",
+				Checksum:  manifest.ChecksumConfig{Strategy: "none"},
+			},
+		},
+	}
+
+	checkResolution := &backend.Resolution{
+		Version: "v1.1.0",
+		Assets: []backend.Asset{
+			{Name: "mytool-linux-amd64", URL: "https://example.com/mytool-linux-amd64"},
+		},
+	}
+	resolveResolution := &backend.Resolution{
+		Version: "v1.1.0",
+		Assets: []backend.Asset{
+			{Name: "mytool-linux-amd64", URL: "https://example.com/mytool-linux-amd64"},
+		},
+	}
+
+	m, _ := newUpdateManager(t, pkg, binPath, checkResolution, resolveResolution)
+
+	ctx := context.Background()
+	results, err := m.Update(ctx, UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	r := results[0]
+	if !r.Updated {
+		t.Errorf("expected Updated=true, got false")
+	}
+	if r.OldVersion != "v1.0.0" {
+		t.Errorf("OldVersion = %q, want %q", r.OldVersion, "v1.0.0")
+	}
+	if r.NewVersion != "v1.1.0" {
+		t.Errorf("NewVersion = %q, want %q", r.NewVersion, "v1.1.0")
+	}
+}
+
+// TestUpdate_BackendChosenByType verifies that a package installed with
+// --type is updated through the same backend, even though its source URL
+// is not one the backend recognises on its own.
+func TestUpdate_BackendChosenByType(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	libDir := filepath.Join(home, ".local", "share", "binmgr")
+	binPath := filepath.Join(t.TempDir(), "mytool")
+
+	res := &backend.Resolution{
+		Version: "v1.1.0",
+		Assets: []backend.Asset{
+			{Name: "mytool-linux-amd64", URL: "https://git.example.com/mytool-linux-amd64"},
+		},
+	}
+	reg := backend.NewRegistry()
+	reg.Register(&MockBackend{
+		TypeFn:      func() string { return "gitea" },
+		CanHandleFn: func(u *url.URL) bool { return false },
+		ResolveFn: func(ctx context.Context, sourceURL *url.URL, opts backend.ResolveOptions) (*backend.Resolution, error) {
+			return res, nil
+		},
+		CheckFn: func(ctx context.Context, p *manifest.Package) (*backend.Resolution, error) {
+			return res, nil
+		},
+	})
+	if err := os.MkdirAll(libDir, 0700); err != nil {
+		t.Fatalf("create libDir: %v", err)
+	}
+	writeManifest(t, libDir, &manifest.Package{
+		ID:        "git.example.com/owner/mytool",
+		Backend:   "gitea",
+		SourceURL: "https://git.example.com/owner/mytool",
+		Version:   "v1.0.0",
+		Specs: []manifest.InstallSpec{
+			{
+				AssetGlob:      "mytool-linux-amd64",
+				LocalName:      "mytool",
+				Checksum:       manifest.ChecksumConfig{Strategy: "none"},
+				InstalledFiles: []manifest.InstalledFile{{LocalPath: binPath}},
+			},
+		},
+	})
+
+	fetcher := &MockFetcher{
+		FetchFn: func(ctx context.Context, u string) ([]byte, error) {
+			return []byte("binary-content"), nil
+		},
+	}
+	verifier := &MockVerifier{
+		VerifyFn:  func(ctx context.Context, data []byte, expected map[string]string) error { return nil },
+		ComputeFn: defaultCompute,
+	}
+	m := New(reg, fetcher, &MockExtractor{ExtractFn: noExtract}, verifier, libDir)
+
+	results, err := m.Update(context.Background(), UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if len(results) != 1 || !results[0].Updated {
+		t.Fatalf("expected the package to be updated, got %+v", results)
+	}
+}
+
+// TestUpdate_SameVersionSkipped verifies that when the backend reports the same
+// version, Update does not call Install and returns Updated=false.
+func TestUpdate_SameVersionSkipped(t *testing.T) {
+	binDir := t.TempDir()
+	binPath := filepath.Join(binDir, "mytool")
+
+	pkg := &manifest.Package{
+		ID:        "example.com/owner/mytool",
+		Backend:   "github",
+		SourceURL: "https://example.com/owner/mytool",
+		Version:   "v1.0.0",
+		Specs: []manifest.InstallSpec{
+			{
+				AssetGlob: "mytool-linux-amd64",
+				LocalName: "mytool",
+				Checksum:  manifest.ChecksumConfig{Strategy: "none"},
+			},
+		},
+	}
+
+	sameResolution := &backend.Resolution{
+		Version: "v1.0.0",
+		Assets: []backend.Asset{
+			{Name: "mytool-linux-amd64", URL: "https://example.com/mytool-linux-amd64"},
+		},
+	}
+
+	fetchCount := 0
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	fetcher := &MockFetcher{
+		FetchFn: func(ctx context.Context, u string) ([]byte, error) {
+			fetchCount++
+			return []byte("binary"), nil
 		},
 	}
 	extractor := &MockExtractor{ExtractFn: noExtract}
@@ -1003,7 +1870,7 @@ func TestUpdate_Unpin_ClearsPinBeforeUpdate(t *testing.T) {
 
 	// Reload the manifest and verify the pin was cleared.
 	libDir := filepath.Join(home, ".local", "share", "binmgr")
-	entries, _ := os.ReadDir(libDir)
+	entries := manifestFiles(t, libDir)
 	if len(entries) != 1 {
 		t.Fatalf("expected 1 manifest, got %d", len(entries))
 	}
@@ -1068,7 +1935,7 @@ func TestUpdate_Pin_SetsPinAfterUpdate(t *testing.T) {
 
 	// Reload the manifest and verify the pin was set.
 	libDir := filepath.Join(home, ".local", "share", "binmgr")
-	entries, _ := os.ReadDir(libDir)
+	entries := manifestFiles(t, libDir)
 	if len(entries) != 1 {
 		t.Fatalf("expected 1 manifest, got %d", len(entries))
 	}
@@ -1257,7 +2124,7 @@ func TestStatus_UpdateAvailable(t *testing.T) {
 	m, _ := newStatusManager(t, pkg, checkResolution)
 
 	ctx := context.Background()
-	results, err := m.Status(ctx, nil)
+	results, err := m.Status(ctx, StatusOptions{})
 	if err != nil {
 		t.Fatalf("Status returned error: %v", err)
 	}
@@ -1290,7 +2157,7 @@ func TestStatus_NoUpdateAvailable(t *testing.T) {
 	m, _ := newStatusManager(t, pkg, checkResolution)
 
 	ctx := context.Background()
-	results, err := m.Status(ctx, nil)
+	results, err := m.Status(ctx, StatusOptions{})
 	if err != nil {
 		t.Fatalf("Status returned error: %v", err)
 	}
@@ -1317,7 +2184,7 @@ func TestStatus_PinnedFlagPropagated(t *testing.T) {
 	m, _ := newStatusManager(t, pkg, checkResolution)
 
 	ctx := context.Background()
-	results, err := m.Status(ctx, nil)
+	results, err := m.Status(ctx, StatusOptions{})
 	if err != nil {
 		t.Fatalf("Status returned error: %v", err)
 	}
@@ -1329,3 +2196,368 @@ func TestStatus_PinnedFlagPropagated(t *testing.T) {
 		t.Errorf("expected Pinned=true, got false")
 	}
 }
+
+// TestUpdate_ChannelPreserved verifies that update resolves within the
+// package's release channel and keeps the channel in the new manifest.
+func TestUpdate_ChannelPreserved(t *testing.T) {
+	binDir := t.TempDir()
+	binPath := filepath.Join(binDir, "mytool")
+
+	channel := &manifest.Channel{Prerelease: true, TagGlob: "mytool-v*"}
+	pkg := &manifest.Package{
+		ID:        "example.com/owner/mytool",
+		Backend:   "github",
+		SourceURL: "https://example.com/owner/mytool",
+		Version:   "mytool-v1.0.0",
+		Channel:   channel,
+		Specs: []manifest.InstallSpec{
+			{
+				AssetGlob: "mytool-linux-amd64",
+				LocalName: "mytool",
+				Checksum:  manifest.ChecksumConfig{Strategy: "none"},
+			},
+		},
+	}
+
+	res := &backend.Resolution{
+		Version: "mytool-v1.1.0-rc.1",
+		Assets: []backend.Asset{
+			{Name: "mytool-linux-amd64", URL: "https://example.com/mytool-linux-amd64"},
+		},
+	}
+
+	m, home := newUpdateManager(t, pkg, binPath, res, res)
+
+	if _, err := m.Update(context.Background(), UpdateOptions{}); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+
+	updated, err := manifest.Load(pkg.ID, filepath.Join(home, ".local", "share", "binmgr"))
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	if updated.Version != "mytool-v1.1.0-rc.1" {
+		t.Errorf("Version = %q, want %q", updated.Version, "mytool-v1.1.0-rc.1")
+	}
+	if updated.Channel == nil || *updated.Channel != *channel {
+		t.Errorf("Channel = %+v, want %+v", updated.Channel, channel)
+	}
+}
+
+// TestStatus_RetaggedReleaseNotAnUpdate verifies that a release whose tag
+// changed upstream is recognised by its release ID and not reported as an
+// update.
+func TestStatus_RetaggedReleaseNotAnUpdate(t *testing.T) {
+	pkg := &manifest.Package{
+		ID:        "example.com/owner/mytool",
+		Backend:   "github",
+		Version:   "v1.0.0",
+		ReleaseID: 42,
+	}
+	checkResolution := &backend.Resolution{Version: "1.0.0", ReleaseID: 42}
+
+	m, _ := newStatusManager(t, pkg, checkResolution)
+
+	results, err := m.Status(context.Background(), StatusOptions{})
+	if err != nil {
+		t.Fatalf("Status returned error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if results[0].UpdateAvailable {
+		t.Errorf("expected UpdateAvailable=false for a retagged release, got true")
+	}
+}
+
+// TestUpdate_ByReleaseID verifies that an update targeting a release ID
+// passes the ID to the backend, records it, and reports the resolved tag.
+func TestUpdate_ByReleaseID(t *testing.T) {
+	binDir := t.TempDir()
+	binPath := filepath.Join(binDir, "mytool")
+
+	pkg := &manifest.Package{
+		ID:        "example.com/owner/mytool",
+		Backend:   "github",
+		SourceURL: "https://example.com/owner/mytool",
+		Version:   "v1.0.0",
+		Specs: []manifest.InstallSpec{
+			{
+				AssetGlob: "mytool-linux-amd64",
+				LocalName: "mytool",
+				Checksum:  manifest.ChecksumConfig{Strategy: "none"},
+			},
+		},
+	}
+	checkResolution := &backend.Resolution{Version: "v2.0.0", ReleaseID: 200}
+
+	m, home := newUpdateManager(t, pkg, binPath, checkResolution, nil)
+	libDir := filepath.Join(home, ".local", "share", "binmgr")
+
+	var gotOpts backend.ResolveOptions
+	reg := backend.NewRegistry()
+	reg.Register(&MockBackend{
+		TypeFn:      func() string { return "github" },
+		CanHandleFn: func(u *url.URL) bool { return true },
+		CheckFn: func(ctx context.Context, p *manifest.Package) (*backend.Resolution, error) {
+			return checkResolution, nil
+		},
+		ResolveFn: func(ctx context.Context, sourceURL *url.URL, opts backend.ResolveOptions) (*backend.Resolution, error) {
+			gotOpts = opts
+			return &backend.Resolution{
+				Version:   "v1.5.0",
+				ReleaseID: 150,
+				Assets: []backend.Asset{
+					{Name: "mytool-linux-amd64", URL: "https://example.com/mytool-linux-amd64"},
+				},
+			}, nil
+		},
+	})
+	m.(*mgr).registry = reg
+
+	results, err := m.Update(context.Background(), UpdateOptions{
+		Packages: []PackageTarget{{ID: pkg.ID, ReleaseID: 150}},
+	})
+	if err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if gotOpts.ReleaseID != 150 {
+		t.Errorf("ResolveOptions.ReleaseID = %d, want 150", gotOpts.ReleaseID)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if !results[0].Updated || results[0].NewVersion != "v1.5.0" {
+		t.Errorf("result = %+v, want updated to v1.5.0", results[0])
+	}
+
+	updated, err := manifest.Load(pkg.ID, libDir)
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	if updated.ReleaseID != 150 || updated.Version != "v1.5.0" {
+		t.Errorf("manifest release = %q (ID %d), want v1.5.0 (ID 150)", updated.Version, updated.ReleaseID)
+	}
+}
+
+// TestStatus_ReportsVersionChange verifies how the latest version is
+// reported relative to the installed one.
+func TestStatus_ReportsVersionChange(t *testing.T) {
+	tests := []struct {
+		installed, latest string
+		change            string
+		updateAvailable   bool
+	}{
+		{"v1.0.0", "v1.0.0", "", false},
+		{"v1.9.0", "v1.10.0", VersionNewer, true},
+		{"v1.10.0", "v1.9.5", VersionOlder, false},
+		{"knative-v1.19.5", "client-v1.20.0", VersionDifferent, true},
+		{"3fa9c0d1e2b4a596", "9d8c7b6a5f4e3d2c", VersionDifferent, true},
+	}
+
+	for _, tt := range tests {
+		pkg := &manifest.Package{
+			ID:      "example.com/owner/mytool",
+			Backend: "github",
+			Version: tt.installed,
+		}
+		m, _ := newStatusManager(t, pkg, &backend.Resolution{Version: tt.latest})
+
+		results, err := m.Status(context.Background(), StatusOptions{})
+		if err != nil {
+			t.Fatalf("Status returned error: %v", err)
+		}
+		if len(results) != 1 {
+			t.Fatalf("expected 1 result, got %d", len(results))
+		}
+		r := results[0]
+		if r.Change != tt.change || r.UpdateAvailable != tt.updateAvailable {
+			t.Errorf("%s → %s: Change = %q, UpdateAvailable = %v; want %q, %v",
+				tt.installed, tt.latest, r.Change, r.UpdateAvailable, tt.change, tt.updateAvailable)
+		}
+	}
+}
+
+// TestUpdate_RefusesImplicitDowngrade verifies that an older latest release
+// is not installed unless AllowDowngrade is set.
+func TestUpdate_RefusesImplicitDowngrade(t *testing.T) {
+	for _, allow := range []bool{false, true} {
+		binDir := t.TempDir()
+		binPath := filepath.Join(binDir, "mytool")
+
+		pkg := &manifest.Package{
+			ID:        "example.com/owner/mytool",
+			Backend:   "github",
+			SourceURL: "https://example.com/owner/mytool",
+			Version:   "v1.2.0",
+			Specs: []manifest.InstallSpec{
+				{
+					AssetGlob: "mytool-linux-amd64",
+					LocalName: "mytool",
+					Checksum:  manifest.ChecksumConfig{Strategy: "none"},
+				},
+			},
+		}
+		res := &backend.Resolution{
+			Version: "v1.1.9",
+			Assets: []backend.Asset{
+				{Name: "mytool-linux-amd64", URL: "https://example.com/mytool-linux-amd64"},
+			},
+		}
+
+		m, _ := newUpdateManager(t, pkg, binPath, res, res)
+
+		results, err := m.Update(context.Background(), UpdateOptions{AllowDowngrade: allow})
+		if err != nil {
+			t.Fatalf("Update returned error: %v", err)
+		}
+		if len(results) != 1 {
+			t.Fatalf("expected 1 result, got %d", len(results))
+		}
+		r := results[0]
+		if !r.Downgrade {
+			t.Errorf("AllowDowngrade=%v: expected Downgrade=true", allow)
+		}
+		if r.Updated != allow {
+			t.Errorf("AllowDowngrade=%v: Updated = %v, want %v", allow, r.Updated, allow)
+		}
+	}
+}
+
+// TestUpdate_SetsConstraint verifies that UpdateOptions.Constraint replaces
+// the package's constraint and that the update keeps it in the new manifest.
+func TestUpdate_SetsConstraint(t *testing.T) {
+	binDir := t.TempDir()
+	binPath := filepath.Join(binDir, "mytool")
+
+	pkg := &manifest.Package{
+		ID:        "example.com/owner/mytool",
+		Backend:   "github",
+		SourceURL: "https://example.com/owner/mytool",
+		Version:   "v1.1.0",
+		Specs: []manifest.InstallSpec{
+			{
+				AssetGlob: "mytool-linux-amd64",
+				LocalName: "mytool",
+				Checksum:  manifest.ChecksumConfig{Strategy: "none"},
+			},
+		},
+	}
+	res := &backend.Resolution{
+		Version: "v1.1.5",
+		Latest:  "v2.0.0",
+		Assets: []backend.Asset{
+			{Name: "mytool-linux-amd64", URL: "https://example.com/mytool-linux-amd64"},
+		},
+	}
+
+	m, home := newUpdateManager(t, pkg, binPath, res, res)
+
+	constraint := "~1.1"
+	results, err := m.Update(context.Background(), UpdateOptions{
+		Packages:   []PackageTarget{{ID: pkg.ID}},
+		Constraint: &constraint,
+	})
+	if err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if len(results) != 1 || !results[0].Updated {
+		t.Fatalf("results = %+v, want one update", results)
+	}
+
+	updated, err := manifest.Load(pkg.ID, filepath.Join(home, ".local", "share", "binmgr"))
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	if updated.Version != "v1.1.5" {
+		t.Errorf("Version = %q, want %q", updated.Version, "v1.1.5")
+	}
+	if updated.Constraint != constraint {
+		t.Errorf("Constraint = %q, want %q", updated.Constraint, constraint)
+	}
+
+	bad := "~"
+	if _, err := m.Update(context.Background(), UpdateOptions{
+		Packages:   []PackageTarget{{ID: pkg.ID}},
+		Constraint: &bad,
+	}); err == nil {
+		t.Fatal("expected error for invalid constraint, got nil")
+	}
+}
+
+// TestStatus_ReportsLatestOverall verifies that status reports both the
+// newest release the constraint allows and the newest overall.
+func TestStatus_ReportsLatestOverall(t *testing.T) {
+	pkg := &manifest.Package{
+		ID:         "example.com/owner/mytool",
+		Backend:    "github",
+		Version:    "v1.29.1",
+		Constraint: "~1.29",
+	}
+	checkResolution := &backend.Resolution{Version: "v1.29.3", Latest: "v1.31.0"}
+
+	m, _ := newStatusManager(t, pkg, checkResolution)
+
+	results, err := m.Status(context.Background(), StatusOptions{})
+	if err != nil {
+		t.Fatalf("Status returned error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	r := results[0]
+	if r.LatestVersion != "v1.29.3" || r.LatestOverall != "v1.31.0" {
+		t.Errorf("LatestVersion, LatestOverall = %q, %q, want %q, %q", r.LatestVersion, r.LatestOverall, "v1.29.3", "v1.31.0")
+	}
+	if r.Change != VersionNewer {
+		t.Errorf("Change = %q, want %q", r.Change, VersionNewer)
+	}
+}
+
+// TestStatus_DefaultMinReleaseAge verifies that the global minimum release
+// age reaches the backend only for packages without their own, and that a
+// held-back release is reported.
+func TestStatus_DefaultMinReleaseAge(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	libDir := filepath.Join(home, ".local", "share", "binmgr")
+	if err := os.MkdirAll(libDir, 0700); err != nil {
+		t.Fatalf("create libDir: %v", err)
+	}
+
+	var mu sync.Mutex
+	seen := make(map[string]string) // package ID → MinReleaseAge passed to Check
+	mb := &MockBackend{
+		TypeFn:      func() string { return "github" },
+		CanHandleFn: func(u *url.URL) bool { return true },
+		CheckFn: func(ctx context.Context, p *manifest.Package) (*backend.Resolution, error) {
+			mu.Lock()
+			seen[p.ID] = p.MinReleaseAge
+			mu.Unlock()
+			return &backend.Resolution{Version: "v1.0.0", HeldBack: "v1.1.0"}, nil
+		},
+	}
+	reg := backend.NewRegistry()
+	reg.Register(mb)
+
+	writeManifest(t, libDir, &manifest.Package{ID: "example.com/owner/a", Backend: "github", Version: "v1.0.0"})
+	writeManifest(t, libDir, &manifest.Package{ID: "example.com/owner/b", Backend: "github", Version: "v1.0.0", MinReleaseAge: "0"})
+
+	m := New(reg, &MockFetcher{}, &MockExtractor{}, &MockVerifier{}, libDir)
+	results, err := m.Status(context.Background(), StatusOptions{DefaultMinReleaseAge: "7d"})
+	if err != nil {
+		t.Fatalf("Status returned error: %v", err)
+	}
+
+	if seen["example.com/owner/a"] != "7d" {
+		t.Errorf("package a checked with MinReleaseAge %q, want the default %q", seen["example.com/owner/a"], "7d")
+	}
+	if seen["example.com/owner/b"] != "0" {
+		t.Errorf("package b checked with MinReleaseAge %q, want its own %q", seen["example.com/owner/b"], "0")
+	}
+	for _, r := range results {
+		if r.UpdateAvailable || r.HeldBack != "v1.1.0" {
+			t.Errorf("%s: UpdateAvailable = %v, HeldBack = %q, want no update and %q held back", r.ID, r.UpdateAvailable, r.HeldBack, "v1.1.0")
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/ventifus/binmgr/pkg/backend"
+	"github.com/ventifus/binmgr/pkg/manifest"
+	"github.com/ventifus/binmgr/pkg/verify"
+)
+
+// provenanceSuffix ends the name of an in-toto attestation file.
+const provenanceSuffix = ".intoto.jsonl"
+
+// verifyProvenance fetches the SLSA provenance published for asset and
+// checks it against the spooled download at path: the attestation must name
+// the download's SHA-256, be signed with cfg's key, come from cfg's builder
+// and have been built from sourceURL.
+func (m *mgr) verifyProvenance(ctx context.Context, cfg *manifest.ProvenanceConfig, asset *backend.Asset, path, sourceURL string, resolution *backend.Resolution) (*verify.Provenance, error) {
+	data, err := m.fetchProvenance(ctx, asset, resolution)
+	if err != nil {
+		return nil, fmt.Errorf("provenance for %q: %w", asset.Name, err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sums, err := m.verifier.Compute(ctx, f, []string{"sha-256"})
+	if err != nil {
+		return nil, fmt.Errorf("provenance for %q: %w", asset.Name, err)
+	}
+
+	prov, err := verify.VerifyProvenance(data, sums["sha-256"], verify.ProvenancePolicy{
+		Key:        []byte(cfg.Key),
+		Builder:    cfg.Builder,
+		SourceRepo: sourceURL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("verify provenance for %q: %w", asset.Name, err)
+	}
+	log.WithField("asset", asset.Name).WithField("builder", prov.Builder).WithField("commit", prov.SourceCommit).Info("provenance verified")
+	return prov, nil
+}
+
+// fetchProvenance returns the attestation file for asset: "{asset}.intoto.jsonl"
+// if the release has one, otherwise the release's only "*.intoto.jsonl" file,
+// which generators use to attest to every asset at once.
+func (m *mgr) fetchProvenance(ctx context.Context, asset *backend.Asset, resolution *backend.Resolution) ([]byte, error) {
+	u, ok := siblingURL(resolution, asset.Name+provenanceSuffix)
+	if !ok {
+		var found []string
+		for _, a := range resolution.Assets {
+			if strings.HasSuffix(a.Name, provenanceSuffix) {
+				found = append(found, a.URL)
+			}
+		}
+		switch len(found) {
+		case 0:
+			u = asset.URL + provenanceSuffix
+		case 1:
+			u = found[0]
+		default:
+			return nil, fmt.Errorf("%d %s files in the release and none named after the asset", len(found), provenanceSuffix)
+		}
+	}
+	data, err := m.fetcher.Fetch(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %q: %w", u, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,126 @@
+package manager
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ventifus/binmgr/pkg/backend"
+	"github.com/ventifus/binmgr/pkg/manifest"
+	"github.com/ventifus/binmgr/pkg/verify"
+)
+
+// attest returns an ".intoto.jsonl" line in which s attests that data was
+// built from repo at commit "abc123".
+func (s *testSigner) attest(t *testing.T, data []byte, repo string) []byte {
+	t.Helper()
+	sum := sha256.Sum256(data)
+	payload, err := json.Marshal(map[string]any{
+		"_type":         "https://in-toto.io/Statement/v0.1",
+		"subject":       []any{map[string]any{"name": "tool", "digest": map[string]string{"sha256": hex.EncodeToString(sum[:])}}},
+		"predicateType": "https://slsa.dev/provenance/v0.2",
+		"predicate": map[string]any{
+			"builder":    map[string]string{"id": "https://github.com/example/builder@v1"},
+			"invocation": map[string]any{"configSource": map[string]any{"uri": "git+" + repo + "@refs/tags/v1.0.0", "digest": map[string]string{"sha1": "abc123"}}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const payloadType = "application/vnd.in-toto+json"
+	pae := sha256.Sum256([]byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload)))
+	sig, err := ecdsa.SignASN1(rand.Reader, s.key, pae[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	line, err := json.Marshal(map[string]any{
+		"payloadType": payloadType,
+		"payload":     base64.StdEncoding.EncodeToString(payload),
+		"signatures":  []any{map[string]string{"sig": base64.StdEncoding.EncodeToString(sig)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(line, '\n')
+}
+
+// TestInstall_Provenance verifies that required provenance is checked
+// against the downloaded asset and the package's source, and that the
+// builder and source commit are recorded.
+func TestInstall_Provenance(t *testing.T) {
+	signer := newTestSigner(t)
+	assetData := []byte("tool-binary")
+	resolution := &backend.Resolution{
+		Version: "v1.0.0",
+		Assets: []backend.Asset{
+			{Name: "tool-linux-amd64", URL: "https://example.com/tool-linux-amd64"},
+			{Name: "multiple.intoto.jsonl", URL: "https://example.com/multiple.intoto.jsonl"},
+		},
+	}
+	verifier := &MockVerifier{ComputeFn: func(ctx context.Context, data []byte, algorithms []string) (map[string]string, error) {
+		return verify.NewVerifier().Compute(ctx, strings.NewReader(string(data)), algorithms)
+	}}
+	opts := InstallOptions{
+		SourceURL:  "https://example.com/owner/tool",
+		Provenance: &manifest.ProvenanceConfig{Key: signer.signer(t).Key, Builder: "https://github.com/example/builder"},
+		Specs: []SpecOpts{{
+			AssetGlob: "tool-linux-amd64",
+			LocalName: "tool",
+			Checksum:  ChecksumOpts{Strategy: "none"},
+		}},
+	}
+
+	tests := []struct {
+		name        string
+		attestation []byte
+		wantErr     bool
+	}{
+		{"valid", signer.attest(t, assetData, "https://example.com/owner/tool"), false},
+		{"other artifact", signer.attest(t, []byte("other-binary"), "https://example.com/owner/tool"), true},
+		{"other source", signer.attest(t, assetData, "https://example.com/evil/tool"), true},
+		{"other signer", newTestSigner(t).attest(t, assetData, "https://example.com/owner/tool"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := &MockFetcher{FetchFn: func(ctx context.Context, u string) ([]byte, error) {
+				if strings.HasSuffix(u, ".intoto.jsonl") {
+					return tt.attestation, nil
+				}
+				return assetData, nil
+			}}
+			m, home := newInstallManager(t, fetcher, &MockExtractor{ExtractFn: noExtract}, verifier, "github", resolution)
+			libDir := filepath.Join(home, ".local", "share", "binmgr")
+			err := m.Install(context.Background(), opts)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "provenance") {
+					t.Fatalf("expected provenance failure, got %v", err)
+				}
+				if entries := manifestFiles(t, libDir); len(entries) != 0 {
+					t.Errorf("expected no manifest after failed provenance, got %d", len(entries))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Install returned error: %v", err)
+			}
+			pkg, err := manifest.Load("example.com/owner/tool", libDir)
+			if err != nil {
+				t.Fatalf("load manifest: %v", err)
+			}
+			if a := pkg.Specs[0].Asset; a.Builder != "https://github.com/example/builder@v1" || a.SourceCommit != "abc123" {
+				t.Errorf("recorded builder %q, commit %q", a.Builder, a.SourceCommit)
+			}
+			if pkg.Provenance == nil || pkg.Provenance.Key == "" {
+				t.Error("expected the provenance requirement to be recorded for updates")
+			}
+		})
+	}
+}
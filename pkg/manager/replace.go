@@ -0,0 +1,147 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// replacement is one installed file staged next to its destination.
+type replacement struct {
+	staged string // temp file in the destination's directory
+	dst    string
+	backup string // previous content of dst once replaced; empty if dst did not exist
+	done   bool   // staged has been renamed over dst
+}
+
+// fileSet holds every file written by one package install so they land
+// together. Files are staged beside their destinations and nothing on disk is
+// replaced until commit; rollback puts back every file commit replaced and
+// removes anything still staged.
+type fileSet struct {
+	files []*replacement
+}
+
+// stage copies src into a temp file in dst's directory, computing the
+// checksums recorded in the manifest as it is written, and fsyncs it. dst
+// itself is left untouched until commit.
+func (m *mgr) stage(ctx context.Context, set *fileSet, src, dst string) (map[string]string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	out, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".binmgr-*")
+	if err != nil {
+		return nil, err
+	}
+	// Register before writing so a failure below is cleaned up by rollback.
+	set.files = append(set.files, &replacement{staged: out.Name(), dst: dst})
+	defer out.Close()
+
+	tee := io.TeeReader(in, out)
+	checksums, err := m.verifier.Compute(ctx, tee, []string{"sha-256"})
+	if err != nil {
+		return nil, fmt.Errorf("compute checksums: %w", err)
+	}
+	if _, err := io.Copy(io.Discard, tee); err != nil {
+		return nil, err
+	}
+	if err := out.Chmod(0755); err != nil {
+		return nil, err
+	}
+	if err := out.Sync(); err != nil {
+		return nil, err
+	}
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+	return checksums, nil
+}
+
+// commit renames every staged file over its destination. An existing
+// destination is first hard-linked aside as a backup, so the path always
+// names either the old or the new file; filesystems without hard links fall
+// back to renaming it aside. On error the caller must call rollback.
+func (s *fileSet) commit() error {
+	dirs := make(map[string]bool)
+	for _, r := range s.files {
+		if info, err := os.Lstat(r.dst); err == nil {
+			if info.IsDir() {
+				return fmt.Errorf("replace %q: is a directory", r.dst)
+			}
+			backup := r.staged + ".old"
+			if err := os.Link(r.dst, backup); err != nil {
+				if err := os.Rename(r.dst, backup); err != nil {
+					return fmt.Errorf("back up %q: %w", r.dst, err)
+				}
+			}
+			r.backup = backup
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		if err := os.Rename(r.staged, r.dst); err != nil {
+			return fmt.Errorf("replace %q: %w", r.dst, err)
+		}
+		r.done = true
+		dirs[filepath.Dir(r.dst)] = true
+	}
+	for dir := range dirs {
+		if err := syncDir(dir); err != nil {
+			return fmt.Errorf("sync %q: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// rollback undoes commit in reverse order, restoring each replaced file from
+// its backup and removing files that did not exist before, then discards any
+// files that were staged but never committed.
+func (s *fileSet) rollback() error {
+	var errs []error
+	for i := len(s.files) - 1; i >= 0; i-- {
+		r := s.files[i]
+		switch {
+		case r.done && r.backup != "":
+			if err := os.Rename(r.backup, r.dst); err != nil {
+				errs = append(errs, fmt.Errorf("restore %q: %w", r.dst, err))
+			}
+		case r.done:
+			if err := os.Remove(r.dst); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, fmt.Errorf("remove %q: %w", r.dst, err))
+			}
+		default:
+			// A backup may exist without done if the rename over dst failed.
+			if r.backup != "" {
+				if err := os.Rename(r.backup, r.dst); err != nil {
+					errs = append(errs, fmt.Errorf("restore %q: %w", r.dst, err))
+				}
+			}
+			os.Remove(r.staged)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// cleanup removes the backups kept by commit once the install has succeeded.
+func (s *fileSet) cleanup() {
+	for _, r := range s.files {
+		if r.backup != "" {
+			os.Remove(r.backup)
+		}
+	}
+}
+
+// syncDir fsyncs a directory so renames within it are durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
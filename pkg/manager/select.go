@@ -0,0 +1,339 @@
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ventifus/binmgr/pkg/backend"
+	"github.com/ventifus/binmgr/pkg/extract"
+)
+
+// osTokens are the spellings of each GOOS found in release asset names.
+var osTokens = map[string][]string{
+	"linux":   {"linux"},
+	"darwin":  {"darwin", "macos", "osx", "mac", "apple"},
+	"windows": {"windows", "win", "win64", "win32"},
+	"freebsd": {"freebsd"},
+	"openbsd": {"openbsd"},
+	"netbsd":  {"netbsd"},
+}
+
+// archTokens are the spellings of each GOARCH found in release asset names.
+// "x86_64" and "x86-64" are rewritten to "amd64" before tokenising, since
+// splitting them would leave an ambiguous "x86".
+var archTokens = map[string][]string{
+	"amd64":   {"amd64", "x64"},
+	"arm64":   {"arm64", "aarch64"},
+	"386":     {"386", "i386", "i686", "x86"},
+	"arm":     {"arm", "armv6", "armv6l", "armv7", "armv7l", "armhf", "armel"},
+	"riscv64": {"riscv64"},
+	"ppc64le": {"ppc64le"},
+	"s390x":   {"s390x"},
+}
+
+// noiseExts are extensions of release assets that are never the thing being
+// installed: checksums, signatures, SBOMs and metadata.
+var noiseExts = map[string]bool{
+	"sha1": true, "sha256": true, "sha512": true, "md5": true, "sum": true, "sums": true,
+	"sig": true, "asc": true, "minisig": true, "pem": true, "crt": true, "cert": true, "pub": true, "bundle": true,
+	"sbom": true, "spdx": true, "cdx": true, "intoto": true, "jsonl": true, "att": true,
+	"json": true, "txt": true, "yaml": true, "yml": true, "md": true,
+}
+
+// packageExts are OS package formats binmgr does not install from.
+var packageExts = map[string]bool{
+	"deb": true, "rpm": true, "apk": true, "msi": true, "pkg": true, "dmg": true,
+	"appimage": true, "snap": true, "flatpak": true, "whl": true, "vsix": true,
+}
+
+// noiseTokens mark an asset as something other than a platform build.
+var noiseTokens = map[string]bool{
+	"checksum": true, "checksums": true, "sha256sums": true, "sha512sums": true,
+	"sbom": true, "src": true, "source": true, "sources": true,
+}
+
+// scoredAsset is a candidate for automatic asset selection.
+type scoredAsset struct {
+	asset  *backend.Asset
+	score  int
+	format int // preference for the asset's archive type; breaks ties
+}
+
+// selectAsset picks the release asset that best matches the platform goos /
+// goarch when no asset glob is given. Checksums, signatures, SBOMs, source
+// archives, OS packages and builds for other platforms are excluded; the
+// rest are ranked by how specifically they name this platform, preferring
+// statically linked (musl) Linux builds. Between equally good builds the
+// archive type this platform usually ships decides. A tie beyond that is an
+// error, since guessing between e.g. two distro builds is worse than asking.
+func selectAsset(assets []backend.Asset, goos, goarch string) (*backend.Asset, error) {
+	var candidates []scoredAsset
+	for i := range assets {
+		score, ok := scoreAsset(assets[i].Name, goos, goarch)
+		if ok {
+			candidates = append(candidates, scoredAsset{asset: &assets[i], score: score, format: formatScore(assets[i].Name, goos)})
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no asset looks like a %s/%s build; specify one with --file", goos, goarch)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].format > candidates[j].format
+	})
+	best := candidates[0]
+	var tied []string
+	for _, c := range candidates {
+		if c.score == best.score && c.format == best.format {
+			tied = append(tied, c.asset.Name)
+		}
+	}
+	if len(tied) > 1 {
+		return nil, fmt.Errorf("several assets match %s/%s equally well (%s); specify one with --file", goos, goarch, strings.Join(tied, ", "))
+	}
+	return best.asset, nil
+}
+
+// scoreAsset rates how well an asset name matches goos/goarch. ok is false
+// for assets that cannot be the right download.
+func scoreAsset(name, goos, goarch string) (score int, ok bool) {
+	lower := strings.ToLower(name)
+	if ext := strings.TrimPrefix(path.Ext(lower), "."); noiseExts[ext] || packageExts[ext] {
+		return 0, false
+	}
+
+	lower = strings.NewReplacer("x86_64", "amd64", "x86-64", "amd64").Replace(lower)
+	tokens := strings.FieldsFunc(lower, func(r rune) bool {
+		return !('a' <= r && r <= 'z' || '0' <= r && r <= '9')
+	})
+	has := make(map[string]bool, len(tokens))
+	for _, t := range tokens {
+		if noiseTokens[t] {
+			return 0, false
+		}
+		has[t] = true
+	}
+
+	for goosName, spellings := range osTokens {
+		for _, s := range spellings {
+			if !has[s] {
+				continue
+			}
+			if goosName != goos {
+				return 0, false
+			}
+			score += 4
+			break
+		}
+	}
+
+	universal := goos == "darwin" && has["universal"]
+	if universal {
+		score += 3
+	}
+	for arch, spellings := range archTokens {
+		for _, s := range spellings {
+			if !has[s] {
+				continue
+			}
+			if arch != goarch {
+				if universal {
+					continue
+				}
+				return 0, false
+			}
+			score += 4
+			break
+		}
+	}
+
+	if goos == "linux" {
+		switch {
+		case has["musl"] || has["static"]:
+			score += 2
+		case has["gnu"] || has["glibc"]:
+			score++
+		}
+	}
+	if has["debug"] || has["dbg"] || has["symbols"] {
+		score -= 3
+	}
+	return score, true
+}
+
+// tarExts are the names of tar archives, compressed or not.
+var tarExts = []string{".tar", ".tar.gz", ".tgz", ".tar.bz2", ".tbz", ".tbz2", ".tar.xz", ".txz", ".tar.zst", ".tzst", ".tar.lzma", ".tar.lz"}
+
+// formatScore rates an asset's archive type for goos: zip is the native
+// format on Windows and tar elsewhere, and either is preferred over a bare
+// or merely compressed file.
+func formatScore(name, goos string) int {
+	lower := strings.ToLower(name)
+	zip := strings.HasSuffix(lower, ".zip")
+	tar := false
+	for _, ext := range tarExts {
+		tar = tar || strings.HasSuffix(lower, ext)
+	}
+	switch {
+	case zip && goos == "windows", tar && goos != "windows":
+		return 2
+	case zip, tar:
+		return 1
+	}
+	return 0
+}
+
+// selectExecutable picks the file to install from the entries of an
+// archive: the native executables (ELF, Mach-O or PE), falling back to
+// scripts with a "#!" line. If there are several, the one named after the
+// project wins; otherwise the choice is ambiguous and an error.
+func selectExecutable(entries []extract.Entry, project string) (*extract.Entry, error) {
+	var native, scripts []*extract.Entry
+	for i := range entries {
+		switch executableKind(entries[i].Head) {
+		case kindNative:
+			native = append(native, &entries[i])
+		case kindScript:
+			scripts = append(scripts, &entries[i])
+		}
+	}
+
+	candidates := native
+	if len(candidates) == 0 {
+		candidates = scripts
+	}
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("no executable found in archive; specify one with --file")
+	case 1:
+		return candidates[0], nil
+	}
+
+	project = strings.ToLower(project)
+	for _, c := range candidates {
+		base := strings.ToLower(strings.TrimSuffix(path.Base(c.SourcePath), ".exe"))
+		if base == project {
+			return c, nil
+		}
+	}
+	names := make([]string, len(candidates))
+	for i, c := range candidates {
+		names[i] = c.SourcePath
+	}
+	return nil, fmt.Errorf("several executables in archive (%s); specify one with --file", strings.Join(names, ", "))
+}
+
+type executable int
+
+const (
+	kindNone executable = iota
+	kindNative
+	kindScript
+)
+
+// executableMagics are the leading bytes of native executable formats.
+var executableMagics = [][]byte{
+	[]byte("\x7fELF"),
+	{0xfe, 0xed, 0xfa, 0xce}, {0xfe, 0xed, 0xfa, 0xcf}, // Mach-O, big-endian
+	{0xce, 0xfa, 0xed, 0xfe}, {0xcf, 0xfa, 0xed, 0xfe}, // Mach-O, little-endian
+	{0xca, 0xfe, 0xba, 0xbe}, // Mach-O universal
+	[]byte("MZ"),             // PE
+}
+
+// executableKind classifies a file by its leading bytes.
+func executableKind(head []byte) executable {
+	for _, magic := range executableMagics {
+		if bytes.HasPrefix(head, magic) {
+			return kindNative
+		}
+	}
+	if bytes.HasPrefix(head, []byte("#!")) {
+		return kindScript
+	}
+	return kindNone
+}
+
+// globEscaper escapes filepath.Match metacharacters.
+var globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`)
+
+// templatize turns a concrete name chosen for tag into a glob that matches
+// the same name in future releases, replacing the whole tag with ${TAG} and
+// the version with ${VERSION}. Versions without a "." are left alone:
+// replacing a bare "6" would also rewrite "arm64".
+func templatize(name, tag string) string {
+	glob := globEscaper.Replace(name)
+	version := strings.TrimPrefix(tag, "v")
+	if !strings.Contains(version, ".") {
+		return glob
+	}
+	if tag != version {
+		glob = strings.ReplaceAll(glob, globEscaper.Replace(tag), "${TAG}")
+	}
+	return strings.ReplaceAll(glob, globEscaper.Replace(version), "${VERSION}")
+}
+
+// compressionExts are stripped from an asset name before deriving the
+// installed file's name.
+var compressionExts = []string{".gz", ".bz2", ".xz", ".zst", ".lzma", ".lz", ".exe"}
+
+// binaryName derives an installed file name from a platform-specific asset
+// name by cutting it at the first OS, architecture or version component:
+// "func_linux_amd64" → "func", "tree-sitter-linux-x64.gz" → "tree-sitter".
+// On Windows the ".exe" suffix is kept.
+func binaryName(asset string) string {
+	name := asset
+	for _, ext := range compressionExts {
+		name = strings.TrimSuffix(name, ext)
+	}
+
+	for i := 0; i < len(name); i++ {
+		if !strings.ContainsRune("-_.", rune(name[i])) {
+			continue
+		}
+		rest := name[i+1:]
+		if j := strings.IndexAny(rest, "-_."); j >= 0 {
+			rest = rest[:j]
+		}
+		if i > 0 && isPlatformOrVersion(strings.ToLower(rest)) {
+			name = name[:i]
+			break
+		}
+	}
+
+	if platformOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// isPlatformOrVersion reports whether an asset name component names an OS,
+// an architecture, or a version ("1.2", "v1").
+func isPlatformOrVersion(component string) bool {
+	if component == "" {
+		return false
+	}
+	if c := strings.TrimPrefix(component, "v"); c != "" && '0' <= c[0] && c[0] <= '9' {
+		return true
+	}
+	for _, spellings := range osTokens {
+		for _, s := range spellings {
+			if component == s {
+				return true
+			}
+		}
+	}
+	for _, spellings := range archTokens {
+		for _, s := range spellings {
+			if component == s {
+				return true
+			}
+		}
+	}
+	return false
+}
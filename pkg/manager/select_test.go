@@ -0,0 +1,377 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ventifus/binmgr/pkg/backend"
+	"github.com/ventifus/binmgr/pkg/extract"
+	"github.com/ventifus/binmgr/pkg/manifest"
+)
+
+func assetsNamed(names ...string) []backend.Asset {
+	assets := make([]backend.Asset, len(names))
+	for i, n := range names {
+		assets[i] = backend.Asset{Name: n, URL: "https://example.com/" + n}
+	}
+	return assets
+}
+
+func TestSelectAsset(t *testing.T) {
+	tests := []struct {
+		name    string
+		goos    string
+		goarch  string
+		assets  []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "goreleaser style",
+			goos:   "linux",
+			goarch: "arm64",
+			assets: []string{
+				"tool_1.2.3_checksums.txt",
+				"tool_1.2.3_darwin_amd64.tar.gz",
+				"tool_1.2.3_darwin_arm64.tar.gz",
+				"tool_1.2.3_linux_amd64.tar.gz",
+				"tool_1.2.3_linux_amd64.tar.gz.sbom.json",
+				"tool_1.2.3_linux_arm64.tar.gz",
+				"tool_1.2.3_linux_arm64.tar.gz.sig",
+				"tool_1.2.3_linux_arm64.deb",
+				"tool_1.2.3_windows_arm64.zip",
+			},
+			want: "tool_1.2.3_linux_arm64.tar.gz",
+		},
+		{
+			name:   "rust triples prefer musl",
+			goos:   "linux",
+			goarch: "amd64",
+			assets: []string{
+				"just-1.46.0-aarch64-unknown-linux-musl.tar.gz",
+				"just-1.46.0-x86_64-apple-darwin.tar.gz",
+				"just-1.46.0-x86_64-pc-windows-msvc.zip",
+				"just-1.46.0-x86_64-unknown-linux-gnu.tar.gz",
+				"just-1.46.0-x86_64-unknown-linux-musl.tar.gz",
+				"SHA256SUMS",
+			},
+			want: "just-1.46.0-x86_64-unknown-linux-musl.tar.gz",
+		},
+		{
+			name:   "bare binaries with x64 spelling",
+			goos:   "linux",
+			goarch: "amd64",
+			assets: []string{"tree-sitter-linux-arm64.gz", "tree-sitter-linux-x64.gz", "tree-sitter-macos-x64.gz", "tree-sitter-windows-x64.gz"},
+			want:   "tree-sitter-linux-x64.gz",
+		},
+		{
+			name:   "darwin universal",
+			goos:   "darwin",
+			goarch: "arm64",
+			assets: []string{"tool-linux-amd64", "tool-darwin-universal.tar.gz"},
+			want:   "tool-darwin-universal.tar.gz",
+		},
+		{
+			name:   "source archives excluded",
+			goos:   "linux",
+			goarch: "amd64",
+			assets: []string{"tool-src.tar.gz", "tool-linux-amd64"},
+			want:   "tool-linux-amd64",
+		},
+		{
+			name:   "tar preferred over zip and packages",
+			goos:   "linux",
+			goarch: "amd64",
+			assets: []string{"tool-linux-amd64.zip", "tool-linux-amd64.tar.gz", "tool-linux-amd64.deb", "tool-linux-amd64.rpm"},
+			want:   "tool-linux-amd64.tar.gz",
+		},
+		{
+			name:   "zip preferred on windows",
+			goos:   "windows",
+			goarch: "amd64",
+			assets: []string{"tool-windows-amd64.tar.gz", "tool-windows-amd64.zip"},
+			want:   "tool-windows-amd64.zip",
+		},
+		{
+			name:   "archive preferred over bare binary",
+			goos:   "linux",
+			goarch: "amd64",
+			assets: []string{"yq_linux_amd64", "yq_linux_amd64.tar.gz"},
+			want:   "yq_linux_amd64.tar.gz",
+		},
+		{
+			name:   "platform match outweighs archive type",
+			goos:   "linux",
+			goarch: "amd64",
+			assets: []string{"tool-linux-amd64-gnu.tar.gz", "tool-linux-amd64-musl.zip"},
+			want:   "tool-linux-amd64-musl.zip",
+		},
+		{
+			name:    "tie is ambiguous",
+			goos:    "linux",
+			goarch:  "amd64",
+			assets:  []string{"client-linux-amd64-rhel8.tar.gz", "client-linux-amd64-rhel9.tar.gz"},
+			wantErr: true,
+		},
+		{
+			name:    "no build for platform",
+			goos:    "linux",
+			goarch:  "riscv64",
+			assets:  []string{"tool-linux-amd64", "tool-linux-arm64", "checksums.txt"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := selectAsset(assetsNamed(tc.assets...), tc.goos, tc.goarch)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got.Name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("selectAsset returned error: %v", err)
+			}
+			if got.Name != tc.want {
+				t.Errorf("selectAsset = %q, want %q", got.Name, tc.want)
+			}
+		})
+	}
+}
+
+func TestBinaryName(t *testing.T) {
+	tests := map[string]string{
+		"func_linux_amd64":         "func",
+		"tree-sitter-linux-x64.gz": "tree-sitter",
+		"kubectl":                  "kubectl",
+		"yq_linux_amd64":           "yq",
+		"tool-v1.2.3-linux-arm64":  "tool",
+		"tool-x86_64-linux":        "tool",
+	}
+	for asset, want := range tests {
+		if got := binaryName(asset); got != want {
+			t.Errorf("binaryName(%q) = %q, want %q", asset, got, want)
+		}
+	}
+}
+
+func TestTemplatize(t *testing.T) {
+	tests := []struct {
+		name, tag, want string
+	}{
+		{"just-1.46.0-x86_64-unknown-linux-musl.tar.gz", "1.46.0", "just-${VERSION}-x86_64-unknown-linux-musl.tar.gz"},
+		{"gh_2.40.1_linux_amd64/bin/gh", "v2.40.1", "gh_${VERSION}_linux_amd64/bin/gh"},
+		{"tool-linux-arm64", "6", "tool-linux-arm64"},
+		{"tool[1]-1.0.tar.gz", "v1.0", `tool\[1]-${VERSION}.tar.gz`},
+		{"tool-v1.2.3-checksums.txt", "v1.2.3", "tool-${TAG}-checksums.txt"},
+		{"tool_v1.2.3_1.2.3_SHA256SUMS", "v1.2.3", "tool_${TAG}_${VERSION}_SHA256SUMS"},
+	}
+	for _, tc := range tests {
+		got := templatize(tc.name, tc.tag)
+		if got != tc.want {
+			t.Errorf("templatize(%q, %q) = %q, want %q", tc.name, tc.tag, got, tc.want)
+		}
+		if ok, err := filepath.Match(ExpandVars(got, tc.tag), tc.name); err != nil || !ok {
+			t.Errorf("expanded %q does not match %q (err: %v)", got, tc.name, err)
+		}
+	}
+}
+
+func TestSelectExecutable(t *testing.T) {
+	file := func(sourcePath, content string) extract.Entry {
+		return extract.Entry{SourcePath: sourcePath, Head: []byte(content)}
+	}
+
+	readme := file("tool/README.md", "# tool")
+	binary := file("tool/bin/tool", "\x7fELF...")
+	helper := file("tool/bin/tool-helper", "\x7fELF...")
+	script := file("tool/completions.sh", "#!/bin/sh")
+
+	got, err := selectExecutable([]extract.Entry{readme, script, binary}, "tool")
+	if err != nil || got.SourcePath != "tool/bin/tool" {
+		t.Errorf("single native executable: got %v, %v", got, err)
+	}
+
+	got, err = selectExecutable([]extract.Entry{readme, helper, binary}, "tool")
+	if err != nil || got.SourcePath != "tool/bin/tool" {
+		t.Errorf("project name preferred: got %v, %v", got, err)
+	}
+
+	if _, err := selectExecutable([]extract.Entry{readme, helper, binary}, "other"); err == nil {
+		t.Error("expected error for several executables with no project match")
+	}
+
+	got, err = selectExecutable([]extract.Entry{readme, script}, "tool")
+	if err != nil || got.SourcePath != "tool/completions.sh" {
+		t.Errorf("script fallback: got %v, %v", got, err)
+	}
+
+	if _, err := selectExecutable([]extract.Entry{readme}, "tool"); err == nil {
+		t.Error("expected error when archive has no executable")
+	}
+}
+
+// TestInstall_AutoSelectsAssetAndExecutable verifies that a spec with no
+// asset glob picks the platform's asset and the executable inside it, and
+// records both as ordinary globs in the manifest.
+func TestInstall_AutoSelectsAssetAndExecutable(t *testing.T) {
+	oldOS, oldArch := platformOS, platformArch
+	platformOS, platformArch = "linux", "arm64"
+	defer func() { platformOS, platformArch = oldOS, oldArch }()
+
+	var fetched []string
+	fetcher := &MockFetcher{
+		FetchFn: func(ctx context.Context, u string) ([]byte, error) {
+			fetched = append(fetched, u)
+			return []byte("archive"), nil
+		},
+	}
+	verifier := &MockVerifier{
+		VerifyFn:  func(ctx context.Context, data []byte, expected map[string]string) error { return nil },
+		ComputeFn: defaultCompute,
+	}
+	var extracts [][]string
+	extractor := &MockExtractor{
+		ExtractFn: func(ctx context.Context, name string, data []byte, globs []string) ([]mockEntry, error) {
+			all := []mockEntry{
+				{SourcePath: "tool_1.2.3_linux_arm64/LICENSE", Data: []byte("MIT")},
+				{SourcePath: "tool_1.2.3_linux_arm64/tool", Data: []byte("\x7fELF-arm64")},
+			}
+			if len(globs) == 0 {
+				return all, nil
+			}
+			extracts = append(extracts, globs)
+			var matched []mockEntry
+			for _, e := range all {
+				if ok, _ := matchGlob(globs[0], e.SourcePath); ok {
+					matched = append(matched, e)
+				}
+			}
+			return matched, nil
+		},
+	}
+
+	resolution := &backend.Resolution{
+		Version: "v1.2.3",
+		Assets: assetsNamed(
+			"checksums.txt",
+			"tool_1.2.3_linux_amd64.tar.gz",
+			"tool_1.2.3_linux_arm64.tar.gz",
+			"tool_1.2.3_darwin_arm64.tar.gz",
+		),
+	}
+
+	m, home := newInstallManager(t, fetcher, extractor, verifier, "github", resolution)
+
+	opts := InstallOptions{
+		SourceURL: "https://example.com/owner/tool",
+		Specs:     []SpecOpts{{Checksum: ChecksumOpts{Strategy: "none"}}},
+	}
+	if err := m.Install(context.Background(), opts); err != nil {
+		t.Fatalf("Install returned error: %v", err)
+	}
+
+	if len(fetched) != 1 || fetched[0] != "https://example.com/tool_1.2.3_linux_arm64.tar.gz" {
+		t.Errorf("fetched %v, want only the linux arm64 archive", fetched)
+	}
+	// The archive is listed to choose the executable; only that is extracted.
+	if len(extracts) != 1 || len(extracts[0]) != 1 || extracts[0][0] != "tool_1.2.3_linux_arm64/tool" {
+		t.Errorf("extracted with globs %v, want only [tool_1.2.3_linux_arm64/tool]", extracts)
+	}
+	got, err := os.ReadFile(filepath.Join(home, ".local", "bin", "tool"))
+	if err != nil {
+		t.Fatalf("installed file not found: %v", err)
+	}
+	if string(got) != "\x7fELF-arm64" {
+		t.Errorf("installed content = %q", got)
+	}
+
+	pkg, err := manifest.Load("example.com/owner/tool", filepath.Join(home, ".local", "share", "binmgr"))
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	spec := pkg.Specs[0]
+	if spec.AssetGlob != "tool_${VERSION}_linux_arm64.tar.gz" {
+		t.Errorf("AssetGlob = %q, want tool_${VERSION}_linux_arm64.tar.gz", spec.AssetGlob)
+	}
+	if len(spec.TraversalGlobs) != 1 || spec.TraversalGlobs[0] != "tool_${VERSION}_linux_arm64/tool" {
+		t.Errorf("TraversalGlobs = %v, want [tool_${VERSION}_linux_arm64/tool]", spec.TraversalGlobs)
+	}
+}
+
+// TestInstall_AutoSelectsCompressedExecutable verifies that a plain
+// compressed executable is selected without a traversal glob and installed
+// decompressed.
+func TestInstall_AutoSelectsCompressedExecutable(t *testing.T) {
+	oldOS, oldArch := platformOS, platformArch
+	platformOS, platformArch = "linux", "amd64"
+	defer func() { platformOS, platformArch = oldOS, oldArch }()
+
+	fetcher := &MockFetcher{
+		FetchFn: func(ctx context.Context, u string) ([]byte, error) {
+			return []byte("gzip"), nil
+		},
+	}
+	verifier := &MockVerifier{
+		VerifyFn:  func(ctx context.Context, data []byte, expected map[string]string) error { return nil },
+		ComputeFn: defaultCompute,
+	}
+	extractor := &MockExtractor{
+		ExtractFn: func(ctx context.Context, name string, data []byte, globs []string) ([]mockEntry, error) {
+			if len(globs) > 0 {
+				return nil, fmt.Errorf("%q is not a recognised archive", name)
+			}
+			return []mockEntry{{Data: []byte("\x7fELF-amd64")}}, nil
+		},
+		DecompressFn: func(ctx context.Context, name string, data []byte) ([]byte, error) {
+			return []byte("\x7fELF-amd64"), nil
+		},
+	}
+	resolution := &backend.Resolution{
+		Version: "v0.25.1",
+		Assets:  assetsNamed("tree-sitter-linux-x64.gz", "tree-sitter-macos-arm64.gz"),
+	}
+
+	m, home := newInstallManager(t, fetcher, extractor, verifier, "github", resolution)
+
+	opts := InstallOptions{
+		SourceURL: "https://example.com/tree-sitter/tree-sitter",
+		Specs:     []SpecOpts{{Checksum: ChecksumOpts{Strategy: "none"}}},
+	}
+	if err := m.Install(context.Background(), opts); err != nil {
+		t.Fatalf("Install returned error: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(home, ".local", "bin", "tree-sitter"))
+	if err != nil {
+		t.Fatalf("installed file not found: %v", err)
+	}
+	if string(got) != "\x7fELF-amd64" {
+		t.Errorf("installed content = %q, want the decompressed executable", got)
+	}
+	pkg, err := manifest.Load("example.com/tree-sitter/tree-sitter", filepath.Join(home, ".local", "share", "binmgr"))
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	if spec := pkg.Specs[0]; len(spec.TraversalGlobs) != 0 {
+		t.Errorf("TraversalGlobs = %v, want none", spec.TraversalGlobs)
+	}
+}
+
+// matchGlob applies the traversal glob rules used by the real extractor
+// closely enough for the mock: full path, or basename for slash-free globs.
+func matchGlob(glob, entry string) (bool, error) {
+	if ok, err := filepath.Match(glob, entry); ok || err != nil {
+		return ok, err
+	}
+	if strings.Contains(glob, "/") {
+		return false, nil
+	}
+	return filepath.Match(glob, filepath.Base(entry))
+}
@@ -0,0 +1,234 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/apex/log"
+	"github.com/ventifus/binmgr/pkg/backend"
+	"github.com/ventifus/binmgr/pkg/manifest"
+	"github.com/ventifus/binmgr/pkg/verify"
+)
+
+// bundleSuffixes name the Sigstore bundles that may be published beside a
+// signed file, in order of preference.
+var bundleSuffixes = []string{".sigstore.json", ".sigstore", ".bundle"}
+
+// certSuffixes name the certificate files cosign publishes beside a keyless
+// ".sig" file.
+var certSuffixes = []string{".pem", ".cert"}
+
+// pgpSuffixes name the detached OpenPGP signatures that may be published
+// beside a checksum file.
+var pgpSuffixes = []string{".sig", ".asc", ".gpg"}
+
+// fetchChecksumFile fetches a checksum file. If opts.Signer holds a keyring
+// or minisign key, the file's detached signature is checked first and the
+// file is refused without a valid one; the signer is returned.
+func (m *mgr) fetchChecksumFile(ctx context.Context, opts ChecksumOpts, file *backend.Asset, resolution *backend.Resolution) ([]byte, string, error) {
+	data, err := m.fetcher.Fetch(ctx, file.URL)
+	if err != nil {
+		return nil, "", fmt.Errorf("fetch checksum file %q: %w", file.URL, err)
+	}
+	s := opts.Signer
+	if s == nil || (s.Keyring == "" && s.Minisign == "") {
+		return data, "", nil
+	}
+
+	var signedBy string
+	if s.Keyring != "" {
+		sig, err := m.fetchSibling(ctx, file.Name, file.URL, resolution, pgpSuffixes)
+		if err != nil {
+			return nil, "", fmt.Errorf("checksum file %q: %w", file.Name, err)
+		}
+		signedBy, err = verify.VerifyPGP(data, sig, []byte(s.Keyring))
+		if err != nil {
+			return nil, "", fmt.Errorf("verify signature of checksum file %q: %w", file.Name, err)
+		}
+	} else {
+		sig, err := m.fetchSibling(ctx, file.Name, file.URL, resolution, []string{".minisig"})
+		if err != nil {
+			return nil, "", fmt.Errorf("checksum file %q: %w", file.Name, err)
+		}
+		signedBy, err = verify.VerifyMinisign(data, sig, s.Minisign)
+		if err != nil {
+			return nil, "", fmt.Errorf("verify signature of checksum file %q: %w", file.Name, err)
+		}
+	}
+	log.WithField("file", file.Name).WithField("signer", signedBy).Info("checksum file signature verified")
+	return data, signedBy, nil
+}
+
+// downloadSigned implements the "sigstore" strategy. With a FileGlob the
+// signature covers that checksum file, which is checked before it is trusted
+// and then supplies the asset's checksum. Otherwise it covers the asset
+// itself, which is spooled first and checked from disk.
+func (m *mgr) downloadSigned(ctx context.Context, asset *backend.Asset, csum ChecksumOpts, resolution *backend.Resolution, scratch string) (*downloadResult, error) {
+	policy, err := signaturePolicy(csum.Signer)
+	if err != nil {
+		return nil, fmt.Errorf("sigstore: %w", err)
+	}
+
+	if csum.FileGlob != "" {
+		sumsAsset, err := findAsset(resolution, csum.FileGlob)
+		if err != nil {
+			return nil, fmt.Errorf("sigstore: %w", err)
+		}
+		data, err := m.fetcher.Fetch(ctx, sumsAsset.URL)
+		if err != nil {
+			return nil, fmt.Errorf("sigstore: fetch checksum file %q: %w", sumsAsset.URL, err)
+		}
+		signedBy, err := m.verifySignature(ctx, bytes.NewReader(data), sumsAsset.Name, sumsAsset.URL, resolution, policy)
+		if err != nil {
+			return nil, err
+		}
+		list, err := parseChecksumFile(data, sumsAsset.Name)
+		if err != nil {
+			return nil, fmt.Errorf("sigstore: %w", err)
+		}
+		checksums, ok := list.lookup(asset.Name)
+		if !ok {
+			return nil, fmt.Errorf("sigstore: asset %q not found in checksum file %q", asset.Name, sumsAsset.URL)
+		}
+		path, err := m.fetchVerified(ctx, asset.Name, asset.URL, checksums, scratch)
+		if err != nil {
+			return nil, err
+		}
+		return &downloadResult{path: path, checksumResult: checksumResult{
+			checksums:  checksums,
+			sourceURL:  sumsAsset.URL,
+			strategy:   "sigstore",
+			algorithms: checkedAlgorithms(checksums),
+			signedBy:   signedBy,
+		}}, nil
+	}
+
+	path, err := m.fetchVerified(ctx, asset.Name, asset.URL, asset.Checksums, scratch)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	signedBy, err := m.verifySignature(ctx, f, asset.Name, asset.URL, resolution, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record checksums of the signed content so later verification and
+	// repair do not need the signature again. Only checksums listed by the
+	// backend were verified; computed ones merely describe the content.
+	checksums, algorithms := asset.Checksums, checkedAlgorithms(asset.Checksums)
+	if len(checksums) == 0 {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		checksums, err = m.verifier.Compute(ctx, f, []string{"sha-256"})
+		if err != nil {
+			return nil, fmt.Errorf("compute checksums of %q: %w", asset.Name, err)
+		}
+	}
+	return &downloadResult{path: path, checksumResult: checksumResult{
+		checksums:  checksums,
+		sourceURL:  asset.ChecksumURL,
+		strategy:   "sigstore",
+		algorithms: algorithms,
+		signedBy:   signedBy,
+	}}, nil
+}
+
+// verifySignature checks the signature published for the file name, served
+// from url, against r's content, and returns who signed it.
+func (m *mgr) verifySignature(ctx context.Context, r io.Reader, name, url string, resolution *backend.Resolution, policy verify.SignaturePolicy) (string, error) {
+	sig, err := m.fetchSignature(ctx, name, url, resolution, len(policy.Key) == 0)
+	if err != nil {
+		return "", fmt.Errorf("sigstore: %q: %w", name, err)
+	}
+	signedBy, err := verify.VerifySignature(ctx, r, sig, policy)
+	if err != nil {
+		return "", fmt.Errorf("sigstore: verify signature of %q: %w", name, err)
+	}
+	return signedBy, nil
+}
+
+// fetchSignature fetches the signature over the file name served from url.
+// A bundle listed among the release's assets is preferred; otherwise a
+// cosign ".sig" file is used, with its certificate if keyless. Files the
+// release does not list (as for kubeurl) are looked for beside url.
+func (m *mgr) fetchSignature(ctx context.Context, name, url string, resolution *backend.Resolution, keyless bool) (*verify.Signature, error) {
+	for _, suffix := range bundleSuffixes {
+		if u, ok := siblingURL(resolution, name+suffix); ok {
+			data, err := m.fetcher.Fetch(ctx, u)
+			if err != nil {
+				return nil, fmt.Errorf("fetch signature bundle %q: %w", u, err)
+			}
+			return verify.ParseSigstoreBundle(data)
+		}
+	}
+
+	sig, err := m.fetchSibling(ctx, name, url, resolution, []string{".sig"})
+	if err != nil {
+		return nil, err
+	}
+	if !keyless {
+		return verify.ParseCosignSignature(sig, nil)
+	}
+	cert, err := m.fetchSibling(ctx, name, url, resolution, certSuffixes)
+	if err != nil {
+		return nil, fmt.Errorf("signing certificate: %w", err)
+	}
+	return verify.ParseCosignSignature(sig, cert)
+}
+
+// fetchSibling fetches the file published beside the file name, served from
+// url, under the first of suffixes the release lists. If the release lists
+// none of them (as for kubeurl), each is tried beside url in turn.
+func (m *mgr) fetchSibling(ctx context.Context, name, url string, resolution *backend.Resolution, suffixes []string) ([]byte, error) {
+	for _, suffix := range suffixes {
+		if u, ok := siblingURL(resolution, name+suffix); ok {
+			data, err := m.fetcher.Fetch(ctx, u)
+			if err != nil {
+				return nil, fmt.Errorf("fetch %q: %w", u, err)
+			}
+			return data, nil
+		}
+	}
+	var err error
+	for _, suffix := range suffixes {
+		var data []byte
+		if data, err = m.fetcher.Fetch(ctx, url+suffix); err == nil {
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("no signature found beside %q: %w", url, err)
+}
+
+// siblingURL returns the URL of the release asset called name.
+func siblingURL(resolution *backend.Resolution, name string) (string, bool) {
+	for _, a := range resolution.Assets {
+		if a.Name == name {
+			return a.URL, true
+		}
+	}
+	return "", false
+}
+
+// signaturePolicy converts a manifest signer into the policy verify checks
+// signatures against.
+func signaturePolicy(s *manifest.Signer) (verify.SignaturePolicy, error) {
+	if s == nil || (s.Key == "" && (s.Identity == "" || s.Issuer == "" || s.Roots == "")) {
+		return verify.SignaturePolicy{}, fmt.Errorf("no signer configured: need a public key, or a certificate identity, issuer and roots")
+	}
+	return verify.SignaturePolicy{
+		Key:      []byte(s.Key),
+		Identity: s.Identity,
+		Issuer:   s.Issuer,
+		Roots:    []byte(s.Roots),
+		LogKey:   []byte(s.Rekor),
+	}, nil
+}
@@ -0,0 +1,368 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ventifus/binmgr/pkg/backend"
+	"github.com/ventifus/binmgr/pkg/manifest"
+	"github.com/ventifus/binmgr/pkg/verify"
+)
+
+// testSigner is a locally generated cosign key pair.
+type testSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func newTestSigner(t *testing.T) *testSigner {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return &testSigner{key: key}
+}
+
+// sign returns a cosign ".sig" file for data.
+func (s *testSigner) sign(t *testing.T, data []byte) []byte {
+	t.Helper()
+	digest := sha256.Sum256(data)
+	sig, err := ecdsa.SignASN1(rand.Reader, s.key, digest[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return []byte(base64.StdEncoding.EncodeToString(sig))
+}
+
+func (s *testSigner) signer(t *testing.T) *manifest.Signer {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(&s.key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	return &manifest.Signer{Key: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))}
+}
+
+// TestInstall_SigstoreSignedAsset verifies that the "sigstore" strategy
+// checks a cosign signature over the asset, records the signer and the
+// asset's checksum, and refuses an asset the signature does not cover.
+func TestInstall_SigstoreSignedAsset(t *testing.T) {
+	signer := newTestSigner(t)
+	assetData := []byte("tool-binary")
+	sig := signer.sign(t, assetData)
+
+	resolution := &backend.Resolution{
+		Version: "v1.0.0",
+		Assets: []backend.Asset{
+			{Name: "tool-linux-amd64", URL: "https://example.com/tool-linux-amd64"},
+			{Name: "tool-linux-amd64.sig", URL: "https://example.com/tool-linux-amd64.sig"},
+		},
+	}
+	opts := InstallOptions{
+		SourceURL: "https://example.com/owner/tool",
+		Specs: []SpecOpts{{
+			AssetGlob: "tool-linux-amd64",
+			LocalName: "tool",
+			Checksum:  ChecksumOpts{Strategy: "sigstore", Signer: signer.signer(t)},
+		}},
+	}
+
+	t.Run("valid signature", func(t *testing.T) {
+		fetcher := &MockFetcher{FetchFn: func(ctx context.Context, u string) ([]byte, error) {
+			if strings.HasSuffix(u, ".sig") {
+				return sig, nil
+			}
+			return assetData, nil
+		}}
+		m, home := newInstallManager(t, fetcher, &MockExtractor{ExtractFn: noExtract}, &MockVerifier{ComputeFn: defaultCompute}, "github", resolution)
+		if err := m.Install(context.Background(), opts); err != nil {
+			t.Fatalf("Install returned error: %v", err)
+		}
+
+		pkg, err := manifest.Load("example.com/owner/tool", filepath.Join(home, ".local", "share", "binmgr"))
+		if err != nil {
+			t.Fatalf("load manifest: %v", err)
+		}
+		asset := pkg.Specs[0].Asset
+		if !strings.HasPrefix(asset.SignedBy, "key SHA256:") {
+			t.Errorf("SignedBy = %q, want the key fingerprint", asset.SignedBy)
+		}
+		if asset.Checksums["sha-256"] == "" {
+			t.Errorf("expected the asset's checksum to be recorded, got %v", asset.Checksums)
+		}
+		if pkg.Specs[0].Checksum.Signer == nil || pkg.Specs[0].Checksum.Signer.Key == "" {
+			t.Error("expected the signer to be recorded for updates")
+		}
+	})
+
+	t.Run("tampered asset", func(t *testing.T) {
+		fetcher := &MockFetcher{FetchFn: func(ctx context.Context, u string) ([]byte, error) {
+			if strings.HasSuffix(u, ".sig") {
+				return sig, nil
+			}
+			return []byte("evil-binary"), nil
+		}}
+		m, home := newInstallManager(t, fetcher, &MockExtractor{ExtractFn: noExtract}, &MockVerifier{ComputeFn: defaultCompute}, "github", resolution)
+		err := m.Install(context.Background(), opts)
+		if err == nil || !strings.Contains(err.Error(), "verify signature") {
+			t.Fatalf("expected signature failure, got %v", err)
+		}
+		if entries := manifestFiles(t, filepath.Join(home, ".local", "share", "binmgr")); len(entries) != 0 {
+			t.Errorf("expected no manifest after a failed signature, got %d", len(entries))
+		}
+	})
+}
+
+// TestInstall_SigstoreSignedChecksumFile verifies that with a checksum file
+// glob the signature is checked over the checksum file before its digest is
+// trusted for the asset.
+func TestInstall_SigstoreSignedChecksumFile(t *testing.T) {
+	signer := newTestSigner(t)
+	assetData := []byte("tool-binary")
+	sum := sha256.Sum256(assetData)
+	sums := []byte(fmt.Sprintf("%s  tool-linux-amd64\n", hex.EncodeToString(sum[:])))
+
+	resolution := &backend.Resolution{
+		Version: "v1.0.0",
+		Assets: []backend.Asset{
+			{Name: "tool-linux-amd64", URL: "https://example.com/tool-linux-amd64"},
+			{Name: "SHA256SUMS", URL: "https://example.com/SHA256SUMS"},
+			{Name: "SHA256SUMS.sig", URL: "https://example.com/SHA256SUMS.sig"},
+		},
+	}
+	verifier := &MockVerifier{
+		VerifyFn: func(ctx context.Context, data []byte, expected map[string]string) error {
+			return verify.NewVerifier().Verify(ctx, strings.NewReader(string(data)), expected)
+		},
+		ComputeFn: defaultCompute,
+	}
+	opts := InstallOptions{
+		SourceURL: "https://example.com/owner/tool",
+		Specs: []SpecOpts{{
+			AssetGlob: "tool-linux-amd64",
+			LocalName: "tool",
+			Checksum:  ChecksumOpts{Strategy: "sigstore", FileGlob: "SHA256SUMS", Signer: signer.signer(t)},
+		}},
+	}
+
+	tests := []struct {
+		name    string
+		sumsSig []byte
+		wantErr bool
+	}{
+		{"signed by the key", signer.sign(t, sums), false},
+		{"signed by another key", newTestSigner(t).sign(t, sums), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := &MockFetcher{FetchFn: func(ctx context.Context, u string) ([]byte, error) {
+				switch {
+				case strings.HasSuffix(u, "SHA256SUMS.sig"):
+					return tt.sumsSig, nil
+				case strings.HasSuffix(u, "SHA256SUMS"):
+					return sums, nil
+				}
+				return assetData, nil
+			}}
+			m, home := newInstallManager(t, fetcher, &MockExtractor{ExtractFn: noExtract}, verifier, "github", resolution)
+			err := m.Install(context.Background(), opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected signature failure")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Install returned error: %v", err)
+			}
+			pkg, err := manifest.Load("example.com/owner/tool", filepath.Join(home, ".local", "share", "binmgr"))
+			if err != nil {
+				t.Fatalf("load manifest: %v", err)
+			}
+			if got := pkg.Specs[0].Asset.Checksums["sha-256"]; got != hex.EncodeToString(sum[:]) {
+				t.Errorf("recorded sha-256 = %q, want the signed checksum file's", got)
+			}
+		})
+	}
+}
+
+// TestResolveChecksums_SignedChecksumFile verifies that with a keyring the
+// shared checksum file is only trusted with a valid detached signature by
+// one of its keys, and that a missing signature fails closed.
+func TestResolveChecksums_SignedChecksumFile(t *testing.T) {
+	key, err := openpgp.NewEntity("release", "", "release@example.com", nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	other, err := openpgp.NewEntity("other", "", "other@example.com", nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	var pub bytes.Buffer
+	if err := key.Serialize(&pub); err != nil {
+		t.Fatal(err)
+	}
+	keyring, err := verify.ArmorKeyring(pub.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sums := []byte("abc123  tool.tar.gz\n")
+	detachSign := func(e *openpgp.Entity) []byte {
+		var sig bytes.Buffer
+		if err := openpgp.ArmoredDetachSign(&sig, e, bytes.NewReader(sums), nil); err != nil {
+			t.Fatal(err)
+		}
+		return sig.Bytes()
+	}
+
+	opts := ChecksumOpts{Strategy: "shared-file", FileGlob: "SHA256SUMS", Signer: &manifest.Signer{Keyring: keyring}}
+	tests := []struct {
+		name    string
+		sig     []byte // nil = no signature published
+		wantErr string
+	}{
+		{"signed by a keyring key", detachSign(key), ""},
+		{"signed by another key", detachSign(other), "verify signature"},
+		{"no signature", nil, "no signature found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolution := &backend.Resolution{Assets: []backend.Asset{
+				{Name: "tool.tar.gz", URL: "https://example.com/tool.tar.gz"},
+				{Name: "SHA256SUMS", URL: "https://example.com/SHA256SUMS"},
+			}}
+			if tt.sig != nil {
+				resolution.Assets = append(resolution.Assets, backend.Asset{Name: "SHA256SUMS.asc", URL: "https://example.com/SHA256SUMS.asc"})
+			}
+			fetcher := &MockFetcher{FetchFn: func(ctx context.Context, u string) ([]byte, error) {
+				switch u {
+				case "https://example.com/SHA256SUMS":
+					return sums, nil
+				case "https://example.com/SHA256SUMS.asc":
+					if tt.sig != nil {
+						return tt.sig, nil
+					}
+				}
+				return nil, fmt.Errorf("HTTP 404 from %s", u)
+			}}
+			m := newTestMgr(fetcher, &MockExtractor{})
+
+			got, err := m.resolveChecksums(context.Background(), opts, &resolution.Assets[0], "", resolution, "v1.0.0")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.checksums["sha-256"] != "abc123" {
+				t.Errorf("got %v, want sha-256 abc123", got.checksums)
+			}
+			if got.signedBy == "" {
+				t.Error("expected the checksum file signer to be recorded")
+			}
+		})
+	}
+
+	t.Run("backend-supplied checksums fail closed", func(t *testing.T) {
+		m := newTestMgr(&MockFetcher{}, &MockExtractor{})
+		asset := &backend.Asset{Name: "oc.tar.gz", Checksums: map[string]string{"sha-256": "abc"}}
+		_, err := m.resolveChecksums(context.Background(), opts, asset, "", &backend.Resolution{}, "")
+		if err == nil {
+			t.Error("expected error when a signature is required but cannot be checked")
+		}
+	})
+}
+
+// TestInstall_ReinstallChecksNewSigner verifies that reinstalling an intact
+// package with a checksum file signer is not skipped as already installed:
+// the signature must be checked and the signer recorded.
+func TestInstall_ReinstallChecksNewSigner(t *testing.T) {
+	key, err := openpgp.NewEntity("release", "", "release@example.com", nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	var pub bytes.Buffer
+	if err := key.Serialize(&pub); err != nil {
+		t.Fatal(err)
+	}
+	keyring, err := verify.ArmorKeyring(pub.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sums := []byte("abc123  tool-linux-amd64\n")
+	var sig bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&sig, key, bytes.NewReader(sums), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var fetched []string
+	fetcher := &MockFetcher{FetchFn: func(ctx context.Context, u string) ([]byte, error) {
+		fetched = append(fetched, u)
+		switch u {
+		case "https://example.com/SHA256SUMS":
+			return sums, nil
+		case "https://example.com/SHA256SUMS.sig":
+			return sig.Bytes(), nil
+		}
+		return []byte("tool-binary"), nil
+	}}
+	verifier := &MockVerifier{
+		VerifyFn:  func(ctx context.Context, data []byte, expected map[string]string) error { return nil },
+		ComputeFn: contentCompute,
+	}
+	resolution := &backend.Resolution{
+		Version: "v1.0.0",
+		Assets: []backend.Asset{
+			{Name: "tool-linux-amd64", URL: "https://example.com/tool-linux-amd64"},
+			{Name: "SHA256SUMS", URL: "https://example.com/SHA256SUMS"},
+			{Name: "SHA256SUMS.sig", URL: "https://example.com/SHA256SUMS.sig"},
+		},
+	}
+	m, home := newInstallManager(t, fetcher, &MockExtractor{ExtractFn: noExtract}, verifier, "github", resolution)
+
+	opts := InstallOptions{
+		SourceURL: "https://example.com/owner/tool",
+		Specs: []SpecOpts{{
+			AssetGlob: "tool-linux-amd64",
+			LocalName: "tool",
+			Checksum:  ChecksumOpts{Strategy: "shared-file", FileGlob: "SHA256SUMS"},
+		}},
+	}
+	if err := m.Install(context.Background(), opts); err != nil {
+		t.Fatalf("Install returned error: %v", err)
+	}
+
+	fetched = nil
+	opts.Specs[0].Checksum.Signer = &manifest.Signer{Keyring: keyring}
+	if err := m.Install(context.Background(), opts); err != nil {
+		t.Fatalf("reinstall returned error: %v", err)
+	}
+	if !slices.Contains(fetched, "https://example.com/SHA256SUMS.sig") {
+		t.Errorf("reinstall fetched %v, want the checksum file's signature", fetched)
+	}
+	pkg, err := manifest.Load("example.com/owner/tool", filepath.Join(home, ".local", "share", "binmgr"))
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	if pkg.Specs[0].Checksum.Signer == nil || pkg.Specs[0].Asset.SignedBy == "" {
+		t.Errorf("signer not recorded: checksum %+v, asset %+v", pkg.Specs[0].Checksum, pkg.Specs[0].Asset)
+	}
+}
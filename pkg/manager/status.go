@@ -8,10 +8,20 @@ import (
 	"github.com/ventifus/binmgr/pkg/manifest"
 )
 
+// Relations of the latest version to the installed one.
+const (
+	VersionNewer     = "newer"
+	VersionOlder     = "older"
+	VersionDifferent = "different" // the versions cannot be ordered
+)
+
 // Status reports whether a newer version is available for each installed
-// package without making any changes. If packages is empty, all installed
-// packages are checked; otherwise only the named packages are checked.
-func (m *mgr) Status(ctx context.Context, packages []string) ([]*StatusResult, error) {
+// package without making any changes. If opts.Packages is empty, all
+// installed packages are checked; otherwise only the named packages are
+// checked.
+func (m *mgr) Status(ctx context.Context, opts StatusOptions) ([]*StatusResult, error) {
+	packages := opts.Packages
+
 	// 1. Load packages.
 	var pkgs []*manifest.Package
 	if len(packages) == 0 {
@@ -56,7 +66,7 @@ func (m *mgr) Status(ctx context.Context, packages []string) ([]*StatusResult, e
 				return
 			}
 
-			resolution, err := b.Check(ctx, p)
+			resolution, err := checkPackage(ctx, b, p, opts.DefaultMinReleaseAge)
 			if err != nil {
 				mu.Lock()
 				if firstErr == nil {
@@ -66,13 +76,17 @@ func (m *mgr) Status(ctx context.Context, packages []string) ([]*StatusResult, e
 				return
 			}
 
+			change := compareRelease(p, resolution)
 			mu.Lock()
 			results[idx] = &StatusResult{
 				ID:               p.ID,
 				InstalledVersion: p.Version,
 				LatestVersion:    resolution.Version,
+				LatestOverall:    resolution.Latest,
+				HeldBack:         resolution.HeldBack,
+				Change:           change,
 				Pinned:           p.Pinned,
-				UpdateAvailable:  resolution.Version != p.Version,
+				UpdateAvailable:  change == VersionNewer || change == VersionDifferent,
 			}
 			mu.Unlock()
 		}(i, pkg)
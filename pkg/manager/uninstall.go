@@ -9,7 +9,8 @@ import (
 	"github.com/ventifus/binmgr/pkg/manifest"
 )
 
-// Uninstall removes all installed files and the manifest for each named package.
+// Uninstall removes all installed files, the manifest and any kept previous
+// versions for each named package.
 // It returns an error if any package ID is not found in the manifest, or if
 // removing an installed file fails for a reason other than it already being gone.
 func (m *mgr) Uninstall(_ context.Context, packages []string) error {
@@ -33,6 +34,9 @@ func (m *mgr) Uninstall(_ context.Context, packages []string) error {
 		if err := manifest.Delete(id, m.libDir); err != nil {
 			return err
 		}
+		if err := manifest.DeleteHistory(id, m.libDir); err != nil {
+			return err
+		}
 	}
 
 	return nil
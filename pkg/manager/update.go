@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/ventifus/binmgr/pkg/backend"
 	"github.com/ventifus/binmgr/pkg/manifest"
+	"github.com/ventifus/binmgr/pkg/version"
 )
 
 // Update updates installed packages and manages pin status.
@@ -15,8 +17,9 @@ import (
 // updated — pinned packages are not skipped when named directly.
 //
 // If opts.Unpin is set, each package's pin is cleared before checking for
-// updates. If opts.Pin is set, each package is pinned after a successful
-// update.
+// updates, and if opts.Constraint or opts.MinReleaseAge is set it replaces
+// each package's setting. If opts.Pin is set, each package is pinned after a
+// successful update.
 func (m *mgr) Update(ctx context.Context, opts UpdateOptions) ([]*UpdateResult, error) {
 	// 1. Load packages.
 	var pkgs []*manifest.Package
@@ -41,7 +44,7 @@ func (m *mgr) Update(ctx context.Context, opts UpdateOptions) ([]*UpdateResult,
 				return nil, fmt.Errorf("update: load package %q: %w", target.ID, err)
 			}
 			pkgs = append(pkgs, pkg)
-			if target.Version != "" {
+			if target.Version != "" || target.ReleaseID != 0 {
 				explicitTargets[target.ID] = target
 			}
 		}
@@ -63,11 +66,41 @@ func (m *mgr) Update(ctx context.Context, opts UpdateOptions) ([]*UpdateResult,
 		}
 	}
 
-	// 3. Check for updates in parallel: for each package, call backend.Check.
+	// 3. If opts.Constraint or opts.MinReleaseAge is set, replace each
+	//    package's setting and persist.
+	if opts.Constraint != nil && *opts.Constraint != "" {
+		if _, err := version.ParseConstraint(*opts.Constraint); err != nil {
+			return nil, fmt.Errorf("update: %w", err)
+		}
+	}
+	if opts.MinReleaseAge != nil && *opts.MinReleaseAge != "" {
+		if _, err := manifest.ParseAge(*opts.MinReleaseAge); err != nil {
+			return nil, fmt.Errorf("update: %w", err)
+		}
+	}
+	for _, pkg := range pkgs {
+		changed := false
+		if opts.Constraint != nil && pkg.Constraint != *opts.Constraint {
+			pkg.Constraint = *opts.Constraint
+			changed = true
+		}
+		if opts.MinReleaseAge != nil && pkg.MinReleaseAge != *opts.MinReleaseAge {
+			pkg.MinReleaseAge = *opts.MinReleaseAge
+			changed = true
+		}
+		if changed {
+			if err := manifest.Save(pkg, m.libDir); err != nil {
+				return nil, fmt.Errorf("update: save settings for %q: %w", pkg.ID, err)
+			}
+		}
+	}
+
+	// 4. Check for updates in parallel: for each package, call backend.Check.
 	type checkResult struct {
 		pkg        *manifest.Package
 		newVersion string // version to install
 		needUpdate bool   // true if installation should proceed
+		downgrade  bool   // newVersion is older than the installed version
 	}
 
 	checks := make([]checkResult, len(pkgs))
@@ -90,7 +123,7 @@ func (m *mgr) Update(ctx context.Context, opts UpdateOptions) ([]*UpdateResult,
 				return
 			}
 
-			resolution, err := b.Check(ctx, p)
+			resolution, err := checkPackage(ctx, b, p, opts.DefaultMinReleaseAge)
 			if err != nil {
 				mu.Lock()
 				if firstCheckErr == nil {
@@ -102,15 +135,25 @@ func (m *mgr) Update(ctx context.Context, opts UpdateOptions) ([]*UpdateResult,
 
 			target := explicitTargets[p.ID]
 			var newVersion string
-			var needUpdate bool
+			var needUpdate, downgrade bool
 
-			if target.Version != "" {
+			if target.Version != "" || target.ReleaseID != 0 {
 				// User explicitly requested a specific version — always reinstall.
+				// For a release ID the tag is only known once it is resolved.
 				newVersion = target.Version
 				needUpdate = true
 			} else {
 				newVersion = resolution.Version
-				needUpdate = resolution.Version != p.Version
+				switch compareRelease(p, resolution) {
+				case "":
+				case VersionOlder:
+					// The latest release was yanked or a backport was tagged
+					// later; only go back if asked to.
+					downgrade = true
+					needUpdate = opts.AllowDowngrade
+				default:
+					needUpdate = true
+				}
 			}
 
 			mu.Lock()
@@ -118,6 +161,7 @@ func (m *mgr) Update(ctx context.Context, opts UpdateOptions) ([]*UpdateResult,
 				pkg:        p,
 				newVersion: newVersion,
 				needUpdate: needUpdate,
+				downgrade:  downgrade,
 			}
 			mu.Unlock()
 		}(i, pkg)
@@ -129,7 +173,7 @@ func (m *mgr) Update(ctx context.Context, opts UpdateOptions) ([]*UpdateResult,
 		return nil, firstCheckErr
 	}
 
-	// 4. For each package that needs updating, run Install using stored specs.
+	// 5. For each package that needs updating, run Install using stored specs.
 	results := make([]*UpdateResult, 0, len(checks))
 
 	for i := range checks {
@@ -141,6 +185,7 @@ func (m *mgr) Update(ctx context.Context, opts UpdateOptions) ([]*UpdateResult,
 			OldVersion: pkg.Version,
 			NewVersion: cr.newVersion,
 			Updated:    false,
+			Downgrade:  cr.downgrade,
 		}
 
 		if !cr.needUpdate {
@@ -150,9 +195,15 @@ func (m *mgr) Update(ctx context.Context, opts UpdateOptions) ([]*UpdateResult,
 
 		// Reconstruct InstallOptions from the manifest's stored (unexpanded) specs.
 		installOpts := InstallOptions{
-			SourceURL: pkg.SourceURL,
-			Version:   cr.newVersion,
-			Pin:       pkg.Pinned,
+			SourceURL:     pkg.SourceURL,
+			BackendType:   pkg.Backend,
+			Version:       cr.newVersion,
+			ReleaseID:     explicitTargets[pkg.ID].ReleaseID,
+			Channel:       pkg.Channel,
+			Constraint:    pkg.Constraint,
+			MinReleaseAge: pkg.MinReleaseAge,
+			Provenance:    pkg.Provenance,
+			Pin:           pkg.Pinned,
 		}
 
 		// Reconstruct SpecOpts from each stored InstallSpec.
@@ -186,6 +237,7 @@ func (m *mgr) Update(ctx context.Context, opts UpdateOptions) ([]*UpdateResult,
 					OrderGlob:     spec.Checksum.OrderGlob,
 					Suffix:        spec.Checksum.Suffix,
 					TraversalGlob: spec.Checksum.TraversalGlob,
+					Signer:        spec.Checksum.Signer,
 				},
 			})
 		}
@@ -197,15 +249,19 @@ func (m *mgr) Update(ctx context.Context, opts UpdateOptions) ([]*UpdateResult,
 		result.Updated = true
 		result.NewVersion = cr.newVersion
 
-		// 5. If opts.Pin is set, reload the manifest and mark it pinned.
-		if opts.Pin {
+		// 6. Reload the manifest to learn the tag of a release targeted by ID,
+		//    and to mark it pinned if opts.Pin is set.
+		if opts.Pin || installOpts.ReleaseID != 0 {
 			updated, err := manifest.Load(pkg.ID, m.libDir)
 			if err != nil {
-				return nil, fmt.Errorf("update: reload manifest for pin %q: %w", pkg.ID, err)
+				return nil, fmt.Errorf("update: reload manifest for %q: %w", pkg.ID, err)
 			}
-			updated.Pinned = true
-			if err := manifest.Save(updated, m.libDir); err != nil {
-				return nil, fmt.Errorf("update: save pin for %q: %w", pkg.ID, err)
+			result.NewVersion = updated.Version
+			if opts.Pin {
+				updated.Pinned = true
+				if err := manifest.Save(updated, m.libDir); err != nil {
+					return nil, fmt.Errorf("update: save pin for %q: %w", pkg.ID, err)
+				}
 			}
 		}
 
@@ -214,3 +270,45 @@ func (m *mgr) Update(ctx context.Context, opts UpdateOptions) ([]*UpdateResult,
 
 	return results, nil
 }
+
+// checkPackage asks b for the latest release of p, applying defaultAge as
+// the minimum release age if p sets none.
+func checkPackage(ctx context.Context, b backend.Backend, p *manifest.Package, defaultAge string) (*backend.Resolution, error) {
+	if p.MinReleaseAge == "" && defaultAge != "" {
+		withAge := *p
+		withAge.MinReleaseAge = defaultAge
+		p = &withAge
+	}
+	return b.Check(ctx, p)
+}
+
+// sameRelease reports whether resolution names the release pkg has
+// installed. When both carry a numeric release ID it decides, so a release
+// that upstream retagged is not mistaken for a new one.
+func sameRelease(pkg *manifest.Package, resolution *backend.Resolution) bool {
+	if pkg.ReleaseID != 0 && resolution.ReleaseID != 0 {
+		return pkg.ReleaseID == resolution.ReleaseID
+	}
+	return pkg.Version == resolution.Version
+}
+
+// compareRelease reports how the release in resolution relates to the one
+// pkg has installed: "" for the same release, otherwise VersionNewer,
+// VersionOlder, or VersionDifferent when the versions cannot be ordered
+// (such as content hashes).
+func compareRelease(pkg *manifest.Package, resolution *backend.Resolution) string {
+	if sameRelease(pkg, resolution) {
+		return ""
+	}
+	c, ok := version.Compare(resolution.Version, pkg.Version)
+	switch {
+	case !ok:
+		return VersionDifferent
+	case c > 0:
+		return VersionNewer
+	case c < 0:
+		return VersionOlder
+	}
+	// Equal by ordering but spelled differently, e.g. "v1.2" and "1.2.0".
+	return VersionDifferent
+}
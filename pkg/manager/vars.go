@@ -1,15 +1,83 @@
 package manager
 
-import "strings"
+import (
+	"runtime"
+	"strings"
+)
 
-// ExpandVars replaces ${TAG} and ${VERSION} in pattern.
+// platformOS and platformArch name the platform that ${OS} / ${ARCH} style
+// variables resolve to. They are variables so tests can fake a platform.
+var (
+	platformOS   = runtime.GOOS
+	platformArch = runtime.GOARCH
+)
+
+// osTitle maps GOOS to the capitalised spelling used by many release names
+// (e.g. "Linux_x86_64"). GOOS values not listed are capitalised as-is.
+var osTitle = map[string]string{
+	"darwin":  "Darwin",
+	"freebsd": "FreeBSD",
+	"linux":   "Linux",
+	"netbsd":  "NetBSD",
+	"openbsd": "OpenBSD",
+	"windows": "Windows",
+}
+
+// archUname maps GOARCH to the `uname -m` spelling (e.g. "x86_64").
+// GOARCH values not listed are used as-is.
+var archUname = map[string]string{
+	"386":   "i686",
+	"amd64": "x86_64",
+	"arm":   "armv7l",
+	"arm64": "aarch64",
+}
+
+// archX maps GOARCH to the x64/x86 spelling used by Node.js, .NET and
+// others. GOARCH values not listed are used as-is.
+var archX = map[string]string{
+	"386":   "x86",
+	"amd64": "x64",
+}
+
+// ExpandVars replaces the release and platform variables in pattern.
+//
 // tag is the raw release tag (e.g. "v1.40.0", "knative-v1.19.5").
 // ${VERSION} is the tag with a leading "v" stripped (strings.TrimPrefix(tag, "v")).
 // If tag does not start with "v", ${TAG} and ${VERSION} are identical.
+//
+// Platform variables describe the running system, in the spellings release
+// assets commonly use:
+//
+//	${OS}          linux, darwin, windows   (Go GOOS)
+//	${OS_TITLE}    Linux, Darwin, Windows
+//	${ARCH}        amd64, arm64, 386, arm   (Go GOARCH)
+//	${ARCH_UNAME}  x86_64, aarch64, i686, armv7l   (uname -m)
+//	${ARCH_X}      x64, arm64, x86, arm
 func ExpandVars(pattern, tag string) string {
 	version := strings.TrimPrefix(tag, "v")
 	return strings.NewReplacer(
 		"${TAG}", tag,
 		"${VERSION}", version,
+		"${OS}", platformOS,
+		"${OS_TITLE}", lookupOr(osTitle, platformOS, title(platformOS)),
+		"${ARCH}", platformArch,
+		"${ARCH_UNAME}", lookupOr(archUname, platformArch, platformArch),
+		"${ARCH_X}", lookupOr(archX, platformArch, platformArch),
 	).Replace(pattern)
 }
+
+// lookupOr returns m[key], or def if key is not in m.
+func lookupOr(m map[string]string, key, def string) string {
+	if v, ok := m[key]; ok {
+		return v
+	}
+	return def
+}
+
+// title upper-cases the first letter of s.
+func title(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,83 @@
+package manager
+
+import "testing"
+
+func TestExpandVars(t *testing.T) {
+	tests := []struct {
+		name    string
+		os      string
+		arch    string
+		pattern string
+		tag     string
+		want    string
+	}{
+		{
+			name:    "tag and version",
+			os:      "linux",
+			arch:    "amd64",
+			pattern: "tool-${VERSION}-${TAG}.tar.gz",
+			tag:     "v1.2.3",
+			want:    "tool-1.2.3-v1.2.3.tar.gz",
+		},
+		{
+			name:    "go spelling",
+			os:      "linux",
+			arch:    "amd64",
+			pattern: "tool_${VERSION}_${OS}_${ARCH}.tar.gz",
+			tag:     "v1.2.3",
+			want:    "tool_1.2.3_linux_amd64.tar.gz",
+		},
+		{
+			name:    "uname spelling on arm64",
+			os:      "linux",
+			arch:    "arm64",
+			pattern: "tool-${ARCH_UNAME}-unknown-${OS}-musl",
+			tag:     "1.0.0",
+			want:    "tool-aarch64-unknown-linux-musl",
+		},
+		{
+			name:    "title case os",
+			os:      "darwin",
+			arch:    "amd64",
+			pattern: "tool_${OS_TITLE}_${ARCH_UNAME}.tar.gz",
+			tag:     "1.0.0",
+			want:    "tool_Darwin_x86_64.tar.gz",
+		},
+		{
+			name:    "x64 spelling",
+			os:      "windows",
+			arch:    "amd64",
+			pattern: "tool-${OS}-${ARCH_X}.zip",
+			tag:     "1.0.0",
+			want:    "tool-windows-x64.zip",
+		},
+		{
+			name:    "unmapped arch used as-is",
+			os:      "linux",
+			arch:    "riscv64",
+			pattern: "${ARCH}-${ARCH_UNAME}-${ARCH_X}",
+			tag:     "1.0.0",
+			want:    "riscv64-riscv64-riscv64",
+		},
+		{
+			name:    "unknown variable left alone",
+			os:      "linux",
+			arch:    "amd64",
+			pattern: "tool-${LIBC}",
+			tag:     "1.0.0",
+			want:    "tool-${LIBC}",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			oldOS, oldArch := platformOS, platformArch
+			platformOS, platformArch = tc.os, tc.arch
+			defer func() { platformOS, platformArch = oldOS, oldArch }()
+
+			if got := ExpandVars(tc.pattern, tc.tag); got != tc.want {
+				t.Errorf("ExpandVars(%q, %q) = %q, want %q", tc.pattern, tc.tag, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+
+	"github.com/ventifus/binmgr/pkg/extract"
+	"github.com/ventifus/binmgr/pkg/manifest"
+)
+
+// Problems reported for an installed file by Verify.
+const (
+	FileMissing       = "missing"
+	FileModified      = "modified"
+	FileNotExecutable = "not executable"
+)
+
+// Verify re-checks every installed file of each named package (all packages
+// if none are named) against the checksums recorded in its manifest. Files
+// that are missing, whose content changed, or that lost their exec bit are
+// reported. With opts.Repair, each affected spec's asset is downloaded again
+// from its recorded URL, checked against the recorded checksums, and the
+// affected files are restored. A package that cannot be repaired has the
+// reason recorded in its result, and the remaining packages are still
+// checked.
+func (m *mgr) Verify(ctx context.Context, opts VerifyOptions) ([]*VerifyResult, error) {
+	var pkgs []*manifest.Package
+	if len(opts.Packages) == 0 {
+		var err error
+		pkgs, err = manifest.LoadAll(m.libDir)
+		if err != nil {
+			return nil, fmt.Errorf("verify: load packages: %w", err)
+		}
+	} else {
+		pkgs = make([]*manifest.Package, 0, len(opts.Packages))
+		for _, id := range opts.Packages {
+			pkg, err := manifest.Load(id, m.libDir)
+			if err != nil {
+				return nil, fmt.Errorf("verify: load package %q: %w", id, err)
+			}
+			pkgs = append(pkgs, pkg)
+		}
+	}
+
+	results := make([]*VerifyResult, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		result := &VerifyResult{ID: pkg.ID}
+		for _, spec := range pkg.Specs {
+			for _, f := range spec.InstalledFiles {
+				problem, err := m.checkFile(ctx, f)
+				if err != nil {
+					return nil, fmt.Errorf("verify: %q: %w", f.LocalPath, err)
+				}
+				if problem != "" {
+					result.Problems = append(result.Problems, FileProblem{LocalPath: f.LocalPath, Problem: problem})
+				}
+			}
+		}
+
+		if opts.Repair && len(result.Problems) > 0 {
+			if err := m.repair(ctx, pkg, result.Problems); err != nil {
+				result.RepairErr = fmt.Errorf("repair %q: %w", pkg.ID, err)
+			} else {
+				result.Repaired = true
+			}
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// checkFile compares an installed file with its manifest record and returns
+// the problem found, or "" if it matches. A file with no recorded checksums
+// is only checked for existence and mode.
+func (m *mgr) checkFile(ctx context.Context, f manifest.InstalledFile) (string, error) {
+	info, err := os.Stat(f.LocalPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return FileMissing, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if len(f.Checksums) > 0 {
+		algorithms := make([]string, 0, len(f.Checksums))
+		for algo := range f.Checksums {
+			algorithms = append(algorithms, algo)
+		}
+		in, err := os.Open(f.LocalPath)
+		if err != nil {
+			return "", err
+		}
+		got, err := m.verifier.Compute(ctx, in, algorithms)
+		in.Close()
+		if err != nil {
+			return "", fmt.Errorf("compute checksums: %w", err)
+		}
+		for algo, want := range f.Checksums {
+			if got[algo] != want {
+				return FileModified, nil
+			}
+		}
+	}
+
+	if info.Mode().Perm()&0111 == 0 {
+		return FileNotExecutable, nil
+	}
+	return "", nil
+}
+
+// repair restores the files named in problems from a fresh download of the
+// asset each was installed from. The download is checked against the asset
+// checksums recorded at install time, and each restored file against its own
+// recorded checksum; a file with neither cannot be trusted and is an error.
+// All files are replaced together or not at all.
+func (m *mgr) repair(ctx context.Context, pkg *manifest.Package, problems []FileProblem) (retErr error) {
+	broken := make(map[string]bool, len(problems))
+	for _, p := range problems {
+		broken[p.LocalPath] = true
+	}
+
+	scratch, err := os.MkdirTemp("", "binmgr-")
+	if err != nil {
+		return fmt.Errorf("create scratch directory: %w", err)
+	}
+	defer os.RemoveAll(scratch)
+
+	var files fileSet
+	defer func() {
+		if retErr != nil {
+			if err := files.rollback(); err != nil {
+				retErr = fmt.Errorf("%w (rollback: %v)", retErr, err)
+			}
+		}
+	}()
+
+	downloads := make(map[string]string) // asset URL → spooled file
+	for _, spec := range pkg.Specs {
+		var targets []manifest.InstalledFile
+		for _, f := range spec.InstalledFiles {
+			if broken[f.LocalPath] {
+				targets = append(targets, f)
+			}
+		}
+		if len(targets) == 0 {
+			continue
+		}
+		if spec.Asset == nil || spec.Asset.URL == "" {
+			return fmt.Errorf("no download URL recorded for %q", targets[0].LocalPath)
+		}
+
+		assetName := spec.Asset.URL
+		if u, err := url.Parse(spec.Asset.URL); err == nil {
+			assetName = path.Base(u.Path)
+		}
+
+		spooled, ok := downloads[spec.Asset.URL]
+		if !ok {
+			var checksums map[string]string
+			if len(spec.Asset.Checksums) > 0 {
+				checksums = spec.Asset.Checksums
+			}
+			spooled, err = m.fetchVerified(ctx, assetName, spec.Asset.URL, checksums, scratch)
+			if err != nil {
+				return err
+			}
+			downloads[spec.Asset.URL] = spooled
+		}
+
+		var extracted []extract.ExtractedFile
+		if len(spec.TraversalGlobs) > 0 {
+			globs := make([]string, len(spec.TraversalGlobs))
+			for i, g := range spec.TraversalGlobs {
+				globs[i] = ExpandVars(g, pkg.Version)
+			}
+			extracted, err = m.traverse(ctx, assetName, spooled, globs, "", scratch)
+			if err != nil {
+				return fmt.Errorf("extract %q: %w", assetName, err)
+			}
+		} else {
+			payload, err := m.extractor.Decompress(ctx, assetName, spooled, scratch)
+			if err != nil {
+				return fmt.Errorf("decompress %q: %w", assetName, err)
+			}
+			extracted = []extract.ExtractedFile{{SourcePath: "", Path: payload}}
+		}
+
+		for _, f := range targets {
+			var src string
+			for _, e := range extracted {
+				if e.SourcePath == f.SourcePath {
+					src = e.Path
+					break
+				}
+			}
+			if src == "" {
+				return fmt.Errorf("%q no longer contains %q", assetName, f.SourcePath)
+			}
+
+			want := f.Checksums["sha-256"]
+			if want == "" && len(spec.Asset.Checksums) == 0 {
+				return fmt.Errorf("no recorded checksums to check a new copy of %q against", f.LocalPath)
+			}
+			checksums, err := m.stage(ctx, &files, src, f.LocalPath)
+			if err != nil {
+				return fmt.Errorf("stage %q: %w", f.LocalPath, err)
+			}
+			if want != "" && checksums["sha-256"] != want {
+				return fmt.Errorf("downloaded copy of %q does not match its recorded sha-256", f.LocalPath)
+			}
+		}
+	}
+
+	if err := files.commit(); err != nil {
+		return err
+	}
+	files.cleanup()
+	return nil
+}
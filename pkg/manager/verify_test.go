@@ -0,0 +1,180 @@
+package manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ventifus/binmgr/pkg/manifest"
+)
+
+// TestVerify_ReportsProblems verifies that modified, non-executable and
+// missing files are each reported, and an untouched install is clean.
+func TestVerify_ReportsProblems(t *testing.T) {
+	m, resolution, binPath, _ := newHistoryManager(t)
+	installVersions(t, m, resolution, "v1.0.0")
+
+	ctx := context.Background()
+	results, err := m.Verify(ctx, VerifyOptions{})
+	if err != nil {
+		t.Fatalf("Verify returned error: %v", err)
+	}
+	if len(results) != 1 || len(results[0].Problems) != 0 {
+		t.Fatalf("expected one clean result, got %+v", results)
+	}
+
+	tests := []struct {
+		name   string
+		damage func() error
+		want   string
+	}{
+		{
+			name:   "modified",
+			damage: func() error { return os.WriteFile(binPath, []byte("tampered"), 0755) },
+			want:   FileModified,
+		},
+		{
+			name:   "not executable",
+			damage: func() error { return os.Chmod(binPath, 0644) },
+			want:   FileNotExecutable,
+		},
+		{
+			name:   "missing",
+			damage: func() error { return os.Remove(binPath) },
+			want:   FileMissing,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			installVersions(t, m, resolution, "v1.0.0")
+			if err := tc.damage(); err != nil {
+				t.Fatal(err)
+			}
+			results, err := m.Verify(ctx, VerifyOptions{Packages: []string{historyPkgID}})
+			if err != nil {
+				t.Fatalf("Verify returned error: %v", err)
+			}
+			if len(results) != 1 || len(results[0].Problems) != 1 {
+				t.Fatalf("expected one problem, got %+v", results)
+			}
+			p := results[0].Problems[0]
+			if p.LocalPath != binPath || p.Problem != tc.want {
+				t.Errorf("problem = %+v, want %q for %q", p, tc.want, binPath)
+			}
+			if results[0].Repaired {
+				t.Error("Repaired set without --repair")
+			}
+		})
+	}
+}
+
+// TestVerify_Repair verifies that --repair re-downloads the asset and
+// restores the affected file.
+func TestVerify_Repair(t *testing.T) {
+	m, resolution, binPath, _ := newHistoryManager(t)
+	installVersions(t, m, resolution, "v1.0.0")
+
+	if err := os.WriteFile(binPath, []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	results, err := m.Verify(ctx, VerifyOptions{Repair: true})
+	if err != nil {
+		t.Fatalf("Verify returned error: %v", err)
+	}
+	if len(results) != 1 || len(results[0].Problems) != 1 || !results[0].Repaired {
+		t.Fatalf("expected one repaired problem, got %+v", results)
+	}
+	if got := readFile(t, binPath); got != "binary-v1.0.0" {
+		t.Errorf("repaired content = %q, want binary-v1.0.0", got)
+	}
+
+	results, err = m.Verify(ctx, VerifyOptions{})
+	if err != nil {
+		t.Fatalf("Verify returned error: %v", err)
+	}
+	if len(results[0].Problems) != 0 {
+		t.Errorf("expected clean verify after repair, got %+v", results[0].Problems)
+	}
+}
+
+// TestVerify_RepairRejectsDifferentContent verifies that a re-download
+// which no longer matches the recorded checksum is refused and the file is
+// left as it was.
+func TestVerify_RepairRejectsDifferentContent(t *testing.T) {
+	m, resolution, binPath, _ := newHistoryManager(t)
+	installVersions(t, m, resolution, "v1.0.0")
+
+	if err := os.WriteFile(binPath, []byte("tampered"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// The mock now serves different bytes from the same URL.
+	resolution.Version = "v1.0.0-rebuilt"
+
+	results, err := m.Verify(context.Background(), VerifyOptions{Repair: true})
+	if err != nil {
+		t.Fatalf("Verify returned error: %v", err)
+	}
+	if len(results) != 1 || results[0].Repaired || results[0].RepairErr == nil {
+		t.Fatalf("expected an unrepaired problem with its error, got %+v", results)
+	}
+	if got := readFile(t, binPath); got != "tampered" {
+		t.Errorf("content = %q, want file left untouched", got)
+	}
+}
+
+// TestVerify_RepairFailureKeepsOtherResults verifies that one package failing
+// to repair does not stop the others from being checked and repaired.
+func TestVerify_RepairFailureKeepsOtherResults(t *testing.T) {
+	m, resolution, binPath, libDir := newHistoryManager(t)
+	installVersions(t, m, resolution, "v1.0.0")
+
+	const otherID = "example.com/owner/other"
+	otherPath := filepath.Join(filepath.Dir(binPath), "other")
+	err := m.Install(context.Background(), InstallOptions{
+		SourceURL: "https://" + otherID,
+		Specs: []SpecOpts{
+			{AssetGlob: "mytool-linux-amd64", LocalName: "other", Checksum: ChecksumOpts{Strategy: "none"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Install %s: %v", otherID, err)
+	}
+
+	// Both files are damaged, but only the first package's recorded
+	// checksum still matches what a re-download gives.
+	for _, p := range []string{binPath, otherPath} {
+		if err := os.WriteFile(p, []byte("tampered"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	other, err := manifest.Load(otherID, libDir)
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	other.Specs[0].InstalledFiles[0].Checksums["sha-256"] = strings.Repeat("0", 64)
+	if err := manifest.Save(other, libDir); err != nil {
+		t.Fatalf("save manifest: %v", err)
+	}
+
+	results, err := m.Verify(context.Background(), VerifyOptions{Repair: true})
+	if err != nil {
+		t.Fatalf("Verify returned error: %v", err)
+	}
+	byID := make(map[string]*VerifyResult)
+	for _, r := range results {
+		byID[r.ID] = r
+	}
+	if r := byID[historyPkgID]; r == nil || !r.Repaired || r.RepairErr != nil {
+		t.Errorf("result for %s = %+v, want repaired", historyPkgID, r)
+	}
+	if r := byID[otherID]; r == nil || r.Repaired || r.RepairErr == nil || len(r.Problems) != 1 {
+		t.Errorf("result for %s = %+v, want one unrepaired problem with its error", otherID, r)
+	}
+	if got := readFile(t, binPath); got != "binary-v1.0.0" {
+		t.Errorf("repaired content = %q, want binary-v1.0.0", got)
+	}
+}
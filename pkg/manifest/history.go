@@ -0,0 +1,140 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// Snapshot is a previously installed version of a package, kept so it can be
+// restored without contacting the backend. Its directory holds the package
+// manifest as it was at the time and a copy of every installed file.
+type Snapshot struct {
+	Package *Package
+	Dir     string
+	seq     int
+}
+
+// snapshotManifest is the name of the manifest file inside a snapshot directory.
+const snapshotManifest = "manifest.json"
+
+// HistoryDir returns the directory holding the snapshots of the package with
+// the given ID. If dir is empty, LibDir() is used.
+func HistoryDir(id string, dir string) string {
+	return filepath.Join(effectiveDir(dir), "history", IDToFilename(id))
+}
+
+// File returns the path inside the snapshot of the copy of
+// pkg.Specs[spec].InstalledFiles[file].
+func (s *Snapshot) File(spec, file int) string {
+	return filepath.Join(s.Dir, fmt.Sprintf("%d-%d", spec, file))
+}
+
+// NewSnapshot creates an empty snapshot directory for pkg, newer than any
+// existing snapshot. The caller copies the installed files into place with
+// File and then calls Commit; until then the snapshot is incomplete and
+// LoadHistory skips it. If dir is empty, LibDir() is used.
+func NewSnapshot(pkg *Package, dir string) (*Snapshot, error) {
+	hd := HistoryDir(pkg.ID, dir)
+	entries, err := os.ReadDir(hd)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read history for %s: %w", pkg.ID, err)
+	}
+	// Number past incomplete snapshots too, so leftover files are never reused.
+	seq := 1
+	for _, entry := range entries {
+		if n, err := strconv.Atoi(entry.Name()); err == nil && n >= seq {
+			seq = n + 1
+		}
+	}
+
+	d := filepath.Join(hd, strconv.Itoa(seq))
+	if err := os.MkdirAll(d, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory for %s: %w", pkg.ID, err)
+	}
+	return &Snapshot{Package: pkg, Dir: d, seq: seq}, nil
+}
+
+// Commit records the snapshot's manifest, marking it complete. It must be
+// called only once every installed file has been copied in.
+func (s *Snapshot) Commit() error {
+	data, err := json.MarshalIndent(s.Package, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal package %s: %w", s.Package.ID, err)
+	}
+	// Write aside and rename, so a partly written manifest is never read.
+	tmp := filepath.Join(s.Dir, snapshotManifest+".tmp")
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write snapshot for %s: %w", s.Package.ID, err)
+	}
+	if err := os.Rename(tmp, filepath.Join(s.Dir, snapshotManifest)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write snapshot for %s: %w", s.Package.ID, err)
+	}
+	return nil
+}
+
+// LoadHistory returns the snapshots of the package with the given ID, newest
+// first. Returns nil (not an error) if there are none. If dir is empty,
+// LibDir() is used.
+func LoadHistory(id string, dir string) ([]*Snapshot, error) {
+	hd := HistoryDir(id, dir)
+	entries, err := os.ReadDir(hd)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read history for %s: %w", id, err)
+	}
+
+	var snapshots []*Snapshot
+	for _, entry := range entries {
+		seq, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		d := filepath.Join(hd, entry.Name())
+		data, err := os.ReadFile(filepath.Join(d, snapshotManifest))
+		if err != nil {
+			// A snapshot whose manifest was never written is incomplete.
+			continue
+		}
+		var pkg Package
+		if err := json.Unmarshal(data, &pkg); err != nil {
+			return nil, fmt.Errorf("failed to parse snapshot %s for %s: %w", entry.Name(), id, err)
+		}
+		snapshots = append(snapshots, &Snapshot{Package: &pkg, Dir: d, seq: seq})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].seq > snapshots[j].seq })
+	return snapshots, nil
+}
+
+// PruneHistory deletes all but the newest keep snapshots of the package with
+// the given ID. If dir is empty, LibDir() is used.
+func PruneHistory(id string, dir string, keep int) error {
+	history, err := LoadHistory(id, dir)
+	if err != nil {
+		return err
+	}
+	for i := keep; i < len(history); i++ {
+		if err := os.RemoveAll(history[i].Dir); err != nil {
+			return fmt.Errorf("failed to prune snapshot for %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// DeleteHistory removes every snapshot of the package with the given ID.
+// If dir is empty, LibDir() is used.
+func DeleteHistory(id string, dir string) error {
+	if err := os.RemoveAll(HistoryDir(id, dir)); err != nil {
+		return fmt.Errorf("failed to delete history for %s: %w", id, err)
+	}
+	return nil
+}
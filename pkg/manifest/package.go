@@ -1,12 +1,52 @@
 package manifest
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
 type Package struct {
-	ID        string        `json:"id"`
-	Backend   string        `json:"backend"`
-	SourceURL string        `json:"source_url"`
-	Version   string        `json:"version"`
-	Pinned    bool          `json:"pinned,omitempty"`
-	Specs     []InstallSpec `json:"specs"`
+	ID            string            `json:"id"`
+	Backend       string            `json:"backend"`
+	SourceURL     string            `json:"source_url"`
+	Version       string            `json:"version"`
+	ReleaseID     int64             `json:"release_id,omitempty"`
+	Pinned        bool              `json:"pinned,omitempty"`
+	Channel       *Channel          `json:"channel,omitempty"`
+	Constraint    string            `json:"constraint,omitempty"`      // version constraint, e.g. "~1.29"; empty = any
+	MinReleaseAge string            `json:"min_release_age,omitempty"` // skip releases younger than this, e.g. "7d"; empty = global default
+	Provenance    *ProvenanceConfig `json:"provenance,omitempty"`      // require SLSA provenance for each asset; nil = not checked
+	Specs         []InstallSpec     `json:"specs"`
+}
+
+// ParseAge parses a release age such as "7d", "2w" or "36h": a whole number
+// of days or weeks, or a Go duration.
+func ParseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.Atoi(n)
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("invalid release age %q", s)
+			}
+			return time.Duration(v) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid release age %q", s)
+	}
+	return d, nil
+}
+
+// Channel selects which releases count as the latest. A nil Channel follows
+// stable releases only.
+type Channel struct {
+	Prerelease bool   `json:"prerelease,omitempty"`
+	Draft      bool   `json:"draft,omitempty"`
+	TagGlob    string `json:"tag_glob,omitempty"`
+	TagRegex   string `json:"tag_regex,omitempty"`
 }
 
 type InstallSpec struct {
@@ -19,18 +59,48 @@ type InstallSpec struct {
 }
 
 type ChecksumConfig struct {
-	Strategy      string `json:"strategy"`
-	FileGlob      string `json:"file_glob,omitempty"`
-	Suffix        string `json:"suffix,omitempty"`
-	DataGlob      string `json:"data_glob,omitempty"`
-	OrderGlob     string `json:"order_glob,omitempty"`
-	TraversalGlob string `json:"traversal_glob,omitempty"`
+	Strategy      string  `json:"strategy"`
+	FileGlob      string  `json:"file_glob,omitempty"`
+	Suffix        string  `json:"suffix,omitempty"`
+	DataGlob      string  `json:"data_glob,omitempty"`
+	OrderGlob     string  `json:"order_glob,omitempty"`
+	TraversalGlob string  `json:"traversal_glob,omitempty"`
+	Signer        *Signer `json:"signer,omitempty"`
+}
+
+// Signer says whose signature is accepted. The "sigstore" checksum strategy
+// accepts the holder of a public key, or a certificate identity vouched for
+// by an issuer and chaining to trusted roots. The checksum file strategies
+// require a detached signature over the file when a keyring or minisign key
+// is set.
+type Signer struct {
+	Key      string `json:"key,omitempty"`      // PEM public key
+	Identity string `json:"identity,omitempty"` // certificate email address or URI
+	Issuer   string `json:"issuer,omitempty"`   // OIDC issuer recorded in the certificate
+	Roots    string `json:"roots,omitempty"`    // PEM CA certificates
+	Rekor    string `json:"rekor,omitempty"`    // PEM public key of the transparency log
+	Keyring  string `json:"keyring,omitempty"`  // armored OpenPGP public keys
+	Minisign string `json:"minisign,omitempty"` // minisign public key
+}
+
+// ProvenanceConfig says which SLSA provenance attestations are accepted for
+// a package's assets. The source repository must be the package's SourceURL.
+type ProvenanceConfig struct {
+	Key     string `json:"key"`               // PEM public key the attestation must be signed with
+	Builder string `json:"builder,omitempty"` // trusted builder ID; empty = any builder
 }
 
+// DownloadedAsset records the asset a spec was installed from and how it
+// was verified.
 type DownloadedAsset struct {
-	URL               string            `json:"url"`
-	Checksums         map[string]string `json:"checksums"`
-	ChecksumSourceURL string            `json:"checksum_source_url,omitempty"`
+	URL                string            `json:"url"`
+	Checksums          map[string]string `json:"checksums"`
+	ChecksumSourceURL  string            `json:"checksum_source_url,omitempty"` // where the checksums were read from
+	ChecksumStrategy   string            `json:"checksum_strategy,omitempty"`   // strategy that supplied the checksums; "auto" records the one it chose
+	VerifiedAlgorithms []string          `json:"verified_algorithms,omitempty"` // algorithms the download was checked against
+	SignedBy           string            `json:"signed_by,omitempty"`           // verified signer of the asset or its checksum file: key fingerprint or certificate identity
+	Builder            string            `json:"builder,omitempty"`             // builder ID from verified provenance
+	SourceCommit       string            `json:"source_commit,omitempty"`       // source commit from verified provenance
 }
 
 type InstalledFile struct {
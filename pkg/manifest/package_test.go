@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 // ==============================
@@ -361,3 +362,132 @@ func TestLoadAll_AllValid(t *testing.T) {
 		t.Errorf("LoadAll returned %d packages, want %d", len(packages), len(ids))
 	}
 }
+
+// ==============================
+// History tests
+// ==============================
+
+func TestHistory_NewestFirstAndPrune(t *testing.T) {
+	dir := t.TempDir()
+
+	versions := []string{"v1", "v2", "v3", "v4"}
+	for _, v := range versions {
+		pkg := &Package{ID: "owner/repo", Backend: "github", Version: v}
+		s, err := NewSnapshot(pkg, dir)
+		if err != nil {
+			t.Fatalf("NewSnapshot(%s) failed: %v", v, err)
+		}
+		if err := s.Commit(); err != nil {
+			t.Fatalf("Commit(%s) failed: %v", v, err)
+		}
+	}
+
+	history, err := LoadHistory("owner/repo", dir)
+	if err != nil {
+		t.Fatalf("LoadHistory failed: %v", err)
+	}
+	if len(history) != len(versions) {
+		t.Fatalf("LoadHistory returned %d snapshots, want %d", len(history), len(versions))
+	}
+	for i, s := range history {
+		want := versions[len(versions)-1-i]
+		if s.Package.Version != want {
+			t.Errorf("history[%d].Version = %q, want %q", i, s.Package.Version, want)
+		}
+	}
+
+	if err := PruneHistory("owner/repo", dir, 2); err != nil {
+		t.Fatalf("PruneHistory failed: %v", err)
+	}
+	history, err = LoadHistory("owner/repo", dir)
+	if err != nil {
+		t.Fatalf("LoadHistory failed: %v", err)
+	}
+	if len(history) != 2 || history[0].Package.Version != "v4" || history[1].Package.Version != "v3" {
+		t.Errorf("after prune, history = %d snapshots, want v4 and v3", len(history))
+	}
+
+	// The history directory must not be mistaken for a manifest.
+	if err := Save(&Package{ID: "owner/repo", Version: "v5"}, dir); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	packages, err := LoadAll(dir)
+	if err != nil {
+		t.Fatalf("LoadAll failed: %v", err)
+	}
+	if len(packages) != 1 {
+		t.Errorf("LoadAll returned %d packages, want 1", len(packages))
+	}
+}
+
+func TestHistory_UncommittedSnapshotSkipped(t *testing.T) {
+	dir := t.TempDir()
+
+	pending, err := NewSnapshot(&Package{ID: "owner/repo", Version: "v1"}, dir)
+	if err != nil {
+		t.Fatalf("NewSnapshot failed: %v", err)
+	}
+	history, err := LoadHistory("owner/repo", dir)
+	if err != nil {
+		t.Fatalf("LoadHistory failed: %v", err)
+	}
+	if len(history) != 0 {
+		t.Fatalf("LoadHistory returned %d snapshots before Commit, want 0", len(history))
+	}
+
+	// A later snapshot must not reuse the incomplete one's directory.
+	s, err := NewSnapshot(&Package{ID: "owner/repo", Version: "v2"}, dir)
+	if err != nil {
+		t.Fatalf("NewSnapshot failed: %v", err)
+	}
+	if s.Dir == pending.Dir {
+		t.Errorf("NewSnapshot reused incomplete snapshot directory %s", s.Dir)
+	}
+	if err := s.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	history, err = LoadHistory("owner/repo", dir)
+	if err != nil {
+		t.Fatalf("LoadHistory failed: %v", err)
+	}
+	if len(history) != 1 || history[0].Package.Version != "v2" {
+		t.Errorf("LoadHistory = %d snapshots, want only v2", len(history))
+	}
+}
+
+func TestLoadHistory_None(t *testing.T) {
+	history, err := LoadHistory("owner/repo", t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadHistory failed: %v", err)
+	}
+	if history != nil {
+		t.Errorf("LoadHistory = %v, want nil", history)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"7d", 7 * 24 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+		{"36h", 36 * time.Hour},
+		{"0", 0},
+	}
+	for _, tt := range tests {
+		got, err := ParseAge(tt.in)
+		if err != nil {
+			t.Errorf("ParseAge(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAge(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	for _, in := range []string{"", "d", "1.5d", "-3d", "-1h", "week"} {
+		if _, err := ParseAge(in); err == nil {
+			t.Errorf("ParseAge(%q) succeeded, want error", in)
+		}
+	}
+}
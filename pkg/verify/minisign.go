@@ -0,0 +1,90 @@
+package verify
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// minisignKey is a decoded minisign public key.
+type minisignKey struct {
+	id  uint64
+	key ed25519.PublicKey
+}
+
+// ParseMinisignKey checks a minisign public key: either the base64 key line
+// or the whole ".pub" file, whose comment line is ignored. It returns the
+// key's ID as minisign prints it.
+func ParseMinisignKey(s string) (string, error) {
+	k, err := parseMinisignKey(s)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%016X", k.id), nil
+}
+
+func parseMinisignKey(s string) (*minisignKey, error) {
+	var line string
+	for _, l := range strings.Split(strings.TrimSpace(s), "\n") {
+		if l = strings.TrimSpace(l); l != "" && !strings.HasPrefix(l, "untrusted comment:") {
+			line = l
+			break
+		}
+	}
+	b, err := base64.StdEncoding.DecodeString(line)
+	if err != nil || len(b) != 2+8+ed25519.PublicKeySize || string(b[:2]) != "Ed" {
+		return nil, errors.New("invalid minisign public key")
+	}
+	return &minisignKey{id: binary.LittleEndian.Uint64(b[2:10]), key: ed25519.PublicKey(b[10:])}, nil
+}
+
+// VerifyMinisign checks that sig, the content of a ".minisig" file, was made
+// over data by pubkey, including the signature over its trusted comment. Both
+// legacy and prehashed signatures are accepted. It returns the key ID.
+func VerifyMinisign(data, sig []byte, pubkey string) (string, error) {
+	k, err := parseMinisignKey(pubkey)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(sig)), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return "", errors.New("malformed minisign signature")
+	}
+	s, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(s) != 2+8+ed25519.SignatureSize {
+		return "", errors.New("malformed minisign signature")
+	}
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return "", errors.New("malformed minisign signature")
+	}
+
+	if id := binary.LittleEndian.Uint64(s[2:10]); id != k.id {
+		return "", fmt.Errorf("minisign signature is by key %016X, want %016X", id, k.id)
+	}
+	msg := data
+	switch string(s[:2]) {
+	case "Ed":
+	case "ED":
+		sum := blake2b.Sum512(data)
+		msg = sum[:]
+	default:
+		return "", fmt.Errorf("unsupported minisign signature algorithm %q", s[:2])
+	}
+	if !ed25519.Verify(k.key, msg, s[10:]) {
+		return "", errors.New("minisign signature mismatch")
+	}
+
+	comment := strings.TrimSuffix(strings.TrimPrefix(lines[2], "trusted comment: "), "\r")
+	if !ed25519.Verify(k.key, append(bytes.Clone(s[10:]), comment...), global) {
+		return "", errors.New("minisign trusted comment signature mismatch")
+	}
+	return fmt.Sprintf("minisign %016X", k.id), nil
+}
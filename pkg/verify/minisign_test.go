@@ -0,0 +1,104 @@
+package verify
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// testMinisignKey is a locally generated minisign key pair.
+type testMinisignKey struct {
+	id   [8]byte
+	priv ed25519.PrivateKey
+}
+
+func newMinisignKey(t *testing.T) *testMinisignKey {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	k := &testMinisignKey{priv: priv}
+	if _, err := rand.Read(k.id[:]); err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+// pub returns the key's ".pub" file.
+func (k *testMinisignKey) pub() string {
+	b := append([]byte("Ed"), k.id[:]...)
+	b = append(b, k.priv.Public().(ed25519.PublicKey)...)
+	return "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(b) + "\n"
+}
+
+// sign returns a ".minisig" file for data, prehashed unless legacy is set.
+func (k *testMinisignKey) sign(data []byte, legacy bool) []byte {
+	alg, msg := "ED", data
+	if legacy {
+		alg = "Ed"
+	} else {
+		sum := blake2b.Sum512(data)
+		msg = sum[:]
+	}
+	sig := ed25519.Sign(k.priv, msg)
+	comment := "timestamp:1700000000\tfile:SHA256SUMS"
+	global := ed25519.Sign(k.priv, append(append([]byte{}, sig...), comment...))
+
+	b := append([]byte(alg), k.id[:]...)
+	b = append(b, sig...)
+	return []byte("untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(b) + "\n" +
+		"trusted comment: " + comment + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n")
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	key := newMinisignKey(t)
+	sums := []byte("e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  zig.tar.xz\n")
+
+	for _, legacy := range []bool{false, true} {
+		signer, err := VerifyMinisign(sums, key.sign(sums, legacy), key.pub())
+		if err != nil {
+			t.Fatalf("VerifyMinisign(legacy=%v): %v", legacy, err)
+		}
+		if !strings.HasPrefix(signer, "minisign ") {
+			t.Errorf("signer = %q, want the key ID", signer)
+		}
+	}
+
+	// The bare key line is accepted as well as the .pub file.
+	keyLine := strings.Split(key.pub(), "\n")[1]
+	if _, err := VerifyMinisign(sums, key.sign(sums, false), keyLine); err != nil {
+		t.Errorf("VerifyMinisign with the bare key line: %v", err)
+	}
+
+	t.Run("tampered checksum file", func(t *testing.T) {
+		if _, err := VerifyMinisign([]byte("0000  zig.tar.xz\n"), key.sign(sums, false), key.pub()); err == nil {
+			t.Error("expected error for a tampered checksum file")
+		}
+	})
+
+	t.Run("other key", func(t *testing.T) {
+		if _, err := VerifyMinisign(sums, newMinisignKey(t).sign(sums, false), key.pub()); err == nil {
+			t.Error("expected error for a signature by another key")
+		}
+	})
+
+	t.Run("tampered trusted comment", func(t *testing.T) {
+		sig := strings.Replace(string(key.sign(sums, false)), "file:SHA256SUMS", "file:OTHER", 1)
+		if _, err := VerifyMinisign(sums, []byte(sig), key.pub()); err == nil {
+			t.Error("expected error for a tampered trusted comment")
+		}
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		if _, err := ParseMinisignKey("RWQnotakey"); err == nil {
+			t.Error("expected error for an invalid key")
+		}
+	})
+}
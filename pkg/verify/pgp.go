@@ -0,0 +1,72 @@
+package verify
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+// VerifyPGP checks that sig, an armored or binary detached OpenPGP
+// signature, was made over data by a key in keyring. It returns the
+// signer's fingerprint and primary user ID.
+func VerifyPGP(data, sig, keyring []byte) (string, error) {
+	keys, err := readKeyring(keyring)
+	if err != nil {
+		return "", err
+	}
+	check := openpgp.CheckDetachedSignature
+	if bytes.HasPrefix(bytes.TrimSpace(sig), []byte("-----BEGIN")) {
+		check = openpgp.CheckArmoredDetachedSignature
+	}
+	signer, err := check(keys, bytes.NewReader(data), bytes.NewReader(sig), nil)
+	if err != nil {
+		return "", fmt.Errorf("pgp signature: %w", err)
+	}
+	desc := fmt.Sprintf("pgp %X", signer.PrimaryKey.Fingerprint)
+	if id := signer.PrimaryIdentity(); id != nil {
+		desc += " (" + id.Name + ")"
+	}
+	return desc, nil
+}
+
+// ArmorKeyring checks that keyring, armored or binary, holds at least one
+// OpenPGP public key and returns the keys armored, fit for storing as text.
+func ArmorKeyring(keyring []byte) (string, error) {
+	keys, err := readKeyring(keyring)
+	if err != nil {
+		return "", err
+	}
+	var buf strings.Builder
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return "", err
+	}
+	for _, k := range keys {
+		if err := k.Serialize(w); err != nil {
+			return "", fmt.Errorf("serialize key %X: %w", k.PrimaryKey.Fingerprint, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return buf.String() + "\n", nil
+}
+
+// readKeyring parses an armored or binary OpenPGP keyring.
+func readKeyring(keyring []byte) (openpgp.EntityList, error) {
+	read := openpgp.ReadKeyRing
+	if bytes.HasPrefix(bytes.TrimSpace(keyring), []byte("-----BEGIN")) {
+		read = openpgp.ReadArmoredKeyRing
+	}
+	keys, err := read(bytes.NewReader(keyring))
+	if err != nil {
+		return nil, fmt.Errorf("read keyring: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("read keyring: no keys")
+	}
+	return keys, nil
+}
@@ -0,0 +1,80 @@
+package verify
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+// newPGPKey generates an OpenPGP key and returns it with its public keyring
+// in binary form.
+func newPGPKey(t *testing.T, name string) (*openpgp.Entity, []byte) {
+	t.Helper()
+	e, err := openpgp.NewEntity(name, "", name+"@example.com", nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	var keyring bytes.Buffer
+	if err := e.Serialize(&keyring); err != nil {
+		t.Fatalf("serialize key: %v", err)
+	}
+	return e, keyring.Bytes()
+}
+
+func TestVerifyPGP(t *testing.T) {
+	key, binKeyring := newPGPKey(t, "release")
+	other, _ := newPGPKey(t, "other")
+	sums := []byte("e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  tool.tar.gz\n")
+
+	keyring, err := ArmorKeyring(binKeyring)
+	if err != nil {
+		t.Fatalf("ArmorKeyring: %v", err)
+	}
+	if !strings.HasPrefix(keyring, "-----BEGIN PGP PUBLIC KEY BLOCK-----") {
+		t.Errorf("ArmorKeyring did not armor the keyring: %q", keyring[:40])
+	}
+
+	var armored, binary, byOther bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&armored, key, bytes.NewReader(sums), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := openpgp.DetachSign(&binary, key, bytes.NewReader(sums), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := openpgp.DetachSign(&byOther, other, bytes.NewReader(sums), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, sig := range map[string][]byte{"armored": armored.Bytes(), "binary": binary.Bytes()} {
+		t.Run(name, func(t *testing.T) {
+			signer, err := VerifyPGP(sums, sig, []byte(keyring))
+			if err != nil {
+				t.Fatalf("VerifyPGP: %v", err)
+			}
+			if !strings.Contains(signer, "release <release@example.com>") {
+				t.Errorf("signer = %q, want the key's user ID", signer)
+			}
+		})
+	}
+
+	t.Run("tampered checksum file", func(t *testing.T) {
+		tampered := bytes.Replace(sums, []byte("e3b0"), []byte("0000"), 1)
+		if _, err := VerifyPGP(tampered, armored.Bytes(), []byte(keyring)); err == nil {
+			t.Error("expected error for a tampered checksum file")
+		}
+	})
+
+	t.Run("key not in keyring", func(t *testing.T) {
+		if _, err := VerifyPGP(sums, byOther.Bytes(), []byte(keyring)); err == nil {
+			t.Error("expected error for a signature by a key outside the keyring")
+		}
+	})
+
+	t.Run("not a keyring", func(t *testing.T) {
+		if _, err := ArmorKeyring([]byte("not a key")); err == nil {
+			t.Error("expected error for a file without keys")
+		}
+	})
+}
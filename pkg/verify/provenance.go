@@ -0,0 +1,237 @@
+package verify
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Provenance is what a verified SLSA provenance attestation says about how
+// an artifact was built.
+type Provenance struct {
+	Builder      string // builder ID
+	SourceRepo   string // repository URI the build was made from
+	SourceCommit string // commit the build was made from; empty if not stated
+}
+
+// ProvenancePolicy says which attestations are accepted: signed with Key,
+// for a build from SourceRepo, and by Builder if set.
+type ProvenancePolicy struct {
+	Key        []byte // PEM public key the DSSE envelope must be signed with
+	Builder    string // trusted builder ID; without an "@ref" suffix any ref matches; empty = any builder
+	SourceRepo string // repository the build must come from, e.g. "https://github.com/owner/repo"
+}
+
+// inTotoPayloadType is the DSSE payload type of an in-toto statement.
+const inTotoPayloadType = "application/vnd.in-toto+json"
+
+type dsseEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+	Signatures  []struct {
+		Sig string `json:"sig"`
+	} `json:"signatures"`
+}
+
+type inTotoStatement struct {
+	Subject []struct {
+		Name   string            `json:"name"`
+		Digest map[string]string `json:"digest"`
+	} `json:"subject"`
+	PredicateType string          `json:"predicateType"`
+	Predicate     json.RawMessage `json:"predicate"`
+}
+
+// resourceRef is a source or material reference in a SLSA predicate.
+type resourceRef struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest"`
+}
+
+type slsaV02 struct {
+	Builder struct {
+		ID string `json:"id"`
+	} `json:"builder"`
+	Invocation struct {
+		ConfigSource resourceRef `json:"configSource"`
+	} `json:"invocation"`
+	Materials []resourceRef `json:"materials"`
+}
+
+type slsaV1 struct {
+	BuildDefinition struct {
+		ResolvedDependencies []resourceRef `json:"resolvedDependencies"`
+	} `json:"buildDefinition"`
+	RunDetails struct {
+		Builder struct {
+			ID string `json:"id"`
+		} `json:"builder"`
+	} `json:"runDetails"`
+}
+
+// VerifyProvenance checks the SLSA provenance in data, an in-toto ".jsonl"
+// file of DSSE envelopes (bare, or wrapped in Sigstore bundles), for the
+// artifact whose SHA-256 is digest. An attestation is accepted if its
+// subject has that digest, its envelope is signed with p.Key, and its
+// builder and source repository are the ones p names. The transparency log
+// and any certificate in a bundle are not checked.
+func VerifyProvenance(data []byte, digest string, p ProvenancePolicy) (*Provenance, error) {
+	pub, err := parsePublicKey(p.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	err = errors.New("no attestation names the artifact as its subject")
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var line struct {
+			dsseEnvelope
+			Bundle *dsseEnvelope `json:"dsseEnvelope"`
+		}
+		if derr := dec.Decode(&line); derr == io.EOF {
+			break
+		} else if derr != nil {
+			return nil, fmt.Errorf("parse provenance: %w", derr)
+		}
+		env := &line.dsseEnvelope
+		if line.Bundle != nil {
+			env = line.Bundle
+		}
+		if env.PayloadType != inTotoPayloadType {
+			continue
+		}
+		payload, derr := base64.StdEncoding.DecodeString(env.Payload)
+		if derr != nil {
+			return nil, fmt.Errorf("parse provenance: payload: %w", derr)
+		}
+		var st inTotoStatement
+		if derr := json.Unmarshal(payload, &st); derr != nil {
+			return nil, fmt.Errorf("parse provenance: statement: %w", derr)
+		}
+		if !hasSubject(&st, digest) {
+			continue
+		}
+
+		// Only the first attestation for the artifact counts; a bad one is
+		// not skipped in favour of another.
+		if err := checkEnvelope(env, payload, pub); err != nil {
+			return nil, err
+		}
+		prov, err := parsePredicate(&st)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkProvenance(prov, p); err != nil {
+			return nil, err
+		}
+		return prov, nil
+	}
+	return nil, err
+}
+
+// hasSubject reports whether st attests to the artifact with SHA-256 digest.
+func hasSubject(st *inTotoStatement, digest string) bool {
+	for _, s := range st.Subject {
+		if d := s.Digest["sha256"]; d != "" && strings.EqualFold(d, digest) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkEnvelope checks that one of env's signatures over payload, encoded
+// as the DSSE pre-authentication encoding, verifies with pub.
+func checkEnvelope(env *dsseEnvelope, payload []byte, pub crypto.PublicKey) error {
+	pae := fmt.Sprintf("DSSEv1 %d %s %d ", len(env.PayloadType), env.PayloadType, len(payload))
+	h := sha256.New()
+	h.Write([]byte(pae))
+	h.Write(payload)
+	digest := h.Sum(nil)
+	for _, s := range env.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err != nil {
+			continue
+		}
+		if checkSignature(pub, digest, sig) == nil {
+			return nil
+		}
+	}
+	return errors.New("provenance signature mismatch")
+}
+
+// parsePredicate extracts the builder and source from a SLSA v0.2 or v1
+// provenance predicate.
+func parsePredicate(st *inTotoStatement) (*Provenance, error) {
+	var src resourceRef
+	prov := &Provenance{}
+	switch st.PredicateType {
+	case "https://slsa.dev/provenance/v0.2":
+		var pred slsaV02
+		if err := json.Unmarshal(st.Predicate, &pred); err != nil {
+			return nil, fmt.Errorf("parse provenance predicate: %w", err)
+		}
+		prov.Builder = pred.Builder.ID
+		src = pred.Invocation.ConfigSource
+		if src.URI == "" && len(pred.Materials) > 0 {
+			src = pred.Materials[0]
+		}
+	case "https://slsa.dev/provenance/v1":
+		var pred slsaV1
+		if err := json.Unmarshal(st.Predicate, &pred); err != nil {
+			return nil, fmt.Errorf("parse provenance predicate: %w", err)
+		}
+		prov.Builder = pred.RunDetails.Builder.ID
+		if len(pred.BuildDefinition.ResolvedDependencies) > 0 {
+			src = pred.BuildDefinition.ResolvedDependencies[0]
+		}
+	default:
+		return nil, fmt.Errorf("unsupported provenance predicate type %q", st.PredicateType)
+	}
+	prov.SourceRepo = src.URI
+	prov.SourceCommit = src.Digest["gitCommit"]
+	if prov.SourceCommit == "" {
+		prov.SourceCommit = src.Digest["sha1"]
+	}
+	return prov, nil
+}
+
+// checkProvenance checks prov's builder and source repository against p.
+func checkProvenance(prov *Provenance, p ProvenancePolicy) error {
+	if prov.Builder == "" {
+		return errors.New("provenance names no builder")
+	}
+	if p.Builder != "" && prov.Builder != p.Builder && !strings.HasPrefix(prov.Builder, p.Builder+"@") {
+		return fmt.Errorf("provenance builder %q is not %q", prov.Builder, p.Builder)
+	}
+	if prov.SourceRepo == "" {
+		return errors.New("provenance names no source repository")
+	}
+	if p.SourceRepo != "" && repoKey(prov.SourceRepo) != repoKey(p.SourceRepo) {
+		return fmt.Errorf("provenance source %q is not %q", prov.SourceRepo, p.SourceRepo)
+	}
+	return nil
+}
+
+// repoKey reduces a repository URI to "host/path" for comparison, dropping
+// the "git+" prefix, scheme, user, "@ref" suffix and ".git" extension:
+// "git+https://github.com/o/r.git@refs/tags/v1" → "github.com/o/r".
+func repoKey(uri string) string {
+	s := strings.TrimPrefix(uri, "git+")
+	if _, rest, ok := strings.Cut(s, "://"); ok {
+		s = rest
+	}
+	if host, _, ok := strings.Cut(s, "/"); ok {
+		if i := strings.LastIndex(host, "@"); i >= 0 {
+			s = s[i+1:]
+		}
+	}
+	s, _, _ = strings.Cut(s, "@")
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "/"), ".git")
+	return strings.ToLower(s)
+}
@@ -0,0 +1,146 @@
+package verify
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const (
+	testDigest  = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	testBuilder = "https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_generic_slsa3.yml"
+	testCommit  = "0123456789abcdef0123456789abcdef01234567"
+)
+
+// slsaV02Statement returns an in-toto statement with a SLSA v0.2 predicate
+// for an artifact with the given SHA-256.
+func slsaV02Statement(digest, builder, repo string) map[string]any {
+	return map[string]any{
+		"_type":         "https://in-toto.io/Statement/v0.1",
+		"subject":       []any{map[string]any{"name": "tool", "digest": map[string]string{"sha256": digest}}},
+		"predicateType": "https://slsa.dev/provenance/v0.2",
+		"predicate": map[string]any{
+			"builder": map[string]string{"id": builder},
+			"invocation": map[string]any{
+				"configSource": map[string]any{"uri": "git+" + repo + "@refs/tags/v1.0.0", "digest": map[string]string{"sha1": testCommit}},
+			},
+		},
+	}
+}
+
+// dsseLine signs statement with key and returns it as one line of an
+// ".intoto.jsonl" file.
+func dsseLine(t *testing.T, key *ecdsa.PrivateKey, statement map[string]any) string {
+	t.Helper()
+	payload, err := json.Marshal(statement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pae := fmt.Sprintf("DSSEv1 %d %s %d %s", len(inTotoPayloadType), inTotoPayloadType, len(payload), payload)
+	digest := sha256.Sum256([]byte(pae))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	line, err := json.Marshal(map[string]any{
+		"payloadType": inTotoPayloadType,
+		"payload":     base64.StdEncoding.EncodeToString(payload),
+		"signatures":  []any{map[string]string{"sig": base64.StdEncoding.EncodeToString(sig)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(line) + "\n"
+}
+
+func TestVerifyProvenance(t *testing.T) {
+	key := newKey(t)
+	policy := ProvenancePolicy{Key: publicKeyPEM(t, key), Builder: testBuilder, SourceRepo: "https://github.com/owner/tool"}
+	good := dsseLine(t, key, slsaV02Statement(testDigest, testBuilder+"@refs/tags/v2.0.0", "https://github.com/owner/tool"))
+
+	t.Run("v0.2", func(t *testing.T) {
+		prov, err := VerifyProvenance([]byte(good), testDigest, policy)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if prov.SourceCommit != testCommit || !strings.HasPrefix(prov.Builder, testBuilder) {
+			t.Errorf("got %+v", prov)
+		}
+	})
+
+	t.Run("v1 in a bundle", func(t *testing.T) {
+		statement := map[string]any{
+			"_type":         "https://in-toto.io/Statement/v1",
+			"subject":       []any{map[string]any{"name": "tool", "digest": map[string]string{"sha256": testDigest}}},
+			"predicateType": "https://slsa.dev/provenance/v1",
+			"predicate": map[string]any{
+				"buildDefinition": map[string]any{
+					"resolvedDependencies": []any{map[string]any{
+						"uri":    "git+https://github.com/owner/tool@refs/heads/main",
+						"digest": map[string]string{"gitCommit": testCommit},
+					}},
+				},
+				"runDetails": map[string]any{"builder": map[string]string{"id": "https://github.com/actions/runner/github-hosted"}},
+			},
+		}
+		bundle := `{"mediaType":"application/vnd.dev.sigstore.bundle.v0.3+json","dsseEnvelope":` + strings.TrimSpace(dsseLine(t, key, statement)) + "}\n"
+		p := policy
+		p.Builder = ""
+		prov, err := VerifyProvenance([]byte(bundle), testDigest, p)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if prov.SourceCommit != testCommit || prov.Builder != "https://github.com/actions/runner/github-hosted" {
+			t.Errorf("got %+v", prov)
+		}
+	})
+
+	t.Run("subject among several", func(t *testing.T) {
+		other := dsseLine(t, key, slsaV02Statement(strings.Repeat("0", 64), testBuilder, "https://github.com/owner/tool"))
+		if _, err := VerifyProvenance([]byte(other+good), testDigest, policy); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	rejected := []struct {
+		name   string
+		data   string
+		digest string // empty = testDigest
+	}{
+		{"other artifact", good, strings.Repeat("1", 64)},
+		{"other key", dsseLine(t, newKey(t), slsaV02Statement(testDigest, testBuilder, "https://github.com/owner/tool")), ""},
+		{"other builder", dsseLine(t, key, slsaV02Statement(testDigest, "https://example.com/builder", "https://github.com/owner/tool")), ""},
+		{"other source", dsseLine(t, key, slsaV02Statement(testDigest, testBuilder, "https://github.com/evil/tool")), ""},
+		{"builder prefix without ref separator", dsseLine(t, key, slsaV02Statement(testDigest, testBuilder+"-fork", "https://github.com/owner/tool")), ""},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			digest := tt.digest
+			if digest == "" {
+				digest = testDigest
+			}
+			if _, err := VerifyProvenance([]byte(tt.data), digest, policy); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestRepoKey(t *testing.T) {
+	tests := map[string]string{
+		"git+https://github.com/Owner/Tool.git@refs/tags/v1.0.0": "github.com/owner/tool",
+		"https://github.com/owner/tool":                          "github.com/owner/tool",
+		"git+ssh://git@github.com/owner/tool":                    "github.com/owner/tool",
+		"github.com/owner/tool/":                                 "github.com/owner/tool",
+	}
+	for in, want := range tests {
+		if got := repoKey(in); got != want {
+			t.Errorf("repoKey(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,484 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signature is a detached signature over a blob, as published by cosign or
+// in a Sigstore bundle. Keyless signatures carry the short-lived certificate
+// that vouches for the signing key.
+type Signature struct {
+	Value  []byte              // raw signature bytes
+	Cert   *x509.Certificate   // signing certificate; nil for key-based signatures
+	Chain  []*x509.Certificate // intermediates shipped with the certificate
+	Digest []byte              // SHA-256 of the blob the bundle says was signed; nil if not stated
+	Entry  *LogEntry           // transparency log entry from the bundle; nil if none
+}
+
+// LogEntry is a transparency log entry as a bundle carries it, with the
+// signed entry timestamp (SET) by which the log promises to include it.
+// Nothing in it is trusted until the SET is checked against the log's key.
+type LogEntry struct {
+	Body           []byte // canonicalized entry body
+	IntegratedTime int64  // seconds since the epoch
+	LogIndex       int64
+	LogID          string // hex SHA-256 of the log's public key
+	SET            []byte
+}
+
+// SignaturePolicy says whose signature is accepted: either the holder of
+// Key, or a certificate issued by one of Roots to Identity by Issuer.
+type SignaturePolicy struct {
+	Key      []byte // PEM public key; if set, the certificate fields are ignored
+	Identity string // certificate subject alternative name: an email address or URI
+	Issuer   string // OIDC issuer recorded in the certificate
+	Roots    []byte // PEM CA certificates the signing certificate must chain to
+	LogKey   []byte // PEM public key of the transparency log; empty = ignore log entries
+}
+
+// Fulcio certificate extensions naming the OIDC issuer. The first holds the
+// raw string; its replacement holds a DER-encoded UTF8String.
+var (
+	oidIssuerV1 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	oidIssuerV2 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+)
+
+// ParseCosignSignature decodes a cosign signature file (".sig") and, for a
+// keyless signature, its certificate file (".pem" or ".cert"). Both may be
+// base64-encoded, as cosign writes them, or raw. cert may be nil.
+func ParseCosignSignature(sig, cert []byte) (*Signature, error) {
+	s := &Signature{Value: decodeBase64(sig)}
+	if len(s.Value) == 0 {
+		return nil, errors.New("empty signature")
+	}
+	if len(cert) > 0 {
+		certs, err := parseCertificates(cert)
+		if err != nil {
+			return nil, fmt.Errorf("parse certificate: %w", err)
+		}
+		s.Cert, s.Chain = certs[0], certs[1:]
+	}
+	return s, nil
+}
+
+// sigstoreBundle covers both the bundle cosign writes with --bundle and the
+// Sigstore bundle format (".sigstore.json").
+type sigstoreBundle struct {
+	Base64Signature string `json:"base64Signature"`
+	Cert            string `json:"cert"`
+	RekorBundle     *struct {
+		SignedEntryTimestamp []byte `json:"SignedEntryTimestamp"`
+		Payload              struct {
+			Body           string `json:"body"`
+			IntegratedTime int64  `json:"integratedTime"`
+			LogIndex       int64  `json:"logIndex"`
+			LogID          string `json:"logID"`
+		} `json:"Payload"`
+	} `json:"rekorBundle"`
+
+	MessageSignature *struct {
+		MessageDigest *struct {
+			Algorithm string `json:"algorithm"`
+			Digest    []byte `json:"digest"`
+		} `json:"messageDigest"`
+		Signature []byte `json:"signature"`
+	} `json:"messageSignature"`
+	VerificationMaterial *struct {
+		Certificate *struct {
+			RawBytes []byte `json:"rawBytes"`
+		} `json:"certificate"`
+		X509CertificateChain *struct {
+			Certificates []struct {
+				RawBytes []byte `json:"rawBytes"`
+			} `json:"certificates"`
+		} `json:"x509CertificateChain"`
+		TlogEntries []struct {
+			LogIndex string `json:"logIndex"`
+			LogID    struct {
+				KeyID []byte `json:"keyId"`
+			} `json:"logId"`
+			IntegratedTime   string `json:"integratedTime"`
+			InclusionPromise *struct {
+				SignedEntryTimestamp []byte `json:"signedEntryTimestamp"`
+			} `json:"inclusionPromise"`
+			CanonicalizedBody []byte `json:"canonicalizedBody"`
+		} `json:"tlogEntries"`
+	} `json:"verificationMaterial"`
+}
+
+// ParseSigstoreBundle decodes a cosign bundle or a Sigstore bundle holding a
+// message signature. The transparency log entry is kept as is; it is only
+// checked by VerifySignature, and only if the policy names the log's key.
+func ParseSigstoreBundle(data []byte) (*Signature, error) {
+	var b sigstoreBundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("parse bundle: %w", err)
+	}
+
+	if b.Base64Signature != "" {
+		s, err := ParseCosignSignature([]byte(b.Base64Signature), []byte(b.Cert))
+		if err != nil {
+			return nil, err
+		}
+		if rb := b.RekorBundle; rb != nil && len(rb.SignedEntryTimestamp) > 0 {
+			body, err := base64.StdEncoding.DecodeString(rb.Payload.Body)
+			if err != nil {
+				return nil, fmt.Errorf("parse bundle log entry: %w", err)
+			}
+			s.Entry = &LogEntry{
+				Body:           body,
+				IntegratedTime: rb.Payload.IntegratedTime,
+				LogIndex:       rb.Payload.LogIndex,
+				LogID:          rb.Payload.LogID,
+				SET:            rb.SignedEntryTimestamp,
+			}
+		}
+		return s, nil
+	}
+
+	if b.MessageSignature == nil || len(b.MessageSignature.Signature) == 0 {
+		return nil, errors.New("bundle has no message signature")
+	}
+	s := &Signature{Value: b.MessageSignature.Signature}
+	if d := b.MessageSignature.MessageDigest; d != nil {
+		if d.Algorithm != "SHA2_256" {
+			return nil, fmt.Errorf("unsupported bundle digest algorithm %q", d.Algorithm)
+		}
+		s.Digest = d.Digest
+	}
+	if vm := b.VerificationMaterial; vm != nil {
+		var raw [][]byte
+		switch {
+		case vm.Certificate != nil:
+			raw = [][]byte{vm.Certificate.RawBytes}
+		case vm.X509CertificateChain != nil:
+			for _, c := range vm.X509CertificateChain.Certificates {
+				raw = append(raw, c.RawBytes)
+			}
+		}
+		for i, der := range raw {
+			c, err := x509.ParseCertificate(der)
+			if err != nil {
+				return nil, fmt.Errorf("parse bundle certificate: %w", err)
+			}
+			if i == 0 {
+				s.Cert = c
+			} else {
+				s.Chain = append(s.Chain, c)
+			}
+		}
+		if len(vm.TlogEntries) > 0 && vm.TlogEntries[0].InclusionPromise != nil {
+			e := vm.TlogEntries[0]
+			integrated, err := strconv.ParseInt(e.IntegratedTime, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parse bundle log entry: %w", err)
+			}
+			index, err := strconv.ParseInt(e.LogIndex, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parse bundle log entry: %w", err)
+			}
+			s.Entry = &LogEntry{
+				Body:           e.CanonicalizedBody,
+				IntegratedTime: integrated,
+				LogIndex:       index,
+				LogID:          hex.EncodeToString(e.LogID.KeyID),
+				SET:            e.InclusionPromise.SignedEntryTimestamp,
+			}
+		}
+	}
+	return s, nil
+}
+
+// VerifySignature reads r to EOF and checks that sig is a valid signature
+// over its content by a signer p accepts. It returns a description of the
+// signer: the key's fingerprint, or the certificate identity and issuer.
+//
+// A keyless certificate is checked at the current time, unless p names the
+// transparency log's key and the bundle's log entry carries a valid SET
+// for this very signature: then it is checked at the time the entry was
+// logged. Short-lived certificates have usually expired by the time an
+// asset is installed, so verifying them offline needs the log's key.
+func VerifySignature(ctx context.Context, r io.Reader, sig *Signature, p SignaturePolicy) (string, error) {
+	h := sha256.New()
+	if err := hashAll(ctx, r, map[string]hash.Hash{"sha-256": h}); err != nil {
+		return "", err
+	}
+	digest := h.Sum(nil)
+	if sig.Digest != nil && !bytes.Equal(sig.Digest, digest) {
+		return "", errors.New("signature was made over different content")
+	}
+
+	if len(p.Key) > 0 {
+		pub, err := parsePublicKey(p.Key)
+		if err != nil {
+			return "", err
+		}
+		if err := checkSignature(pub, digest, sig.Value); err != nil {
+			return "", err
+		}
+		return "key " + fingerprint(pub), nil
+	}
+
+	if p.Identity == "" || p.Issuer == "" || len(p.Roots) == 0 {
+		return "", errors.New("no public key, or certificate identity, issuer and roots, to check the signature against")
+	}
+	if sig.Cert == nil {
+		return "", errors.New("signature has no certificate and no public key is configured")
+	}
+	at := time.Now()
+	if len(p.LogKey) > 0 && sig.Entry != nil {
+		logged, err := checkLogEntry(sig, digest, p.LogKey)
+		if err != nil {
+			return "", fmt.Errorf("transparency log entry: %w", err)
+		}
+		at = logged
+	}
+	if err := checkCertificate(sig, p, at); err != nil {
+		return "", err
+	}
+	if err := checkSignature(sig.Cert.PublicKey, digest, sig.Value); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s (%s)", p.Identity, p.Issuer), nil
+}
+
+// checkCertificate checks that sig's certificate chains to p.Roots at time
+// at and was issued to p.Identity by p.Issuer.
+func checkCertificate(sig *Signature, p SignaturePolicy, at time.Time) error {
+	trusted, err := parseCertificates(p.Roots)
+	if err != nil {
+		return fmt.Errorf("parse trusted roots: %w", err)
+	}
+	roots, inter := x509.NewCertPool(), x509.NewCertPool()
+	for _, c := range trusted {
+		if bytes.Equal(c.RawIssuer, c.RawSubject) {
+			roots.AddCert(c)
+		} else {
+			inter.AddCert(c)
+		}
+	}
+	for _, c := range sig.Chain {
+		inter.AddCert(c)
+	}
+
+	if _, err := sig.Cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: inter,
+		CurrentTime:   at,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}); err != nil {
+		return fmt.Errorf("certificate not trusted: %w", err)
+	}
+
+	if !hasIdentity(sig.Cert, p.Identity) {
+		return fmt.Errorf("certificate not issued to %q", p.Identity)
+	}
+	if got := certIssuer(sig.Cert); got != p.Issuer {
+		return fmt.Errorf("certificate issued by %q, want %q", got, p.Issuer)
+	}
+	return nil
+}
+
+// hashedRekord is the part of a "hashedrekord" log entry body that ties it
+// to a signature.
+type hashedRekord struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Signature struct {
+			Content   []byte `json:"content"`
+			PublicKey struct {
+				Content []byte `json:"content"`
+			} `json:"publicKey"`
+		} `json:"signature"`
+		Data struct {
+			Hash struct {
+				Algorithm string `json:"algorithm"`
+				Value     string `json:"value"`
+			} `json:"hash"`
+		} `json:"data"`
+	} `json:"spec"`
+}
+
+// checkLogEntry checks sig's log entry against the log's public key and
+// returns the time it was logged. The SET must be the log's signature over
+// the entry, and the entry must record sig's signature, certificate and
+// digest; otherwise a SET copied from another entry would vouch for any
+// time the bundle's author liked.
+func checkLogEntry(sig *Signature, digest, logKey []byte) (time.Time, error) {
+	pub, err := parsePublicKey(logKey)
+	if err != nil {
+		return time.Time{}, err
+	}
+	e := sig.Entry
+
+	// The SET signs the canonical JSON of these fields: keys sorted, no
+	// whitespace, which is what encoding/json produces for this struct.
+	payload, err := json.Marshal(struct {
+		Body           string `json:"body"`
+		IntegratedTime int64  `json:"integratedTime"`
+		LogID          string `json:"logID"`
+		LogIndex       int64  `json:"logIndex"`
+	}{base64.StdEncoding.EncodeToString(e.Body), e.IntegratedTime, e.LogID, e.LogIndex})
+	if err != nil {
+		return time.Time{}, err
+	}
+	sum := sha256.Sum256(payload)
+	if err := checkSignature(pub, sum[:], e.SET); err != nil {
+		return time.Time{}, fmt.Errorf("signed entry timestamp: %w", err)
+	}
+
+	var body hashedRekord
+	if err := json.Unmarshal(e.Body, &body); err != nil {
+		return time.Time{}, fmt.Errorf("parse entry: %w", err)
+	}
+	if body.Kind != "hashedrekord" {
+		return time.Time{}, fmt.Errorf("unsupported entry kind %q", body.Kind)
+	}
+	if h := body.Spec.Data.Hash; h.Algorithm != "sha256" || h.Value != hex.EncodeToString(digest) {
+		return time.Time{}, errors.New("entry is for different content")
+	}
+	if !bytes.Equal(body.Spec.Signature.Content, sig.Value) {
+		return time.Time{}, errors.New("entry is for a different signature")
+	}
+	certs, err := parseCertificates(body.Spec.Signature.PublicKey.Content)
+	if err != nil || !certs[0].Equal(sig.Cert) {
+		return time.Time{}, errors.New("entry is for a different certificate")
+	}
+	return time.Unix(e.IntegratedTime, 0), nil
+}
+
+// hasIdentity reports whether c names id as an email address or URI.
+func hasIdentity(c *x509.Certificate, id string) bool {
+	if slices.Contains(c.EmailAddresses, id) {
+		return true
+	}
+	for _, u := range c.URIs {
+		if u.String() == id {
+			return true
+		}
+	}
+	return false
+}
+
+// certIssuer returns the OIDC issuer recorded in a Fulcio certificate, or ""
+// if there is none.
+func certIssuer(c *x509.Certificate) string {
+	for _, ext := range c.Extensions {
+		if ext.Id.Equal(oidIssuerV2) {
+			var s string
+			if _, err := asn1.Unmarshal(ext.Value, &s); err == nil {
+				return s
+			}
+		}
+	}
+	for _, ext := range c.Extensions {
+		if ext.Id.Equal(oidIssuerV1) {
+			return string(ext.Value)
+		}
+	}
+	return ""
+}
+
+// checkSignature checks sig over a SHA-256 digest with an ECDSA or RSA key.
+func checkSignature(pub crypto.PublicKey, digest, sig []byte) error {
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		if ecdsa.VerifyASN1(k, digest, sig) {
+			return nil
+		}
+	case *rsa.PublicKey:
+		if rsa.VerifyPKCS1v15(k, crypto.SHA256, digest, sig) == nil ||
+			rsa.VerifyPSS(k, crypto.SHA256, digest, sig, nil) == nil {
+			return nil
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+	return errors.New("signature mismatch")
+}
+
+// parsePublicKey parses a PEM-encoded PKIX public key.
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("public key is not PEM-encoded")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse public key: %w", err)
+	}
+	return pub, nil
+}
+
+// fingerprint returns the SHA-256 fingerprint of a public key, in the
+// "SHA256:..." form ssh-keygen prints.
+func fingerprint(pub crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "unknown"
+	}
+	sum := sha256.Sum256(der)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// parseCertificates parses one or more PEM certificates, which may
+// themselves be base64-encoded, or a single DER certificate.
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	data = bytes.TrimSpace(data)
+	if !bytes.HasPrefix(data, []byte("-----BEGIN")) {
+		data = decodeBase64(data)
+	}
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, c)
+	}
+	if len(certs) == 0 {
+		c, err := x509.ParseCertificate(data)
+		if err != nil {
+			return nil, errors.New("no certificate found")
+		}
+		certs = append(certs, c)
+	}
+	return certs, nil
+}
+
+// decodeBase64 returns the base64 decoding of data, or data itself if it is
+// not valid base64.
+func decodeBase64(data []byte) []byte {
+	s := strings.Join(strings.Fields(string(data)), "")
+	if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return b
+	}
+	return data
+}
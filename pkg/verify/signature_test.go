@@ -0,0 +1,345 @@
+package verify
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testIdentity = "release@example.com"
+	testIssuer   = "https://accounts.example.com"
+)
+
+// signBlob signs the SHA-256 of blob with key, as cosign sign-blob does.
+func signBlob(t *testing.T, key *ecdsa.PrivateKey, blob string) []byte {
+	t.Helper()
+	digest := sha256.Sum256([]byte(blob))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return sig
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return key
+}
+
+func publicKeyPEM(t *testing.T, key *ecdsa.PrivateKey) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+// testCA is a locally generated stand-in for the Fulcio CA.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key := newKey(t)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create root: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse root: %v", err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) pem() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})
+}
+
+// issue returns a ten-minute signing certificate for key, issued to email
+// by issuer and valid from notBefore, as Fulcio issues them.
+func (ca *testCA) issue(t *testing.T, key *ecdsa.PrivateKey, email, issuer string, notBefore time.Time) *x509.Certificate {
+	t.Helper()
+	issuerExt, err := asn1.Marshal(issuer)
+	if err != nil {
+		t.Fatalf("marshal issuer: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:    big.NewInt(2),
+		NotBefore:       notBefore,
+		NotAfter:        notBefore.Add(10 * time.Minute),
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		EmailAddresses:  []string{email},
+		ExtraExtensions: []pkix.Extension{{Id: oidIssuerV2, Value: issuerExt}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("create leaf: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse leaf: %v", err)
+	}
+	return cert
+}
+
+func TestVerifySignature_Key(t *testing.T) {
+	ctx := context.Background()
+	key := newKey(t)
+	sigFile := []byte(base64.StdEncoding.EncodeToString(signBlob(t, key, "hello")) + "\n")
+	sig, err := ParseCosignSignature(sigFile, nil)
+	if err != nil {
+		t.Fatalf("ParseCosignSignature: %v", err)
+	}
+
+	t.Run("matching key", func(t *testing.T) {
+		signer, err := VerifySignature(ctx, strings.NewReader("hello"), sig, SignaturePolicy{Key: publicKeyPEM(t, key)})
+		if err != nil {
+			t.Fatalf("VerifySignature: %v", err)
+		}
+		if !strings.HasPrefix(signer, "key SHA256:") {
+			t.Errorf("signer = %q, want a key fingerprint", signer)
+		}
+	})
+
+	t.Run("tampered content", func(t *testing.T) {
+		_, err := VerifySignature(ctx, strings.NewReader("hellO"), sig, SignaturePolicy{Key: publicKeyPEM(t, key)})
+		if err == nil || !strings.Contains(err.Error(), "mismatch") {
+			t.Errorf("expected signature mismatch, got %v", err)
+		}
+	})
+
+	t.Run("other key", func(t *testing.T) {
+		_, err := VerifySignature(ctx, strings.NewReader("hello"), sig, SignaturePolicy{Key: publicKeyPEM(t, newKey(t))})
+		if err == nil {
+			t.Error("expected error for a signature by another key")
+		}
+	})
+
+	t.Run("no policy", func(t *testing.T) {
+		if _, err := VerifySignature(ctx, strings.NewReader("hello"), sig, SignaturePolicy{}); err == nil {
+			t.Error("expected error with nothing to check against")
+		}
+	})
+}
+
+// testLog is a locally generated stand-in for the Rekor transparency log.
+type testLog struct {
+	key *ecdsa.PrivateKey
+	id  string
+}
+
+func newTestLog(t *testing.T) *testLog {
+	t.Helper()
+	key := newKey(t)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal log key: %v", err)
+	}
+	id := sha256.Sum256(der)
+	return &testLog{key: key, id: hex.EncodeToString(id[:])}
+}
+
+// entry returns a hashedrekord entry body for a signature over blob, and
+// the log's SET over it as if it were logged at integrated.
+func (l *testLog) entry(t *testing.T, blob string, rawSig, certPEM []byte, integrated time.Time) (body, set []byte) {
+	t.Helper()
+	digest := sha256.Sum256([]byte(blob))
+	body, _ = json.Marshal(map[string]any{
+		"apiVersion": "0.0.1",
+		"kind":       "hashedrekord",
+		"spec": map[string]any{
+			"data": map[string]any{"hash": map[string]any{"algorithm": "sha256", "value": hex.EncodeToString(digest[:])}},
+			"signature": map[string]any{
+				"content":   rawSig,
+				"publicKey": map[string]any{"content": certPEM},
+			},
+		},
+	})
+	payload, _ := json.Marshal(map[string]any{
+		"body":           base64.StdEncoding.EncodeToString(body),
+		"integratedTime": integrated.Unix(),
+		"logID":          l.id,
+		"logIndex":       42,
+	})
+	sum := sha256.Sum256(payload)
+	set, err := ecdsa.SignASN1(rand.Reader, l.key, sum[:])
+	if err != nil {
+		t.Fatalf("sign entry: %v", err)
+	}
+	return body, set
+}
+
+// cosignBundle returns a bundle as cosign writes it with --bundle.
+func (l *testLog) cosignBundle(rawSig, certPEM, body, set []byte, integrated time.Time) []byte {
+	b, _ := json.Marshal(map[string]any{
+		"base64Signature": base64.StdEncoding.EncodeToString(rawSig),
+		"cert":            base64.StdEncoding.EncodeToString(certPEM),
+		"rekorBundle": map[string]any{
+			"SignedEntryTimestamp": set,
+			"Payload": map[string]any{
+				"body":           base64.StdEncoding.EncodeToString(body),
+				"integratedTime": integrated.Unix(),
+				"logIndex":       42,
+				"logID":          l.id,
+			},
+		},
+	})
+	return b
+}
+
+func TestVerifySignature_Keyless(t *testing.T) {
+	ctx := context.Background()
+	ca := newTestCA(t)
+	log := newTestLog(t)
+	key := newKey(t)
+	signedAt := time.Now().Add(-30 * time.Minute).Truncate(time.Second)
+	cert := ca.issue(t, key, testIdentity, testIssuer, signedAt.Add(-time.Minute))
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	rawSig := signBlob(t, key, "hello")
+	body, set := log.entry(t, "hello", rawSig, certPEM, signedAt)
+
+	policy := SignaturePolicy{Identity: testIdentity, Issuer: testIssuer, Roots: ca.pem(), LogKey: publicKeyPEM(t, log.key)}
+
+	// The certificate has expired by now; bundles carry the logged time it
+	// was valid at, which the log's SET vouches for.
+	cosignBundle := log.cosignBundle(rawSig, certPEM, body, set, signedAt)
+	digest := sha256.Sum256([]byte("hello"))
+	logID, _ := hex.DecodeString(log.id)
+	sigstoreBundle, _ := json.Marshal(map[string]any{
+		"mediaType": "application/vnd.dev.sigstore.bundle.v0.3+json",
+		"verificationMaterial": map[string]any{
+			"certificate": map[string]any{"rawBytes": cert.Raw},
+			"tlogEntries": []any{map[string]any{
+				"logIndex":          "42",
+				"logId":             map[string]any{"keyId": logID},
+				"integratedTime":    strconv.FormatInt(signedAt.Unix(), 10),
+				"inclusionPromise":  map[string]any{"signedEntryTimestamp": set},
+				"canonicalizedBody": body,
+			}},
+		},
+		"messageSignature": map[string]any{
+			"messageDigest": map[string]any{"algorithm": "SHA2_256", "digest": digest[:]},
+			"signature":     rawSig,
+		},
+	})
+
+	for name, data := range map[string][]byte{"cosign bundle": cosignBundle, "sigstore bundle": sigstoreBundle} {
+		t.Run(name, func(t *testing.T) {
+			sig, err := ParseSigstoreBundle(data)
+			if err != nil {
+				t.Fatalf("ParseSigstoreBundle: %v", err)
+			}
+			signer, err := VerifySignature(ctx, strings.NewReader("hello"), sig, policy)
+			if err != nil {
+				t.Fatalf("VerifySignature: %v", err)
+			}
+			if want := testIdentity + " (" + testIssuer + ")"; signer != want {
+				t.Errorf("signer = %q, want %q", signer, want)
+			}
+		})
+	}
+
+	sig, err := ParseSigstoreBundle(cosignBundle)
+	if err != nil {
+		t.Fatalf("ParseSigstoreBundle: %v", err)
+	}
+	rejects := map[string]SignaturePolicy{
+		"wrong identity": {Identity: "someone@example.com", Issuer: testIssuer, Roots: ca.pem(), LogKey: policy.LogKey},
+		"wrong issuer":   {Identity: testIdentity, Issuer: "https://evil.example.com", Roots: ca.pem(), LogKey: policy.LogKey},
+		"untrusted root": {Identity: testIdentity, Issuer: testIssuer, Roots: newTestCA(t).pem(), LogKey: policy.LogKey},
+		"untrusted log":  {Identity: testIdentity, Issuer: testIssuer, Roots: ca.pem(), LogKey: publicKeyPEM(t, newKey(t))},
+		// Without the log's key the logged time is not trusted, and the
+		// certificate is checked now.
+		"no log key": {Identity: testIdentity, Issuer: testIssuer, Roots: ca.pem()},
+	}
+	for name, p := range rejects {
+		t.Run(name, func(t *testing.T) {
+			if _, err := VerifySignature(ctx, strings.NewReader("hello"), sig, p); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	t.Run("expired certificate without logged time", func(t *testing.T) {
+		sig, err := ParseCosignSignature([]byte(base64.StdEncoding.EncodeToString(rawSig)), certPEM)
+		if err != nil {
+			t.Fatalf("ParseCosignSignature: %v", err)
+		}
+		if _, err := VerifySignature(ctx, strings.NewReader("hello"), sig, policy); err == nil {
+			t.Error("expected error for an expired certificate")
+		}
+	})
+
+	t.Run("forged integrated time", func(t *testing.T) {
+		// A certificate that expired long ago, with the bundle claiming it
+		// was logged while it was still valid.
+		oldAt := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+		old := ca.issue(t, key, testIdentity, testIssuer, oldAt.Add(-time.Minute))
+		oldPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: old.Raw})
+		body, set := log.entry(t, "hello", rawSig, oldPEM, time.Now())
+		sig, err := ParseSigstoreBundle(log.cosignBundle(rawSig, oldPEM, body, set, oldAt))
+		if err != nil {
+			t.Fatalf("ParseSigstoreBundle: %v", err)
+		}
+		if _, err := VerifySignature(ctx, strings.NewReader("hello"), sig, policy); err == nil {
+			t.Error("expected error for a forged integrated time")
+		}
+	})
+
+	t.Run("log entry for another signature", func(t *testing.T) {
+		// A genuine SET from the right time, but for a different entry.
+		other := signBlob(t, newKey(t), "other")
+		body, set := log.entry(t, "other", other, certPEM, signedAt)
+		sig, err := ParseSigstoreBundle(log.cosignBundle(rawSig, certPEM, body, set, signedAt))
+		if err != nil {
+			t.Fatalf("ParseSigstoreBundle: %v", err)
+		}
+		if _, err := VerifySignature(ctx, strings.NewReader("hello"), sig, policy); err == nil {
+			t.Error("expected error for a log entry of another signature")
+		}
+	})
+
+	t.Run("bundle digest for other content", func(t *testing.T) {
+		sig, err := ParseSigstoreBundle(sigstoreBundle)
+		if err != nil {
+			t.Fatalf("ParseSigstoreBundle: %v", err)
+		}
+		_, err = VerifySignature(ctx, strings.NewReader("other"), sig, policy)
+		if err == nil || !strings.Contains(err.Error(), "different content") {
+			t.Errorf("expected digest mismatch, got %v", err)
+		}
+	})
+}
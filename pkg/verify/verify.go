@@ -2,66 +2,103 @@ package verify
 
 import (
 	"context"
+	"crypto/md5"
+	"crypto/sha1"
 	"crypto/sha256"
+	"crypto/sha3"
 	"crypto/sha512"
 	"encoding/hex"
 	"fmt"
+	"hash"
+	"io"
+	"sort"
 	"strings"
+
+	"github.com/ventifus/binmgr/pkg/internal/ctxio"
+	"github.com/zeebo/blake3"
+	"golang.org/x/crypto/blake2b"
 )
 
-// Verifier computes and verifies cryptographic checksums for byte slices.
+// Verifier computes and verifies cryptographic checksums for byte streams.
 type Verifier interface {
-	// Verify checks that data matches all checksums in expected.
+	// Verify reads r to EOF and checks that its content matches all
+	// checksums in expected. Every algorithm is computed in a single pass,
+	// so r may be a download in progress.
 	// Keys in expected use the hyphenated algorithm name (e.g. "sha-256").
 	// All mismatches are collected before returning a single error.
-	// Returns nil if expected is nil or empty.
-	Verify(ctx context.Context, data []byte, expected map[string]string) error
+	// Returns nil without reading r if expected is nil or empty.
+	Verify(ctx context.Context, r io.Reader, expected map[string]string) error
 
-	// Compute returns hex-encoded checksums for data using each named algorithm.
+	// Compute reads r to EOF and returns hex-encoded checksums of its content
+	// using each named algorithm.
 	// Returns an error for any unrecognized algorithm name.
-	Compute(ctx context.Context, data []byte, algorithms []string) (map[string]string, error)
+	Compute(ctx context.Context, r io.Reader, algorithms []string) (map[string]string, error)
 }
 
-type verifier struct{}
+// Policy controls which checksums Verify accepts.
+type Policy struct {
+	// AllowWeak accepts content checked only against weak algorithms (md5,
+	// sha-1). Otherwise weak checksums are still checked, but a strong
+	// algorithm must be checked as well.
+	AllowWeak bool
+}
 
-// NewVerifier returns a Verifier backed by the standard library.
+type verifier struct {
+	policy Policy
+}
+
+// NewVerifier returns a Verifier with the default policy, which does not
+// trust weak algorithms on their own.
 func NewVerifier() Verifier {
 	return &verifier{}
 }
 
-func (v *verifier) Compute(_ context.Context, data []byte, algorithms []string) (map[string]string, error) {
-	result := make(map[string]string, len(algorithms))
+// NewVerifierWithPolicy returns a Verifier that applies p.
+func NewVerifierWithPolicy(p Policy) Verifier {
+	return &verifier{policy: p}
+}
+
+// Weak reports whether algo is too weak to be trusted on its own.
+func Weak(algo string) bool {
+	return algo == "md5" || algo == "sha-1"
+}
+
+// Supported reports whether Verify can check algo.
+func Supported(algo string) bool {
+	_, err := newHash(algo)
+	return err == nil
+}
+
+func (v *verifier) Compute(ctx context.Context, r io.Reader, algorithms []string) (map[string]string, error) {
+	hashes := make(map[string]hash.Hash, len(algorithms))
 	for _, algo := range algorithms {
-		digest, err := computeOne(data, algo)
+		h, err := newHash(algo)
 		if err != nil {
 			return nil, err
 		}
-		result[algo] = digest
+		hashes[algo] = h
+	}
+	if err := hashAll(ctx, r, hashes); err != nil {
+		return nil, err
 	}
-	return result, nil
+	return sums(hashes), nil
 }
 
-func (v *verifier) Verify(_ context.Context, data []byte, expected map[string]string) error {
+func (v *verifier) Verify(ctx context.Context, r io.Reader, expected map[string]string) error {
 	if len(expected) == 0 {
 		return nil
 	}
 
-	var failures []string
-	checked := 0
-	for algo, want := range expected {
-		got, err := computeOne(data, algo)
-		if err != nil {
-			// Unknown algorithm — skip; only fail on algorithms we can compute.
-			continue
-		}
-		checked++
-		if got != want {
-			failures = append(failures, fmt.Sprintf("%s mismatch: expected %s, got %s", algo, want, got))
+	// Unknown algorithms are skipped; only fail on algorithms we can compute.
+	hashes := make(map[string]hash.Hash, len(expected))
+	for algo := range expected {
+		if h, err := newHash(algo); err == nil {
+			hashes[algo] = h
 		}
 	}
 
 	// If every algorithm in expected was unknown, refuse to silently pass.
-	if checked == 0 && len(expected) > 0 {
+	if len(hashes) == 0 {
 		var algos []string
 		for algo := range expected {
 			algos = append(algos, algo)
@@ -69,22 +106,86 @@ func (v *verifier) Verify(_ context.Context, data []byte, expected map[string]st
 		return fmt.Errorf("no supported checksum algorithm found in expected set; got: %s", strings.Join(algos, ", "))
 	}
 
+	// Likewise if only weak algorithms are left, unless the policy allows it.
+	if !v.policy.AllowWeak {
+		strong := false
+		for algo := range hashes {
+			strong = strong || !Weak(algo)
+		}
+		if !strong {
+			var algos []string
+			for algo := range hashes {
+				algos = append(algos, algo)
+			}
+			sort.Strings(algos)
+			return fmt.Errorf("only weak checksum algorithms available (%s); a stronger one is required", strings.Join(algos, ", "))
+		}
+	}
+
+	if err := hashAll(ctx, r, hashes); err != nil {
+		return err
+	}
+
+	var failures []string
+	for algo, got := range sums(hashes) {
+		if want := expected[algo]; got != want {
+			failures = append(failures, fmt.Sprintf("%s mismatch: expected %s, got %s", algo, want, got))
+		}
+	}
+
 	if len(failures) > 0 {
 		return fmt.Errorf("%s", strings.Join(failures, "; "))
 	}
 	return nil
 }
 
-// computeOne returns the hex-encoded digest for a single algorithm.
-func computeOne(data []byte, algo string) (string, error) {
+// newHash returns a fresh hash for a single algorithm.
+func newHash(algo string) (hash.Hash, error) {
 	switch algo {
+	case "md5":
+		return md5.New(), nil
+	case "sha-1":
+		return sha1.New(), nil
+	case "sha-224":
+		return sha256.New224(), nil
 	case "sha-256":
-		sum := sha256.Sum256(data)
-		return hex.EncodeToString(sum[:]), nil
+		return sha256.New(), nil
+	case "sha-384":
+		return sha512.New384(), nil
 	case "sha-512":
-		sum := sha512.Sum512(data)
-		return hex.EncodeToString(sum[:]), nil
+		return sha512.New(), nil
+	case "sha3-256":
+		return sha3.New256(), nil
+	case "sha3-512":
+		return sha3.New512(), nil
+	case "blake2b":
+		return blake2b.New512(nil)
+	case "blake2b-256":
+		return blake2b.New256(nil)
+	case "blake3":
+		return blake3.New(), nil
 	default:
-		return "", fmt.Errorf("unsupported algorithm: %q", algo)
+		return nil, fmt.Errorf("unsupported algorithm: %q", algo)
+	}
+}
+
+// hashAll copies r into every hash in one pass.
+func hashAll(ctx context.Context, r io.Reader, hashes map[string]hash.Hash) error {
+	writers := make([]io.Writer, 0, len(hashes))
+	for _, h := range hashes {
+		writers = append(writers, h)
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), ctxio.Reader(ctx, r)); err != nil {
+		return fmt.Errorf("reading data: %w", err)
+	}
+	return nil
+}
+
+// sums returns the hex-encoded digest of each hash.
+func sums(hashes map[string]hash.Hash) map[string]string {
+	result := make(map[string]string, len(hashes))
+	for algo, h := range hashes {
+		result[algo] = hex.EncodeToString(h.Sum(nil))
 	}
+	return result
 }
@@ -1,9 +1,12 @@
 package verify
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 // Known SHA-256 / SHA-512 vectors.
@@ -18,7 +21,7 @@ func TestCompute(t *testing.T) {
 	v := NewVerifier()
 
 	t.Run("sha-256 of empty string", func(t *testing.T) {
-		got, err := v.Compute(ctx, []byte{}, []string{"sha-256"})
+		got, err := v.Compute(ctx, bytes.NewReader([]byte{}), []string{"sha-256"})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -28,7 +31,7 @@ func TestCompute(t *testing.T) {
 	})
 
 	t.Run("sha-512 of empty string", func(t *testing.T) {
-		got, err := v.Compute(ctx, []byte{}, []string{"sha-512"})
+		got, err := v.Compute(ctx, bytes.NewReader([]byte{}), []string{"sha-512"})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -38,7 +41,7 @@ func TestCompute(t *testing.T) {
 	})
 
 	t.Run("sha-256 of hello", func(t *testing.T) {
-		got, err := v.Compute(ctx, []byte("hello"), []string{"sha-256"})
+		got, err := v.Compute(ctx, bytes.NewReader([]byte("hello")), []string{"sha-256"})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -48,7 +51,7 @@ func TestCompute(t *testing.T) {
 	})
 
 	t.Run("multiple algorithms at once", func(t *testing.T) {
-		got, err := v.Compute(ctx, []byte{}, []string{"sha-256", "sha-512"})
+		got, err := v.Compute(ctx, bytes.NewReader([]byte{}), []string{"sha-256", "sha-512"})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -61,43 +64,108 @@ func TestCompute(t *testing.T) {
 	})
 
 	t.Run("unknown algorithm returns error", func(t *testing.T) {
-		_, err := v.Compute(ctx, []byte("hello"), []string{"md5"})
+		_, err := v.Compute(ctx, bytes.NewReader([]byte("hello")), []string{"crc32"})
 		if err == nil {
 			t.Fatal("expected error for unknown algorithm, got nil")
 		}
-		if !strings.Contains(err.Error(), "md5") {
+		if !strings.Contains(err.Error(), "crc32") {
 			t.Errorf("error should mention the unknown algorithm, got: %v", err)
 		}
 	})
 
 	t.Run("unknown algorithm mixed with valid returns error", func(t *testing.T) {
-		_, err := v.Compute(ctx, []byte("hello"), []string{"sha-256", "sha-1"})
+		_, err := v.Compute(ctx, bytes.NewReader([]byte("hello")), []string{"sha-256", "crc32"})
 		if err == nil {
 			t.Fatal("expected error for unknown algorithm, got nil")
 		}
 	})
 }
 
+func TestCompute_Algorithms(t *testing.T) {
+	// Digests of the empty string.
+	want := map[string]string{
+		"md5":         "d41d8cd98f00b204e9800998ecf8427e",
+		"sha-1":       "da39a3ee5e6b4b0d3255bfef95601890afd80709",
+		"sha-224":     "d14a028c2a3a2bc9476102bb288234c415a2b01f828ea62ac5b3e42f",
+		"sha-256":     sha256Empty,
+		"sha-384":     "38b060a751ac96384cd9327eb1b1e36a21fdb71114be07434c0cc7bf63f6e1da274edebfe76f65fbd51ad2f14898b95b",
+		"sha-512":     sha512Empty,
+		"sha3-256":    "a7ffc6f8bf1ed76651c14756a061d662f580ff4de43b49fa82d80a4b80f8434a",
+		"sha3-512":    "a69f73cca23a9ac5c8b567dc185a756e97c982164fe25859e0d1dcc1475c80a615b2123af1f5f94c11e3e9402c3ac558f500199d95b6d3e301758586281dcd26",
+		"blake2b":     "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce",
+		"blake2b-256": "0e5751c026e543b2e8ab2eb06099daa1d1e5df47778f7787faab45cdf12fe3a8",
+		"blake3":      "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262",
+	}
+	algos := make([]string, 0, len(want))
+	for algo := range want {
+		algos = append(algos, algo)
+	}
+	got, err := NewVerifier().Compute(context.Background(), bytes.NewReader(nil), algos)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for algo, w := range want {
+		if got[algo] != w {
+			t.Errorf("%s('') = %q, want %q", algo, got[algo], w)
+		}
+		if !Supported(algo) {
+			t.Errorf("Supported(%q) = false", algo)
+		}
+	}
+	if Supported("crc32") {
+		t.Error(`Supported("crc32") = true`)
+	}
+}
+
+func TestVerify_WeakAlgorithms(t *testing.T) {
+	ctx := context.Background()
+	const (
+		md5Empty  = "d41d8cd98f00b204e9800998ecf8427e"
+		sha1Empty = "da39a3ee5e6b4b0d3255bfef95601890afd80709"
+	)
+	weakOnly := map[string]string{"md5": md5Empty, "sha-1": sha1Empty}
+
+	t.Run("weak algorithms alone are refused", func(t *testing.T) {
+		err := NewVerifier().Verify(ctx, bytes.NewReader(nil), weakOnly)
+		if err == nil || !strings.Contains(err.Error(), "weak") {
+			t.Fatalf("expected weak algorithm error, got %v", err)
+		}
+	})
+
+	t.Run("weak algorithms alone are accepted when allowed", func(t *testing.T) {
+		if err := NewVerifierWithPolicy(Policy{AllowWeak: true}).Verify(ctx, bytes.NewReader(nil), weakOnly); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("weak algorithm alongside a strong one is still checked", func(t *testing.T) {
+		err := NewVerifier().Verify(ctx, bytes.NewReader(nil), map[string]string{"sha-256": sha256Empty, "md5": sha256Empty})
+		if err == nil || !strings.Contains(err.Error(), "md5") {
+			t.Fatalf("expected md5 mismatch, got %v", err)
+		}
+	})
+}
+
 func TestVerify(t *testing.T) {
 	ctx := context.Background()
 	v := NewVerifier()
 
 	t.Run("correct sha-256 returns nil", func(t *testing.T) {
-		err := v.Verify(ctx, []byte{}, map[string]string{"sha-256": sha256Empty})
+		err := v.Verify(ctx, bytes.NewReader([]byte{}), map[string]string{"sha-256": sha256Empty})
 		if err != nil {
 			t.Errorf("expected nil error for correct checksum, got: %v", err)
 		}
 	})
 
 	t.Run("correct sha-512 returns nil", func(t *testing.T) {
-		err := v.Verify(ctx, []byte{}, map[string]string{"sha-512": sha512Empty})
+		err := v.Verify(ctx, bytes.NewReader([]byte{}), map[string]string{"sha-512": sha512Empty})
 		if err != nil {
 			t.Errorf("expected nil error for correct checksum, got: %v", err)
 		}
 	})
 
 	t.Run("correct both algorithms returns nil", func(t *testing.T) {
-		err := v.Verify(ctx, []byte{}, map[string]string{
+		err := v.Verify(ctx, bytes.NewReader([]byte{}), map[string]string{
 			"sha-256": sha256Empty,
 			"sha-512": sha512Empty,
 		})
@@ -107,7 +175,7 @@ func TestVerify(t *testing.T) {
 	})
 
 	t.Run("wrong sha-256 returns error naming algorithm", func(t *testing.T) {
-		err := v.Verify(ctx, []byte("hello"), map[string]string{"sha-256": sha256Empty})
+		err := v.Verify(ctx, bytes.NewReader([]byte("hello")), map[string]string{"sha-256": sha256Empty})
 		if err == nil {
 			t.Fatal("expected error for wrong checksum, got nil")
 		}
@@ -117,7 +185,7 @@ func TestVerify(t *testing.T) {
 	})
 
 	t.Run("wrong sha-512 returns error naming algorithm", func(t *testing.T) {
-		err := v.Verify(ctx, []byte("hello"), map[string]string{"sha-512": sha512Empty})
+		err := v.Verify(ctx, bytes.NewReader([]byte("hello")), map[string]string{"sha-512": sha512Empty})
 		if err == nil {
 			t.Fatal("expected error for wrong checksum, got nil")
 		}
@@ -127,7 +195,7 @@ func TestVerify(t *testing.T) {
 	})
 
 	t.Run("multiple wrong algorithms error mentions all", func(t *testing.T) {
-		err := v.Verify(ctx, []byte("hello"), map[string]string{
+		err := v.Verify(ctx, bytes.NewReader([]byte("hello")), map[string]string{
 			"sha-256": sha256Empty, // wrong for "hello"
 			"sha-512": sha512Empty, // wrong for "hello"
 		})
@@ -143,21 +211,21 @@ func TestVerify(t *testing.T) {
 	})
 
 	t.Run("empty expected map returns nil", func(t *testing.T) {
-		err := v.Verify(ctx, []byte("hello"), map[string]string{})
+		err := v.Verify(ctx, bytes.NewReader([]byte("hello")), map[string]string{})
 		if err != nil {
 			t.Errorf("expected nil for empty expected map, got: %v", err)
 		}
 	})
 
 	t.Run("nil expected map returns nil", func(t *testing.T) {
-		err := v.Verify(ctx, []byte("hello"), nil)
+		err := v.Verify(ctx, bytes.NewReader([]byte("hello")), nil)
 		if err != nil {
 			t.Errorf("expected nil for nil expected map, got: %v", err)
 		}
 	})
 
 	t.Run("one correct one wrong reports only the failure", func(t *testing.T) {
-		err := v.Verify(ctx, []byte{}, map[string]string{
+		err := v.Verify(ctx, bytes.NewReader([]byte{}), map[string]string{
 			"sha-256": sha256Empty, // correct for ""
 			"sha-512": sha256Empty, // wrong: using sha-256 digest for sha-512 slot
 		})
@@ -170,9 +238,9 @@ func TestVerify(t *testing.T) {
 	})
 
 	t.Run("unknown algorithm is silently skipped when a known algorithm passes", func(t *testing.T) {
-		err := v.Verify(ctx, []byte{}, map[string]string{
-			"sha-256":  sha256Empty, // correct — supported
-			"sha3-512": "somehash",  // unknown — should be skipped
+		err := v.Verify(ctx, bytes.NewReader([]byte{}), map[string]string{
+			"sha-256": sha256Empty, // correct — supported
+			"crc32":   "somehash",  // unknown — should be skipped
 		})
 		if err != nil {
 			t.Errorf("expected nil when known algorithm passes and unknown is skipped, got: %v", err)
@@ -180,12 +248,55 @@ func TestVerify(t *testing.T) {
 	})
 
 	t.Run("all-unknown algorithms returns error", func(t *testing.T) {
-		err := v.Verify(ctx, []byte("hello"), map[string]string{
-			"sha3-256": "abc",
-			"btih":     "def",
+		err := v.Verify(ctx, bytes.NewReader([]byte("hello")), map[string]string{
+			"crc32": "abc",
+			"btih":  "def",
 		})
 		if err == nil {
 			t.Fatal("expected error when no supported algorithm is found, got nil")
 		}
 	})
 }
+
+func TestVerifyStreaming(t *testing.T) {
+	ctx := context.Background()
+	v := NewVerifier()
+
+	t.Run("all algorithms computed in one pass over a trickling reader", func(t *testing.T) {
+		r := iotest.OneByteReader(strings.NewReader("hello"))
+		got, err := v.Compute(ctx, r, []string{"sha-256", "sha-512"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got["sha-256"] != sha256Hello {
+			t.Errorf("sha-256('hello') = %q, want %q", got["sha-256"], sha256Hello)
+		}
+	})
+
+	t.Run("verify consumes the reader", func(t *testing.T) {
+		r := strings.NewReader("hello")
+		if err := v.Verify(ctx, r, map[string]string{"sha-256": sha256Hello}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if r.Len() != 0 {
+			t.Errorf("expected reader to be drained, %d bytes left", r.Len())
+		}
+	})
+
+	t.Run("read error is reported", func(t *testing.T) {
+		r := iotest.ErrReader(errors.New("connection reset"))
+		err := v.Verify(ctx, r, map[string]string{"sha-256": sha256Empty})
+		if err == nil || !strings.Contains(err.Error(), "connection reset") {
+			t.Errorf("expected read error, got %v", err)
+		}
+	})
+
+	t.Run("cancelled context stops reading", func(t *testing.T) {
+		cctx, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := v.Compute(cctx, strings.NewReader("hello"), []string{"sha-256"})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	})
+}
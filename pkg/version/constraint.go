@@ -0,0 +1,204 @@
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Constraint is a version constraint expression such as "~1.29",
+// "<2.0", ">=1.2, <1.5" or "^1.4 || ^2.0".
+//
+// Comparisons ignore a version's prefix. Ranges implied by "~", "^", "~>"
+// and partial versions ("1.29", "1.29.x") exclude the prereleases of their
+// exclusive upper bound, so "~1.29" does not admit "1.30.0-rc.1".
+type Constraint struct {
+	expr string
+	alts [][]comparator // alternatives separated by "||", each a conjunction
+}
+
+// comparator is one bound of a constraint.
+type comparator struct {
+	op string // "=", "!=", "<", "<=", ">", ">="
+	v  Version
+}
+
+// ParseConstraint parses a constraint expression. Within an alternative,
+// comparators are separated by commas or spaces and must all hold.
+func ParseConstraint(expr string) (*Constraint, error) {
+	c := &Constraint{expr: expr}
+	for _, alt := range strings.Split(expr, "||") {
+		fields := strings.Fields(strings.ReplaceAll(alt, ",", " "))
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("invalid constraint %q: empty alternative", expr)
+		}
+		var cmps []comparator
+		for i := 0; i < len(fields); i++ {
+			tok := fields[i]
+			// Allow a space between operator and version: ">= 1.2".
+			if strings.TrimLeft(tok, "<>=!~^") == "" && i+1 < len(fields) {
+				i++
+				tok += fields[i]
+			}
+			cs, err := parseComparator(tok)
+			if err != nil {
+				return nil, fmt.Errorf("invalid constraint %q: %w", expr, err)
+			}
+			cmps = append(cmps, cs...)
+		}
+		c.alts = append(c.alts, cmps)
+	}
+	return c, nil
+}
+
+// String returns the expression the constraint was parsed from.
+func (c *Constraint) String() string {
+	return c.expr
+}
+
+// Allows reports whether version s satisfies the constraint. A version that
+// does not parse never does.
+func (c *Constraint) Allows(s string) bool {
+	v, ok := Parse(s)
+	if !ok {
+		return false
+	}
+	for _, alt := range c.alts {
+		if allHold(alt, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func allHold(cmps []comparator, v Version) bool {
+	for _, cmp := range cmps {
+		d := v.Compare(cmp.v)
+		var ok bool
+		switch cmp.op {
+		case "=":
+			ok = d == 0
+		case "!=":
+			ok = d != 0
+		case "<":
+			ok = d < 0
+		case "<=":
+			ok = d <= 0
+		case ">":
+			ok = d > 0
+		case ">=":
+			ok = d >= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// parseComparator parses one operator-and-version token into the bounds it
+// stands for.
+func parseComparator(tok string) ([]comparator, error) {
+	var op string
+	for _, o := range []string{"~>", ">=", "<=", "!=", "==", ">", "<", "=", "~", "^"} {
+		if strings.HasPrefix(tok, o) {
+			op, tok = o, tok[len(o):]
+			break
+		}
+	}
+
+	nums, wild, pre, err := parseSpec(tok)
+	if err != nil {
+		return nil, err
+	}
+	if len(nums) == 0 {
+		// "*" alone: any version, with no upper bound.
+		if op != "" && op != "=" && op != "==" {
+			return nil, fmt.Errorf("wildcard %q not allowed with %s", tok, op)
+		}
+		return []comparator{{">=", Version{Release: []int{0}}}}, nil
+	}
+	lower := Version{Release: nums, Prerelease: pre}
+
+	switch op {
+	case "", "=", "==":
+		if !wild && len(nums) >= 3 {
+			return []comparator{{"=", lower}}, nil
+		}
+		// A partial version is the range of versions it is a prefix of.
+		return bounded(lower, upper(nums, len(nums)-1)), nil
+	case "!=", "<", "<=", ">", ">=":
+		if wild {
+			return nil, fmt.Errorf("wildcard %q not allowed with %s", tok, op)
+		}
+		return []comparator{{op, lower}}, nil
+	case "~":
+		// ~1 → <2; ~1.29 and ~1.29.3 → <1.30.
+		return bounded(lower, upper(nums, min(len(nums)-1, 1))), nil
+	case "~>":
+		// ~>1.2 → <2; ~>1.2.3 → <1.3: the last given component may change.
+		return bounded(lower, upper(nums, max(len(nums)-2, 0))), nil
+	case "^":
+		// The first non-zero component may not change.
+		i := 0
+		for i < len(nums)-1 && nums[i] == 0 {
+			i++
+		}
+		return bounded(lower, upper(nums, i)), nil
+	}
+	return nil, fmt.Errorf("unknown operator %q", op)
+}
+
+// bounded returns the range lower <= v < up.
+func bounded(lower, up Version) []comparator {
+	return []comparator{{">=", lower}, {"<", up}}
+}
+
+// upper returns the exclusive upper bound that increments component i of
+// nums and drops the rest. Its "0" prerelease sorts before every prerelease
+// of that version, excluding them from the range.
+func upper(nums []int, i int) Version {
+	up := make([]int, i+1)
+	copy(up, nums[:i+1])
+	up[i]++
+	return Version{Release: up, Prerelease: []string{"0"}}
+}
+
+// parseSpec parses the version part of a comparator: an optional prefix
+// such as "v", dot-separated numbers that may end in a wildcard ("x", "X"
+// or "*"), and an optional prerelease. The prefix is dropped, as it is when
+// comparing. A bare wildcard yields no numbers.
+func parseSpec(s string) (nums []int, wild bool, pre []string, err error) {
+	if s != "x" && s != "X" && s != "*" {
+		s = strings.TrimLeftFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	}
+	if s == "" {
+		return nil, false, nil, fmt.Errorf("missing version")
+	}
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		pre = strings.Split(s[i+1:], ".")
+		s = s[:i]
+	}
+	for _, p := range strings.Split(s, ".") {
+		if p == "x" || p == "X" || p == "*" {
+			wild = true
+			break
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, false, nil, fmt.Errorf("invalid version %q", s)
+		}
+		nums = append(nums, n)
+	}
+	if len(nums) == 0 {
+		// "*" alone: any version.
+		return nil, true, nil, nil
+	}
+	if wild && pre != nil {
+		return nil, false, nil, fmt.Errorf("wildcard version %q cannot have a prerelease", s)
+	}
+	return nums, wild, pre, nil
+}
@@ -0,0 +1,75 @@
+package version
+
+import "testing"
+
+func TestConstraintAllows(t *testing.T) {
+	tests := []struct {
+		expr    string
+		version string
+		want    bool
+	}{
+		{"~1.29", "v1.29.0", true},
+		{"~1.29", "v1.29.14", true},
+		{"~1.29", "v1.30.0", false},
+		{"~1.29", "v1.30.0-rc.1", false},
+		{"~1.29", "v1.28.9", false},
+		{"~1.29.3", "1.29.2", false},
+		{"~1.29.3", "1.29.7", true},
+		{"~1", "1.99.0", true},
+		{"~1", "2.0.0", false},
+		{"<2.0", "1.9.8", true},
+		{"<2.0", "2.0.0", false},
+		{"< 2.0", "v1.14.3", true},
+		{">=1.2, <1.5", "1.4.9", true},
+		{">=1.2, <1.5", "1.5.0", false},
+		{">=1.2 <1.5", "1.1.0", false},
+		{"^1.4", "1.9.0", true},
+		{"^1.4", "2.0.0", false},
+		{"^0.4.2", "0.4.9", true},
+		{"^0.4.2", "0.5.0", false},
+		{"~>1.2", "1.9.0", true},
+		{"~>1.2.3", "1.3.0", false},
+		{"1.29", "v1.29.5", true},
+		{"1.29.x", "v1.30.0", false},
+		{"1.29.3", "v1.29.3", true},
+		{"=1.29.3", "v1.29.4", false},
+		{"!=1.29.3", "v1.29.4", true},
+		{"*", "v0.0.1", true},
+		{"*", "v3.1.0", true},
+		{"*", "v2.0.0-rc.1", true},
+		{"x", "v3.1.0", true},
+		{"x", "v2.0.0-rc.1", true},
+		{"= *", "12.0.0", true},
+		{"^1.4 || ^2.0", "2.3.0", true},
+		{"^1.4 || ^2.0", "3.0.0", false},
+		{"knative-v1.19", "knative-v1.19.5", true},
+		{"~1.29", "nightly", false},
+	}
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.expr)
+		if err != nil {
+			t.Errorf("ParseConstraint(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := c.Allows(tt.version); got != tt.want {
+			t.Errorf("%q.Allows(%q) = %v, want %v", tt.expr, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestParseConstraintRejects(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"~",
+		">=abc",
+		"<1.x",
+		"1.2 ||",
+		"1.x-rc.1",
+		"<*",
+		"~x",
+	} {
+		if _, err := ParseConstraint(expr); err == nil {
+			t.Errorf("ParseConstraint(%q) succeeded, want error", expr)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Package version orders release version strings: semantic versions, tags
+// with a product prefix such as "knative-v1.19.5", and calendar versions
+// such as "2024.01.15" or "2024-01-15".
+package version
+
+import (
+	"cmp"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version is a release version split into its comparable parts.
+type Version struct {
+	Prefix     string   // text before the numbers, e.g. "v" or "knative-v"
+	Release    []int    // dot-separated numeric components
+	Prerelease []string // dot-separated identifiers after '-'; empty for a release
+}
+
+// semverRe matches the version after its prefix. The prefix is the shortest
+// digit-free leading text that lets the rest match.
+var semverRe = regexp.MustCompile(`^([^0-9]*?)(\d+(?:\.\d+)*)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// calverRe matches a dash-separated calendar date, optionally followed by a
+// build counter, which semverRe would read as a prerelease.
+var calverRe = regexp.MustCompile(`^([^0-9]*?)(\d{4})-(\d{1,2})-(\d{1,2})(?:[.-](\d+))?$`)
+
+// Parse splits s into a Version. It returns false if s has no recognisable
+// numeric version, or if digits appear before it (as in a content hash).
+func Parse(s string) (Version, bool) {
+	if m := calverRe.FindStringSubmatch(s); m != nil {
+		v := Version{Prefix: m[1]}
+		for _, p := range m[2:] {
+			if p == "" {
+				continue
+			}
+			n, err := strconv.Atoi(p)
+			if err != nil {
+				return Version{}, false
+			}
+			v.Release = append(v.Release, n)
+		}
+		return v, true
+	}
+
+	m := semverRe.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, false
+	}
+	v := Version{Prefix: m[1]}
+	for _, p := range strings.Split(m[2], ".") {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return Version{}, false
+		}
+		v.Release = append(v.Release, n)
+	}
+	if m[3] != "" {
+		v.Prerelease = strings.Split(m[3], ".")
+	}
+	return v, true
+}
+
+// Compare orders a and b, returning -1, 0 or +1. It returns false if they
+// cannot be ordered: either does not parse, or their prefixes differ (other
+// than a leading "v"), so they likely name different products.
+func Compare(a, b string) (int, bool) {
+	va, ok := Parse(a)
+	if !ok {
+		return 0, false
+	}
+	vb, ok := Parse(b)
+	if !ok {
+		return 0, false
+	}
+	if normalizePrefix(va.Prefix) != normalizePrefix(vb.Prefix) {
+		return 0, false
+	}
+	return va.Compare(vb), true
+}
+
+// normalizePrefix drops a trailing "v" so that "1.2.3" and "v1.2.3" compare.
+func normalizePrefix(p string) string {
+	return strings.TrimSuffix(strings.ToLower(p), "v")
+}
+
+// Compare orders v and w by release numbers, then by semver prerelease
+// precedence. The prefix is ignored. Missing release components count as 0.
+func (v Version) Compare(w Version) int {
+	for i := 0; i < max(len(v.Release), len(w.Release)); i++ {
+		var a, b int
+		if i < len(v.Release) {
+			a = v.Release[i]
+		}
+		if i < len(w.Release) {
+			b = w.Release[i]
+		}
+		if a != b {
+			return cmp.Compare(a, b)
+		}
+	}
+
+	// A release sorts after any of its prereleases.
+	switch {
+	case len(v.Prerelease) == 0 && len(w.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(w.Prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < min(len(v.Prerelease), len(w.Prerelease)); i++ {
+		if c := compareIdentifier(v.Prerelease[i], w.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(v.Prerelease), len(w.Prerelease))
+}
+
+// compareIdentifier orders two prerelease identifiers: numeric identifiers
+// numerically and before alphanumeric ones, which compare as strings.
+func compareIdentifier(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return cmp.Compare(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,92 @@
+package version
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in         string
+		prefix     string
+		release    []int
+		prerelease []string
+	}{
+		{"1.2.3", "", []int{1, 2, 3}, nil},
+		{"v1.2.3", "v", []int{1, 2, 3}, nil},
+		{"v1.2.3-rc.1+build.5", "v", []int{1, 2, 3}, []string{"rc", "1"}},
+		{"v1.2.3-beta-2", "v", []int{1, 2, 3}, []string{"beta-2"}},
+		{"knative-v1.19.5", "knative-v", []int{1, 19, 5}, nil},
+		{"2024.01.15", "", []int{2024, 1, 15}, nil},
+		{"2024-01-15", "", []int{2024, 1, 15}, nil},
+		{"release-2024-01-15.2", "release-", []int{2024, 1, 15, 2}, nil},
+		{"jq-1.7", "jq-", []int{1, 7}, nil},
+	}
+	for _, tt := range tests {
+		v, ok := Parse(tt.in)
+		if !ok {
+			t.Errorf("Parse(%q) failed", tt.in)
+			continue
+		}
+		if v.Prefix != tt.prefix || !slices.Equal(v.Release, tt.release) || !slices.Equal(v.Prerelease, tt.prerelease) {
+			t.Errorf("Parse(%q) = %+v, want prefix %q release %v prerelease %v", tt.in, v, tt.prefix, tt.release, tt.prerelease)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"latest",
+		"3fa9c0d1e2b4a59687f0e1d2c3b4a5968778695a4b3c2d1e0f9a8b7c6d5e4f3a",
+		"k8s-v1.2",
+	} {
+		if v, ok := Parse(in); ok {
+			t.Errorf("Parse(%q) = %+v, want failure", in, v)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2", "1.2.0", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"1.10.0", "1.9.0", 1},
+		{"v2.0.0", "v1.99.99", 1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-rc.1", "1.0.0-beta", 1},
+		{"knative-v1.19.5", "knative-v1.20.0", -1},
+		{"2024.01.15", "2023.12.31", 1},
+		{"2024-01-15", "2024-02-01", -1},
+	}
+	for _, tt := range tests {
+		got, ok := Compare(tt.a, tt.b)
+		if !ok {
+			t.Errorf("Compare(%q, %q) not comparable", tt.a, tt.b)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCompareIncomparable(t *testing.T) {
+	for _, tt := range []struct{ a, b string }{
+		{"knative-v1.19.5", "client-v1.20.0"},
+		{"v1.0.0", "nightly"},
+		{"3fa9c0d1e2b4a59687f0e1d2c3b4a596", "1.0.0"},
+	} {
+		if got, ok := Compare(tt.a, tt.b); ok {
+			t.Errorf("Compare(%q, %q) = %d, want not comparable", tt.a, tt.b, got)
+		}
+	}
+}